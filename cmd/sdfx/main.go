@@ -0,0 +1,276 @@
+//-----------------------------------------------------------------------------
+/*
+
+sdfx: build models from the registered object generators
+
+	sdfx list
+	sdfx parms <generator> [parameter flags]
+	sdfx build <generator> [-parms file.json] [-o output] [-r renderer] [-cells n] [parameter flags]
+	sdfx render <model.json|model.yaml> [-o output] [-r renderer] [-cells n]
+	sdfx preview <generator|model.json|model.yaml> [-addr host:port] [-cells n] [-parms file.json] [parameter flags]
+
+E.g.
+
+	sdfx build Bolt -Thread M16x2 -TotalLength 50 -o bolt.stl
+	sdfx parms InvoluteGear > gear.json
+	sdfx build InvoluteGear -parms gear.json -NumberTeeth 40 -o gear.dxf
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadsy/sdfx/obj"
+	"github.com/deadsy/sdfx/preview"
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: sdfx <command> [arguments]\n\n")
+	fmt.Fprintf(os.Stderr, "  list                      list the object generators\n")
+	fmt.Fprintf(os.Stderr, "  parms <generator> [flags] print the generator parameters as JSON\n")
+	fmt.Fprintf(os.Stderr, "  build <generator> [flags] build an object\n")
+	fmt.Fprintf(os.Stderr, "  render <model> [flags]    render a saved model (.json .yaml)\n")
+	fmt.Fprintf(os.Stderr, "  preview <name> [flags]    live preview of a generator or saved model\n\n")
+	fmt.Fprintf(os.Stderr, "use \"sdfx <command> <name> -h\" for the command flags\n")
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "sdfx: %s\n", err)
+	os.Exit(1)
+}
+
+//-----------------------------------------------------------------------------
+
+// cmdList lists the object generators.
+func cmdList(args []string) error {
+	for _, g := range obj.Generators() {
+		fmt.Printf("%-20s %s\n", g.Name, g.Doc)
+	}
+	return nil
+}
+
+// generatorArgs are the generator command line arguments.
+type generatorArgs struct {
+	g    *obj.Generator
+	ps   *parmSet
+	file string // parameter file
+}
+
+// generatorFlags parses the generator name and parameter flags.
+// Other command flags are added by the flags function.
+func generatorFlags(cmd string, args []string, flags func(fs *flag.FlagSet)) (*generatorArgs, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return nil, fmt.Errorf("%s: no generator name", cmd)
+	}
+	g, err := obj.Lookup(args[0])
+	if err != nil {
+		return nil, err
+	}
+	a := &generatorArgs{g: g}
+	fs := flag.NewFlagSet(cmd+" "+g.Name, flag.ExitOnError)
+	fs.StringVar(&a.file, "parms", "", "parameter file (.json)")
+	if flags != nil {
+		flags(fs)
+	}
+	a.ps = addParmFlags(fs, g.Parms())
+	fs.Parse(args[1:])
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("%s: unexpected arguments %v", cmd, fs.Args())
+	}
+	return a, nil
+}
+
+// parms returns the generator parameters from the parameter file and flags.
+func (a *generatorArgs) parms() (any, error) {
+	k := a.g.Parms()
+	if a.file != "" {
+		b, err := os.ReadFile(a.file)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, k); err != nil {
+			return nil, fmt.Errorf("%s: %s", a.file, err)
+		}
+	}
+	a.ps.k = k
+	a.ps.apply()
+	return k, nil
+}
+
+// cmdParms prints the generator parameters.
+func cmdParms(args []string) error {
+	a, err := generatorFlags("parms", args, nil)
+	if err != nil {
+		return err
+	}
+	parms, err := a.parms()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(parms, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// cmdBuild builds an object with a generator.
+func cmdBuild(args []string) error {
+	k := &outputParms{}
+	a, err := generatorFlags("build", args, func(fs *flag.FlagSet) { addOutputFlags(fs, k) })
+	if err != nil {
+		return err
+	}
+	parms, err := a.parms()
+	if err != nil {
+		return err
+	}
+	g := a.g
+	s, err := obj.Generate(g.Name, parms)
+	if err != nil {
+		return err
+	}
+	if k.path == "" {
+		k.path = g.Name + defaultExt(s)
+	}
+	if parts, ok := s.([]sdf.SDF3); ok {
+		path := k.path
+		for i, part := range parts {
+			name := fmt.Sprintf("%d", i)
+			if i < len(g.Parts) {
+				name = g.Parts[i]
+			}
+			k.path = partPath(path, name)
+			if err := writeModel(part, k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return writeModel(s, k)
+}
+
+// cmdRender renders a saved model.
+func cmdRender(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("render: no model file")
+	}
+	k := &outputParms{}
+	fs := flag.NewFlagSet("render "+args[0], flag.ExitOnError)
+	addOutputFlags(fs, k)
+	fs.Parse(args[1:])
+	s, err := sdf.LoadModel(args[0])
+	if err != nil {
+		return err
+	}
+	if k.path == "" {
+		name := args[0]
+		if i := strings.LastIndex(name, "."); i > 0 {
+			name = name[:i]
+		}
+		k.path = name + defaultExt(s)
+	}
+	return writeModel(s, k)
+}
+
+// cmdPreview serves a live preview of a generator or a saved model.
+func cmdPreview(args []string) error {
+	k := &preview.Parms{}
+	flags := func(fs *flag.FlagSet) {
+		fs.StringVar(&k.Addr, "addr", "localhost:8080", "listen address")
+		fs.IntVar(&k.Cells, "cells", 100, "initial mesh cells along the longest axis")
+	}
+	if len(args) != 0 && isModelFile(args[0]) {
+		fs := flag.NewFlagSet("preview "+args[0], flag.ExitOnError)
+		flags(fs)
+		fs.Parse(args[1:])
+		k.Watch = []string{args[0]}
+		s := preview.NewServer(k)
+		s.Register(args[0], preview.WatchFile(args[0]))
+		return s.ListenAndServe()
+	}
+	a, err := generatorFlags("preview", args, flags)
+	if err != nil {
+		return err
+	}
+	if a.file != "" {
+		k.Watch = []string{a.file}
+	}
+	s := preview.NewServer(k)
+	// model function for the object (part < 0) or a part of a multi-part object
+	model := func(part int) preview.ModelFunc {
+		return func() (any, error) {
+			parms, err := a.parms()
+			if err != nil {
+				return nil, err
+			}
+			x, err := obj.Generate(a.g.Name, parms)
+			if err != nil {
+				return nil, err
+			}
+			if parts, ok := x.([]sdf.SDF3); ok && part >= 0 && part < len(parts) {
+				return parts[part], nil
+			}
+			return x, nil
+		}
+	}
+	if len(a.g.Parts) == 0 {
+		s.Register(a.g.Name, model(-1))
+	}
+	for i, part := range a.g.Parts {
+		s.Register(a.g.Name+"_"+part, model(i))
+	}
+	return s.ListenAndServe()
+}
+
+// isModelFile returns true if the filename is a saved model.
+func isModelFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".json" || ext == ".yaml" || ext == ".yml"
+}
+
+// defaultExt returns the default output file extension for an object.
+func defaultExt(s any) string {
+	if _, ok := s.(sdf.SDF2); ok {
+		return ".svg"
+	}
+	return ".stl"
+}
+
+//-----------------------------------------------------------------------------
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmds := map[string]func([]string) error{
+		"list":    cmdList,
+		"parms":   cmdParms,
+		"build":   cmdBuild,
+		"render":  cmdRender,
+		"preview": cmdPreview,
+	}
+	fn, ok := cmds[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := fn(os.Args[2:]); err != nil {
+		fatal(err)
+	}
+}
+
+//-----------------------------------------------------------------------------
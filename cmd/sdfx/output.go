@@ -0,0 +1,147 @@
+//-----------------------------------------------------------------------------
+/*
+
+Model Output
+
+Write an SDF2/SDF3 to a file, the format is selected by the file extension.
+
+3d: stl, 3mf, json, yaml
+2d: dxf, svg, png, json, yaml
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadsy/sdfx/render"
+	"github.com/deadsy/sdfx/sdf"
+	"github.com/deadsy/sdfx/vec/v2i"
+)
+
+//-----------------------------------------------------------------------------
+
+const svgLineStyle = "fill:none;stroke:black;stroke-width:0.1"
+
+// outputParms are the rendering and output options.
+type outputParms struct {
+	path     string // output filename
+	renderer string // rendering method
+	cells    int    // mesh cells along the longest bounding box axis
+	quiet    bool   // no progress output
+}
+
+// addOutputFlags adds the rendering and output flags.
+func addOutputFlags(fs *flag.FlagSet, k *outputParms) {
+	fs.StringVar(&k.path, "o", "", "output file (.stl .3mf .dxf .svg .png .json .yaml)")
+	fs.StringVar(&k.renderer, "r", "", "renderer, 3d: octree|uniform, 2d: quadtree|uniform|dc")
+	fs.IntVar(&k.cells, "cells", 200, "mesh cells along the longest axis")
+	fs.BoolVar(&k.quiet, "q", false, "quiet, no progress output")
+}
+
+// renderer3 returns the 3d renderer.
+func renderer3(name string, cells int) (render.Render3, error) {
+	switch name {
+	case "", "octree":
+		return render.NewMarchingCubesOctree(cells), nil
+	case "uniform":
+		return render.NewMarchingCubesUniform(cells), nil
+	}
+	return nil, fmt.Errorf("unknown 3d renderer \"%s\"", name)
+}
+
+// renderer2 returns the 2d renderer.
+func renderer2(name string, cells int) (render.Render2, error) {
+	switch name {
+	case "", "quadtree":
+		return render.NewMarchingSquaresQuadtree(cells), nil
+	case "uniform":
+		return render.NewMarchingSquaresUniform(cells), nil
+	case "dc":
+		return render.NewDualContouring2D(cells), nil
+	}
+	return nil, fmt.Errorf("unknown 2d renderer \"%s\"", name)
+}
+
+// partPath returns the output filename for a part of a multi-part object.
+func partPath(path, part string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + part + ext
+}
+
+//-----------------------------------------------------------------------------
+
+// writeModel writes an SDF2/SDF3 to a file.
+func writeModel(s any, k *outputParms) error {
+	ext := strings.ToLower(filepath.Ext(k.path))
+	if ext == ".json" || ext == ".yaml" || ext == ".yml" {
+		return sdf.SaveModel(k.path, s)
+	}
+	switch x := s.(type) {
+	case sdf.SDF3:
+		return write3(x, ext, k)
+	case sdf.SDF2:
+		return write2(x, ext, k)
+	}
+	return fmt.Errorf("%T is not an SDF2 or SDF3", s)
+}
+
+// write3 renders an SDF3 to a file.
+func write3(s sdf.SDF3, ext string, k *outputParms) error {
+	r, err := renderer3(k.renderer, k.cells)
+	if err != nil {
+		return err
+	}
+	if !k.quiet {
+		fmt.Printf("rendering %s (%s)\n", k.path, r.Info(s))
+	}
+	switch ext {
+	case ".stl":
+		return render.SaveSTL(k.path, render.ToTriangles(s, r))
+	case ".3mf":
+		render.To3MF(s, k.path, r)
+		return nil
+	}
+	return fmt.Errorf("%s: unsupported 3d output format", k.path)
+}
+
+// write2 renders an SDF2 to a file.
+func write2(s sdf.SDF2, ext string, k *outputParms) error {
+	if ext == ".png" {
+		bb := s.BoundingBox()
+		bb = bb.ScaleAboutCenter(1.1)
+		size := bb.Size()
+		scale := float64(k.cells) / max(size.X, size.Y)
+		pixels := v2i.Vec{int(size.X * scale), int(size.Y * scale)}
+		if !k.quiet {
+			fmt.Printf("rendering %s (%dx%d)\n", k.path, pixels.X, pixels.Y)
+		}
+		d, err := render.NewPNG(k.path, bb, pixels)
+		if err != nil {
+			return err
+		}
+		d.RenderSDF2(s)
+		return d.Save()
+	}
+	r, err := renderer2(k.renderer, k.cells)
+	if err != nil {
+		return err
+	}
+	if !k.quiet {
+		fmt.Printf("rendering %s (%s)\n", k.path, r.Info(s))
+	}
+	switch ext {
+	case ".dxf":
+		return render.SaveDXF(k.path, render.ToLines(s, r))
+	case ".svg":
+		return render.SaveSVG(k.path, svgLineStyle, render.ToLines(s, r))
+	}
+	return fmt.Errorf("%s: unsupported 2d output format", k.path)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,273 @@
+//-----------------------------------------------------------------------------
+/*
+
+Generator Parameter Flags
+
+Each field of a generator parameter struct is a command line flag.
+Nested structs use dotted names (E.g. -Gear.NumberTeeth 30), vectors and
+arrays are comma separated lists (E.g. -Size 100,60) and floats may have a
+"deg" suffix to give an angle in degrees (E.g. -PressureAngle 20deg).
+
+The flag values are applied after any parameter file, so the command line
+overrides the file.
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+// parmSet is the set of parameter flags for a parameter struct.
+type parmSet struct {
+	k   any          // pointer to the parameter struct
+	set []*parmValue // flag values in command line order
+}
+
+// parmValue is a parameter value set on the command line.
+type parmValue struct {
+	f     *parmFlag
+	value reflect.Value
+}
+
+// parmFlag is a command line flag for a field of a parameter struct.
+type parmFlag struct {
+	ps   *parmSet
+	typ  reflect.Type // field type
+	path []int        // field index path from the parameter struct
+}
+
+// field returns the struct field for the flag.
+// Nil struct pointers on the path are allocated if alloc is true.
+func (f *parmFlag) field(alloc bool) (reflect.Value, bool) {
+	v := reflect.ValueOf(f.ps.k).Elem()
+	for _, i := range f.path {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+func (f *parmFlag) String() string {
+	if f == nil || f.ps == nil {
+		return ""
+	}
+	v, ok := f.field(false)
+	if !ok {
+		return ""
+	}
+	return formatValue(v)
+}
+
+func (f *parmFlag) Set(s string) error {
+	v, err := parseValue(f.typ, s)
+	if err != nil {
+		return err
+	}
+	f.ps.set = append(f.ps.set, &parmValue{f, v})
+	return nil
+}
+
+// IsBoolFlag allows boolean flags without a value.
+func (f *parmFlag) IsBoolFlag() bool {
+	return f != nil && f.typ.Kind() == reflect.Bool
+}
+
+// apply sets the command line values in the parameter struct.
+func (ps *parmSet) apply() {
+	for _, x := range ps.set {
+		v, _ := x.f.field(true)
+		v.Set(x.value)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// isBasic returns true for the basic kinds of value.
+func isBasic(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// isList returns true for types set with a comma separated list.
+func isList(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array, reflect.Slice:
+		return isBasic(t.Elem())
+	case reflect.Struct:
+		// vectors
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() || !isBasic(f.Type) || f.Type.Kind() == reflect.String {
+				return false
+			}
+		}
+		return t.NumField() != 0
+	}
+	return false
+}
+
+// typeName returns a description of the value type for the flag usage.
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Array:
+		return fmt.Sprintf("%d x %s", t.Len(), typeName(t.Elem()))
+	case reflect.Slice:
+		return "list of " + typeName(t.Elem())
+	case reflect.Struct:
+		names := make([]string, t.NumField())
+		for i := range names {
+			names[i] = strings.ToLower(t.Field(i).Name)
+		}
+		return strings.Join(names, ",")
+	}
+	return t.Kind().String()
+}
+
+// addParmFlags adds a flag for each field of a parameter struct.
+func addParmFlags(fs *flag.FlagSet, k any) *parmSet {
+	ps := &parmSet{k: k}
+	var walk func(t reflect.Type, prefix string, path []int)
+	walk = func(t reflect.Type, prefix string, path []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			ft := sf.Type
+			p := append(append([]int{}, path...), i)
+			if ft.Kind() == reflect.Pointer && ft.Elem().Kind() == reflect.Struct {
+				walk(ft.Elem(), prefix+sf.Name+".", p)
+				continue
+			}
+			if ft.Kind() == reflect.Struct && !isList(ft) {
+				walk(ft, prefix+sf.Name+".", p)
+				continue
+			}
+			if !isBasic(ft) && !isList(ft) {
+				continue
+			}
+			fs.Var(&parmFlag{ps, ft, p}, prefix+sf.Name, typeName(ft))
+		}
+	}
+	walk(reflect.TypeOf(k).Elem(), "", nil)
+	return ps
+}
+
+//-----------------------------------------------------------------------------
+
+// parseValue parses a flag value of a given type.
+func parseValue(t reflect.Type, s string) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(x)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(s, 0, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := parseFloat(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(x)
+	case reflect.Array, reflect.Slice, reflect.Struct:
+		items := strings.Split(s, ",")
+		n := len(items)
+		switch t.Kind() {
+		case reflect.Array:
+			if n != t.Len() {
+				return v, fmt.Errorf("expected %d values", t.Len())
+			}
+		case reflect.Struct:
+			if n != t.NumField() {
+				return v, fmt.Errorf("expected %d values", t.NumField())
+			}
+		case reflect.Slice:
+			v.Set(reflect.MakeSlice(t, n, n))
+		}
+		for i, item := range items {
+			var e reflect.Value
+			if t.Kind() == reflect.Struct {
+				e = v.Field(i)
+			} else {
+				e = v.Index(i)
+			}
+			x, err := parseValue(e.Type(), strings.TrimSpace(item))
+			if err != nil {
+				return v, err
+			}
+			e.Set(x)
+		}
+	default:
+		return v, fmt.Errorf("unsupported type %s", t)
+	}
+	return v, nil
+}
+
+// parseFloat parses a float, a "deg" suffix converts degrees to radians.
+func parseFloat(s string) (float64, error) {
+	if x, ok := strings.CutSuffix(s, "deg"); ok {
+		f, err := strconv.ParseFloat(x, 64)
+		return sdf.DtoR(f), err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// formatValue returns the flag string for a value.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Array, reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	case reflect.Struct:
+		items := make([]string, v.NumField())
+		for i := range items {
+			items[i] = formatValue(v.Field(i))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+//-----------------------------------------------------------------------------
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/deadsy/sdfx/sdf"
 	v3 "github.com/deadsy/sdfx/vec/v3"
@@ -43,4 +44,30 @@ func main() {
 		log.Fatal(err)
 	}
 	sdf.BenchmarkSDF3("box SDF3", s3d)
+
+	// profile the nodes of an SDF tree
+	sphere, err := sdf.Sphere3D(12)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s2d, err = sdf.Polygon2D(sdf.Nagon(6, 5.0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	hole := sdf.Extrude3D(s2d, 40)
+	s3d = sdf.Difference3D(sdf.Union3D(s3d, sphere), hole)
+	s3d, profile := sdf.ProfileSDF3(s3d)
+	sdf.BenchmarkSDF3("profiled SDF3", s3d)
+	profile.WriteText(os.Stdout)
+
+	// go tool pprof -http=: benchmark.pprof
+	f, err := os.Create("benchmark.pprof")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	err = profile.WritePprof(f)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
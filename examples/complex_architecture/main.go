@@ -516,8 +516,8 @@ func CoverAssembly(cfg *JointConfig) (sdf.SDF3, error) {
 // Main Assembly
 //-----------------------------------------------------------------------------
 
-// CompleteJointAssembly assembles all components into final model
-func CompleteJointAssembly(cfg *JointConfig) (sdf.SDF3, error) {
+// JointAssembly assembles all components with their assembly transforms
+func JointAssembly(cfg *JointConfig) (*sdf.Assembly, error) {
     // Create all sub-assemblies
     basePlate, err := BasePlateAssembly(cfg)
     if err != nil {
@@ -544,36 +544,95 @@ func CompleteJointAssembly(cfg *JointConfig) (sdf.SDF3, error) {
         return nil, err
     }
     
-    // Position components in assembly
-    // Base is at origin (z=0)
-    basePlate = sdf.Transform3D(basePlate, sdf.Translate3d(v3.Vec{0, 0, -cfg.BaseThickness / 2}))
-    
-    // Lower housing sits on base
     lowerHousingHeight := cfg.BearingThickness + cfg.HousingFlangeHeight
-    lowerHousing = sdf.Transform3D(lowerHousing, sdf.Translate3d(v3.Vec{0, 0, lowerHousingHeight / 2}))
+    plateThickness := cfg.BaseThickness * 0.6
+    up := v3.Vec{0, 0, 1}
+    down := v3.Vec{0, 0, -1}
+    
+    // Declare the connectors of each sub-assembly (in its own coordinates).
+    // The base plate is the fixed frame, it also carries the upper housing
+    // (through the frame, which isn't modelled).
+    basePlate, err = sdf.AddConnector(basePlate,
+        sdf.Connector3{Name: "lower_housing", Position: v3.Vec{0, 0, cfg.BaseThickness / 2}, Vector: up},
+        sdf.Connector3{Name: "upper_housing", Position: v3.Vec{0, 0, cfg.BaseThickness/2 + cfg.ShaftLength - lowerHousingHeight}, Vector: up},
+    )
+    if err != nil {
+        return nil, err
+    }
     
-    // Drive train in center
-    driveTrain = sdf.Transform3D(driveTrain, sdf.Translate3d(v3.Vec{0, 0, cfg.BearingThickness / 2}))
+    lowerHousing, err = sdf.AddConnector(lowerHousing,
+        sdf.Connector3{Name: "base", Position: v3.Vec{0, 0, -lowerHousingHeight / 2}, Vector: down},
+        sdf.Connector3{Name: "bearing", Position: v3.Vec{0, 0, -lowerHousingHeight / 2}, Vector: up},
+    )
+    if err != nil {
+        return nil, err
+    }
     
-    // Upper housing above drive train
-    upperHousing = sdf.Transform3D(upperHousing, sdf.Translate3d(v3.Vec{0, 0, cfg.ShaftLength - lowerHousingHeight/2}))
+    upperHousing, err = sdf.AddConnector(upperHousing,
+        sdf.Connector3{Name: "base", Position: v3.Vec{0, 0, -lowerHousingHeight / 2}, Vector: down},
+        sdf.Connector3{Name: "cover", Position: v3.Vec{0, 0, lowerHousingHeight / 2}, Vector: up},
+    )
+    if err != nil {
+        return nil, err
+    }
     
-    // Cover on top
-    plateThickness := cfg.BaseThickness * 0.6
-    cover = sdf.Transform3D(cover, sdf.Translate3d(v3.Vec{0, 0, cfg.ShaftLength + plateThickness/2}))
+    driveTrain, err = sdf.AddConnector(driveTrain,
+        sdf.Connector3{Name: "shaft", Position: v3.Vec{0, 0, -cfg.BearingThickness / 2}, Vector: down},
+    )
+    if err != nil {
+        return nil, err
+    }
     
-    // Combine all components
-    assembly := sdf.Union3D(
-        basePlate,
-        lowerHousing,
-        driveTrain,
-        upperHousing,
-        cover,
+    cover, err = sdf.AddConnector(cover,
+        sdf.Connector3{Name: "base", Position: v3.Vec{0, 0, -plateThickness / 2}, Vector: down},
     )
+    if err != nil {
+        return nil, err
+    }
+    
+    // Base is at origin (z=0)
+    assembly := sdf.NewAssembly()
+    if err := assembly.Add("base_plate", basePlate, sdf.Translate3d(v3.Vec{0, 0, -cfg.BaseThickness / 2})); err != nil {
+        return nil, err
+    }
+    
+    // Mate the other components to it
+    mates := []struct {
+        name        string
+        sdf         sdf.SDF3
+        connector   string
+        to          string
+        toConnector string
+    }{
+        // Lower housing sits on base
+        {"lower_housing", lowerHousing, "base", "base_plate", "lower_housing"},
+        // Drive train turns in the lower housing bearing
+        {"drive_train", driveTrain, "shaft", "lower_housing", "bearing"},
+        // Upper housing above drive train
+        {"upper_housing", upperHousing, "base", "base_plate", "upper_housing"},
+        // Cover on top
+        {"cover_plate", cover, "base", "upper_housing", "cover"},
+    }
+    for _, m := range mates {
+        if err := assembly.Mate(m.name, m.sdf, m.connector, m.to, m.toConnector, 0); err != nil {
+            return nil, err
+        }
+    }
     
     return assembly, nil
 }
 
+// CompleteJointAssembly assembles all components into final model
+func CompleteJointAssembly(cfg *JointConfig) (sdf.SDF3, error) {
+    assembly, err := JointAssembly(cfg)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Combine all components
+    return assembly.Union(), nil
+}
+
 //-----------------------------------------------------------------------------
 // Rendering Functions
 //-----------------------------------------------------------------------------
@@ -634,13 +693,18 @@ func main() {
     
     // Option 1: Export complete assembly for visualization
     log.Println("Building complete assembly...")
-    assembly, err := CompleteJointAssembly(cfg)
+    assembly, err := JointAssembly(cfg)
     if err != nil {
         log.Fatalf("Failed to build assembly: %s", err)
     }
     
+    // Turn the joint, the drive train rotates in its bearing
+    if err := assembly.SetAngle("drive_train", sdf.DtoR(30)); err != nil {
+        log.Fatalf("Failed to set the joint angle: %s", err)
+    }
+    
     log.Println("Rendering complete assembly...")
-    RenderComponent(assembly, "joint_assembly.stl", cfg)
+    RenderComponent(assembly.Union(), "joint_assembly.stl", cfg)
     
     // Option 2: Export individual components for 3D printing
     if err := ExportIndividualComponents(cfg); err != nil {
@@ -0,0 +1,85 @@
+//-----------------------------------------------------------------------------
+/*
+
+Enclosures with Different Closures and Port Cutouts
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"log"
+
+	"github.com/deadsy/sdfx/obj"
+	"github.com/deadsy/sdfx/render"
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// material shrinkage
+const shrink = 1.0 / 0.999 // PLA ~0.1%
+//const shrink = 1.0/0.995; // ABS ~0.5%
+
+//-----------------------------------------------------------------------------
+
+func enclosure(closure string) ([]sdf.SDF3, error) {
+	k := &obj.EnclosureParms{
+		Size:      v3.Vec{80, 60, 40},
+		Wall:      2.5,
+		Rounding:  5.0,
+		Closure:   closure,
+		Lid:       12.0,
+		Clearance: 0.2,
+		Snap: obj.CantileverTab{
+			Length:    12.0,
+			Thickness: 1.5,
+			Width:     6.0,
+			Overhang:  1.0,
+			MaxStrain: 0.02, // PLA
+			Clearance: 0.2,
+		},
+		Snaps: 2,
+		Hinge: obj.LivingHingeParms{
+			Thickness: 0.5,
+			Length:    8.0,
+			MaxStrain: 0.25, // PP
+		},
+		Ports: []obj.Port{
+			{Face: "front", Type: "usb-c", Position: v2.Vec{-20, -5}},
+			{Face: "front", Type: "barrel", Position: v2.Vec{0, -5}},
+			{Face: "back", Type: "db9", Position: v2.Vec{0, -5}},
+			{Face: "left", Type: "round", Position: v2.Vec{0, -5}, Size: v2.Vec{6.5, 0}},
+		},
+	}
+	if closure == "hinge" {
+		// the hinged lid is half the box height
+		k.Lid = 0.5 * k.Size.Z
+	}
+	return obj.Enclosure3D(k)
+}
+
+//-----------------------------------------------------------------------------
+
+func main() {
+	for _, closure := range []string{"lip", "snap", "slide", "hinge"} {
+		parts, err := enclosure(closure)
+		if err != nil {
+			log.Fatalf("error: %s", err)
+		}
+		names := []string{"base", "lid"}
+		if closure == "hinge" {
+			// the hinged box is printed as one piece
+			parts = []sdf.SDF3{sdf.Union3D(parts...)}
+			names = []string{"box"}
+		}
+		for i, s := range parts {
+			render.ToSTL(sdf.ScaleUniform3D(s, shrink), closure+"_"+names[i]+".stl", render.NewMarchingCubesOctree(300))
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -10,10 +10,12 @@ package main
 
 import (
 	"log"
+	"math"
 
 	"github.com/deadsy/sdfx/obj"
 	"github.com/deadsy/sdfx/render"
 	"github.com/deadsy/sdfx/sdf"
+	"github.com/deadsy/sdfx/vec/v2i"
 	v3 "github.com/deadsy/sdfx/vec/v3"
 )
 
@@ -37,6 +39,58 @@ var k1 = obj.GenevaParms{
 	Clearance:      0.1,
 }
 
+// drivenAngle returns the driven wheel angle for a driver wheel angle in [-pi, pi).
+// The driver pin points at the driven wheel for a driver angle of 0.
+func drivenAngle(k *obj.GenevaParms, phi float64) float64 {
+	theta := sdf.Pi / float64(k.NumSectors)
+	d := k.CenterDistance
+	r := k.DrivenRadius
+	a := math.Sqrt((d * d) + (r * r) - (2 * d * r * math.Cos(theta)))
+	// the pin is in a slot for |phi| < phiEngage
+	phiEngage := math.Acos((a*a + d*d - r*r) / (2 * a * d))
+	phi = math.Max(-phiEngage, math.Min(phiEngage, phi))
+	// the slot points at the pin
+	return math.Atan2(a*math.Sin(phi), a*math.Cos(phi)-d) - theta
+}
+
+// animate renders one turn of the driver wheel.
+func animate(k *obj.GenevaParms, driver, driven sdf.SDF3) error {
+	model := func(t float64) (any, error) {
+		phi := sdf.Tau*t - sdf.Pi
+		a := sdf.NewAssembly()
+		if err := a.Add("driver", driver, sdf.RotateZ(phi)); err != nil {
+			return nil, err
+		}
+		// the driven wheel is flipped to put its hub below the wheel
+		m := sdf.Translate3d(v3.Vec{k.CenterDistance, 0, 0}).Mul(sdf.RotateZ(drivenAngle(k, phi))).Mul(sdf.RotateX(sdf.Pi))
+		if err := a.Add("driven", driven, m); err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+	anim, err := render.NewAnimation(model, &render.AnimationParms{Frames: 36, End: 1, FPS: 12})
+	if err != nil {
+		return err
+	}
+	collisions, err := anim.Collisions(&sdf.InterferenceParms{Cells: 50}, 1)
+	if err != nil {
+		return err
+	}
+	for _, c := range collisions {
+		log.Printf("collision at t=%.3f: %s", c.Time, c.Interference)
+	}
+	// slices through the wheels
+	err = anim.SavePNG("geneva_", &render.FrameParms{
+		Pixels: v2i.Vec{256, 160},
+		SliceA: v3.Vec{0, 0, 2.5},
+		SliceN: v3.Vec{0, 0, 1},
+	})
+	if err != nil {
+		return err
+	}
+	return anim.SaveGLB("geneva.glb", render.NewMarchingCubesOctree(150))
+}
+
 func main() {
 
 	k := k0
@@ -75,10 +129,15 @@ func main() {
 	const meshCells = 300
 	render.ToSTL(driver3d, "driver.stl", render.NewMarchingCubesOctree(meshCells))
 	render.ToSTL(driven3d, "driven.stl", render.NewMarchingCubesOctree(meshCells))
+	driver3d0, driven3d0 := driver3d, driven3d
 
 	driver3d = sdf.Transform3D(driver3d, sdf.Translate3d(v3.Vec{-0.8 * k.DrivenRadius, 0, 0}))
 	driven3d = sdf.Transform3D(driven3d, sdf.Translate3d(v3.Vec{k.DrivenRadius, 0, 0}))
 	render.ToSTL(sdf.Union3D(driver3d, driven3d), "geneva.stl", render.NewMarchingCubesOctree(meshCells))
+
+	if err := animate(&k, driver3d0, driven3d0); err != nil {
+		log.Fatalf("error: %s", err)
+	}
 }
 
 //-----------------------------------------------------------------------------
@@ -0,0 +1,48 @@
+//-----------------------------------------------------------------------------
+/*
+
+Gridfinity Body Sweep
+
+Build all of the gridfinity body sizes from 1x1x1 to 4x4x4.
+Only the bodies with changed parameters or code are rendered again.
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"log"
+
+	"github.com/deadsy/sdfx/obj"
+	"github.com/deadsy/sdfx/render"
+)
+
+//-----------------------------------------------------------------------------
+
+func body(k *obj.GfBodyParms) (any, error) {
+	return obj.GfBody(k), nil
+}
+
+func main() {
+	sizes := []any{1, 2, 3, 4}
+	variants, err := render.Grid("body", obj.GfBodyParms{Hole: true, Empty: true},
+		render.GridAxis{Field: "Size.X", Values: sizes},
+		render.GridAxis{Field: "Size.Y", Values: sizes},
+		render.GridAxis{Field: "Size.Z", Values: sizes},
+	)
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	k := &render.BatchParms{
+		Dir:     ".",
+		Ext:     ".stl",
+		Render3: render.NewMarchingCubesOctree(150),
+	}
+	_, err = render.Batch(k, body, variants)
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,104 @@
+//-----------------------------------------------------------------------------
+/*
+
+PCB Mounting Kits from Board Files
+
+* Raspberry Pi 4: board outline and mounting holes from a KiCad file
+* Arduino Uno: board outline and mounting holes from a JSON file
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"log"
+
+	"github.com/deadsy/sdfx/obj"
+	"github.com/deadsy/sdfx/render"
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// material shrinkage
+const shrink = 1.0 / 0.999 // PLA ~0.1%
+//const shrink = 1.0/0.995; // ABS ~0.5%
+
+//-----------------------------------------------------------------------------
+
+// rpi4 returns a Raspberry Pi 4 base and enclosure.
+func rpi4() ([]sdf.SDF3, error) {
+	k := &obj.PCBMountParms{
+		File: "rpi4.kicad_pcb",
+		Standoff: obj.StandoffParms{
+			PillarHeight:   6.0,
+			PillarDiameter: 5.0,
+			HoleDepth:      5.0,
+			HoleDiameter:   2.2, // M2.5 screw
+		},
+		BaseThickness: 3.0,
+		BaseMargin:    3.0,
+		Enclosure:     true,
+		Wall:          2.0,
+		Height:        28.0,
+		Clearance:     0.3,
+		Cutouts: []obj.PCBCutout{
+			{Side: "front", Position: 11.2, Height: 1.6, Size: v2.Vec{9.5, 3.8}, Radius: 1.5},    // USB-C power
+			{Side: "front", Position: 26.0, Height: 1.5, Size: v2.Vec{7.5, 3.6}, Radius: 0.5},    // micro HDMI 0
+			{Side: "front", Position: 39.5, Height: 1.5, Size: v2.Vec{7.5, 3.6}, Radius: 0.5},    // micro HDMI 1
+			{Side: "front", Position: 53.5, Height: 3.0, Size: v2.Vec{6.5, 6.5}, Radius: 3.25},   // audio jack
+			{Side: "right", Position: 9.0, Height: 8.0, Size: v2.Vec{14.5, 16.5}, Radius: 1.0},   // USB 2
+			{Side: "right", Position: 27.0, Height: 8.0, Size: v2.Vec{14.5, 16.5}, Radius: 1.0},  // USB 3
+			{Side: "right", Position: 45.75, Height: 6.8, Size: v2.Vec{16.5, 14.0}, Radius: 1.0}, // ethernet
+		},
+	}
+	return obj.PCBMount3D(k)
+}
+
+// uno returns an Arduino Uno base and enclosure.
+func uno() ([]sdf.SDF3, error) {
+	k := &obj.PCBMountParms{
+		File: "uno.json",
+		Standoff: obj.StandoffParms{
+			PillarHeight:   8.0,
+			PillarDiameter: 6.0,
+			HoleDepth:      6.0,
+			HoleDiameter:   2.4, // #4 screw
+		},
+		BaseThickness: 3.0,
+		BaseMargin:    3.0,
+		Enclosure:     true,
+		Wall:          2.0,
+		Height:        25.0,
+		Clearance:     0.3,
+		Cutouts: []obj.PCBCutout{
+			{Side: "left", Position: 38.1, Height: 4.5, Size: v2.Vec{12.5, 11.5}, Radius: 0.5}, // USB B
+			{Side: "left", Position: 7.6, Height: 5.5, Size: v2.Vec{9.5, 11.5}, Radius: 0.5},   // DC jack
+		},
+	}
+	return obj.PCBMount3D(k)
+}
+
+//-----------------------------------------------------------------------------
+
+func main() {
+	kits := []struct {
+		name string
+		fn   func() ([]sdf.SDF3, error)
+	}{
+		{"rpi4", rpi4},
+		{"uno", uno},
+	}
+	for _, kit := range kits {
+		s, err := kit.fn()
+		if err != nil {
+			log.Fatalf("error: %s", err)
+		}
+		render.ToSTL(sdf.ScaleUniform3D(s[0], shrink), kit.name+"_base.stl", render.NewMarchingCubesOctree(300))
+		render.ToSTL(sdf.ScaleUniform3D(s[1], shrink), kit.name+"_enclosure.stl", render.NewMarchingCubesOctree(300))
+	}
+}
+
+//-----------------------------------------------------------------------------
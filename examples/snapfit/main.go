@@ -0,0 +1,140 @@
+//-----------------------------------------------------------------------------
+/*
+
+Snap-Fit and Compliant Joint Test Pieces
+
+Print these to tune the clearances and strains for a material.
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"log"
+
+	"github.com/deadsy/sdfx/obj"
+	"github.com/deadsy/sdfx/render"
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// material shrinkage
+const shrink = 1.0 / 0.999 // PLA ~0.1%
+//const shrink = 1.0/0.995; // ABS ~0.5%
+
+//-----------------------------------------------------------------------------
+
+func save(s sdf.SDF3, name string) {
+	render.ToSTL(sdf.ScaleUniform3D(s, shrink), name+".stl", render.NewMarchingCubesOctree(200))
+}
+
+func saveParts(parts []sdf.SDF3, err error, names ...string) {
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	for i, s := range parts {
+		save(s, names[i])
+	}
+}
+
+func savePart(s sdf.SDF3, err error, name string) {
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	save(s, name)
+}
+
+//-----------------------------------------------------------------------------
+
+func main() {
+	strain, err := obj.MaterialStrain("PLA")
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	const clearance = 0.2
+
+	parts, err := obj.CantileverSnap3D(&obj.CantileverTab{
+		Length:    12.0,
+		Thickness: 1.5,
+		Width:     6.0,
+		Overhang:  1.0,
+		MaxStrain: strain,
+		Clearance: clearance,
+	})
+	saveParts(parts, err, "cantilever_lower", "cantilever_upper")
+
+	parts, err = obj.AnnularSnap3D(&obj.AnnularTab{
+		Diameter:  12.0,
+		Length:    8.0,
+		Undercut:  0.1,
+		MaxStrain: strain,
+		Clearance: clearance,
+	})
+	saveParts(parts, err, "annular_lower", "annular_upper")
+
+	parts, err = obj.Dovetail3D(&obj.DovetailTab{
+		Width:     10.0,
+		Height:    5.0,
+		Length:    30.0,
+		Angle:     sdf.DtoR(15),
+		MaxStrain: strain,
+		Clearance: clearance,
+	})
+	saveParts(parts, err, "dovetail_lower", "dovetail_upper")
+
+	s, err := obj.TorsionSnap3D(&obj.TorsionSnapParms{
+		BarDiameter:    2.0,
+		BarLength:      8.0,
+		LeverLength:    20.0,
+		TailLength:     10.0,
+		LeverWidth:     8.0,
+		LeverThickness: 3.0,
+		Overhang:       1.5,
+		MaxStrain:      strain,
+		Clearance:      0.5,
+	})
+	savePart(s, err, "torsion_snap")
+
+	s, err = obj.SnapPin3D(&obj.SnapPinParms{
+		Hole:         6.0,
+		Grip:         8.0,
+		HeadDiameter: 10.0,
+		HeadHeight:   2.0,
+		Slot:         1.2,
+		SlotLength:   10.0,
+		Overhang:     0.5,
+		MaxStrain:    strain,
+		Clearance:    0.1,
+	})
+	savePart(s, err, "snap_pin")
+
+	parts, err = obj.PinHinge3D(&obj.PinHingeParms{
+		Length:        40.0,
+		Knuckles:      5,
+		Diameter:      6.0,
+		Pin:           3.0,
+		LeafWidth:     20.0,
+		LeafThickness: 3.0,
+		Clearance:     0.4,
+	})
+	// print-in-place: both leaves are printed together
+	if err == nil {
+		parts = []sdf.SDF3{sdf.Union3D(parts...)}
+	}
+	saveParts(parts, err, "pin_hinge")
+
+	s, err = obj.FlexurePivot3D(&obj.FlexurePivotParms{
+		Thickness: 0.8,
+		Length:    20.0,
+		Width:     10.0,
+		Block:     v2.Vec{20, 10},
+		Angle:     sdf.DtoR(15),
+		MaxStrain: strain,
+	})
+	savePart(s, err, "flexure_pivot")
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,84 @@
+//-----------------------------------------------------------------------------
+/*
+
+Aluminium Extrusion Jig Parts
+
+* a length of 2040 extrusion (for checking fits)
+* slot nuts and a corner bracket
+* a mounting plate with holes that line up with the extrusion slots
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"log"
+
+	"github.com/deadsy/sdfx/obj"
+	"github.com/deadsy/sdfx/render"
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// material shrinkage
+const shrink = 1.0 / 0.999 // PLA ~0.1%
+//const shrink = 1.0/0.995; // ABS ~0.5%
+
+//-----------------------------------------------------------------------------
+
+// plate returns a mounting plate for the wide face of a 2040 extrusion.
+func plate() (sdf.SDF3, error) {
+	k := &obj.ExtrusionParms{Series: 20, Style: "t-slot", X: 2, Y: 1}
+	holes, err := obj.SlotHoles2D(k, "y", 0.5*3.4, 2, 40)
+	if err != nil {
+		return nil, err
+	}
+	s := sdf.Difference2D(sdf.Box2D(v2.Vec{40, 60}, 3), holes)
+	return sdf.Extrude3D(s, 5), nil
+}
+
+//-----------------------------------------------------------------------------
+
+func main() {
+	profile, err := obj.ProfileLookup("2040")
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	render.ToDXF(profile, "2040.dxf", render.NewMarchingSquaresQuadtree(400))
+	render.ToSTL(sdf.Extrude3D(profile, 30), "2040.stl", render.NewMarchingCubesOctree(200))
+
+	nut, err := obj.SlotNut3D(&obj.SlotNutParms{
+		Series:    20,
+		Style:     "t-slot",
+		Length:    12.0,
+		Thread:    "M3x0.5",
+		Clearance: 0.2,
+	})
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	render.ToSTL(sdf.ScaleUniform3D(nut, shrink), "slot_nut.stl", render.NewMarchingCubesOctree(150))
+
+	bracket, err := obj.ExtrusionBracket3D(&obj.ExtrusionBracketParms{
+		Series:    20,
+		Holes:     2,
+		Thickness: 4.0,
+		Gusset:    3.0,
+		Hole:      3.4,
+	})
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	render.ToSTL(sdf.ScaleUniform3D(bracket, shrink), "bracket.stl", render.NewMarchingCubesOctree(200))
+
+	s, err := plate()
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	render.ToSTL(sdf.ScaleUniform3D(s, shrink), "plate.stl", render.NewMarchingCubesOctree(200))
+}
+
+//-----------------------------------------------------------------------------
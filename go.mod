@@ -14,11 +14,12 @@ require (
 	github.com/yofu/dxf v0.0.0-20240729034626-50c66fc03e0d
 	golang.org/x/image v0.22.0
 	gonum.org/v1/gonum v0.15.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/qmuntal/opc v0.7.12 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/text v0.20.0 // indirect
 )
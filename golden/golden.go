@@ -0,0 +1,323 @@
+//-----------------------------------------------------------------------------
+/*
+
+Golden Output Regression Testing
+
+Render an SDF and compare it with a stored golden mesh (SDF3) or contour
+(SDF2) using geometric tolerances rather than exact bytes:
+
+* Hausdorff distance between the outputs
+* relative change in enclosed volume (area for 2d)
+* bounding box change
+
+Golden files are kept in testdata/golden. Create or update them with:
+
+	go test -run <test> -args -golden.update
+
+When a comparison fails a diff report (<name>.diff.txt) and the new output
+(<name>.new.stl or <name>.new.txt) are written next to the golden file.
+
+*/
+//-----------------------------------------------------------------------------
+
+package golden
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/deadsy/sdfx/render"
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// Dir is the golden file directory.
+var Dir = filepath.Join("testdata", "golden")
+
+var update = flag.Bool("golden.update", false, "update the golden files")
+
+// Tolerance is the allowed difference between a golden and new output.
+type Tolerance struct {
+	Hausdorff   float64 // maximum Hausdorff distance
+	Volume      float64 // maximum relative change of the volume (area for 2d)
+	BoundingBox float64 // maximum change of any bounding box coordinate
+}
+
+// DefaultTolerance returns a tolerance for a rendering resolution.
+// The Hausdorff and bounding box limits are 1.5 mesh cells and the volume limit is 1%.
+func DefaultTolerance(resolution float64) *Tolerance {
+	return &Tolerance{
+		Hausdorff:   1.5 * resolution,
+		Volume:      0.01,
+		BoundingBox: 1.5 * resolution,
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// Report is the comparison of a golden and new output.
+type Report struct {
+	Name        string
+	Count       [2]int     // primitives (triangles or lines) in the golden and new outputs
+	Volume      [2]float64 // enclosed volume (area for 2d) of the golden and new outputs
+	VolumeDelta float64    // relative change of the volume
+	BoxDelta    float64    // maximum change of any bounding box coordinate
+	Hausdorff   float64    // Hausdorff distance
+	Worst       []Deviation
+	Failures    []string // tolerances that were exceeded
+}
+
+// finish sets the derived values and checks the tolerances.
+func (r *Report) finish(d []Deviation, tol *Tolerance) *Report {
+	r.VolumeDelta = math.Abs(r.Volume[1]-r.Volume[0]) / math.Max(math.Abs(r.Volume[0]), 1e-9)
+	r.Worst = worst(d)
+	if len(r.Worst) != 0 {
+		r.Hausdorff = r.Worst[0].Distance
+	}
+	if r.Count[0] == 0 || r.Count[1] == 0 {
+		if r.Count[0] != r.Count[1] {
+			r.Failures = append(r.Failures, "one of the outputs is empty")
+		}
+		return r
+	}
+	if r.Hausdorff > tol.Hausdorff {
+		r.Failures = append(r.Failures, fmt.Sprintf("hausdorff distance %g > %g", r.Hausdorff, tol.Hausdorff))
+	}
+	if r.VolumeDelta > tol.Volume {
+		r.Failures = append(r.Failures, fmt.Sprintf("volume change %.4g%% > %.4g%%", r.VolumeDelta*100, tol.Volume*100))
+	}
+	if r.BoxDelta > tol.BoundingBox {
+		r.Failures = append(r.Failures, fmt.Sprintf("bounding box change %g > %g", r.BoxDelta, tol.BoundingBox))
+	}
+	return r
+}
+
+// OK returns true if the outputs match within the tolerances.
+func (r *Report) OK() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", r.Name)
+	fmt.Fprintf(&b, "primitives: golden %d new %d\n", r.Count[0], r.Count[1])
+	fmt.Fprintf(&b, "volume: golden %g new %g (%+.4g%%)\n", r.Volume[0], r.Volume[1], r.VolumeDelta*100)
+	fmt.Fprintf(&b, "bounding box change: %g\n", r.BoxDelta)
+	fmt.Fprintf(&b, "hausdorff distance: %g\n", r.Hausdorff)
+	if len(r.Worst) != 0 {
+		fmt.Fprintf(&b, "largest deviations:\n")
+		for _, d := range r.Worst {
+			src := "new"
+			if d.Golden {
+				src = "golden"
+			}
+			fmt.Fprintf(&b, "  %-6s point %v distance %g\n", src, d.Point, d.Distance)
+		}
+	}
+	if r.OK() {
+		fmt.Fprintf(&b, "PASS\n")
+	} else {
+		for _, f := range r.Failures {
+			fmt.Fprintf(&b, "FAIL: %s\n", f)
+		}
+	}
+	return b.String()
+}
+
+//-----------------------------------------------------------------------------
+
+// CompareMesh compares a golden and new triangle mesh.
+func CompareMesh(golden, mesh []*sdf.Triangle3, tol *Tolerance) *Report {
+	r := &Report{
+		Count:  [2]int{len(golden), len(mesh)},
+		Volume: [2]float64{meshVolume(golden), meshVolume(mesh)},
+	}
+	if len(golden) != 0 && len(mesh) != 0 {
+		b0, b1 := meshBox(golden), meshBox(mesh)
+		r.BoxDelta = b0.Min.Sub(b1.Min).Abs().Max(b0.Max.Sub(b1.Max).Abs()).MaxComponent()
+	}
+	d := append(meshDeviations(golden, mesh, true), meshDeviations(mesh, golden, false)...)
+	return r.finish(d, tol)
+}
+
+// CompareContour compares a golden and new 2d contour.
+func CompareContour(golden, lines []*sdf.Line2, tol *Tolerance) *Report {
+	r := &Report{
+		Count:  [2]int{len(golden), len(lines)},
+		Volume: [2]float64{contourArea(golden), contourArea(lines)},
+	}
+	if len(golden) != 0 && len(lines) != 0 {
+		b0, b1 := contourBox(golden), contourBox(lines)
+		r.BoxDelta = b0.Min.Sub(b1.Min).Abs().Max(b0.Max.Sub(b1.Max).Abs()).MaxComponent()
+	}
+	d := append(contourDeviations(golden, lines, true), contourDeviations(lines, golden, false)...)
+	return r.finish(d, tol)
+}
+
+//-----------------------------------------------------------------------------
+
+// Mesh renders an SDF3 and compares it with the golden mesh <Dir>/<name>.stl.
+// A nil tolerance uses the default tolerance for the renderer resolution.
+func Mesh(t testing.TB, name string, s sdf.SDF3, r render.Render3, tol *Tolerance) *Report {
+	t.Helper()
+	mesh := render.ToTriangles(s, r)
+	path := filepath.Join(Dir, name+".stl")
+	if *update {
+		writeGolden(t, path, func(path string) error { return render.SaveSTL(path, mesh) })
+		return nil
+	}
+	golden, err := render.LoadSTL(path)
+	if err != nil {
+		t.Fatalf("%s: %s (use -golden.update to create it)", name, err)
+	}
+	if tol == nil {
+		tol = DefaultTolerance(meshResolution(s, r))
+	}
+	rep := CompareMesh(golden, mesh, tol)
+	rep.Name = name
+	check(t, rep, func(path string) error { return render.SaveSTL(path, mesh) }, ".new.stl")
+	return rep
+}
+
+// Contour renders an SDF2 and compares it with the golden contour <Dir>/<name>.txt.
+// A nil tolerance uses the default tolerance for the renderer resolution.
+func Contour(t testing.TB, name string, s sdf.SDF2, r render.Render2, tol *Tolerance) *Report {
+	t.Helper()
+	lines := render.ToLines(s, r)
+	path := filepath.Join(Dir, name+".txt")
+	if *update {
+		writeGolden(t, path, func(path string) error { return SaveLines(path, lines) })
+		return nil
+	}
+	golden, err := LoadLines(path)
+	if err != nil {
+		t.Fatalf("%s: %s (use -golden.update to create it)", name, err)
+	}
+	if tol == nil {
+		tol = DefaultTolerance(contourResolution(s, r))
+	}
+	rep := CompareContour(golden, lines, tol)
+	rep.Name = name
+	check(t, rep, func(path string) error { return SaveLines(path, lines) }, ".new.txt")
+	return rep
+}
+
+// writeGolden writes a golden file.
+func writeGolden(t testing.TB, path string, save func(path string) error) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := save(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("updated %s", path)
+}
+
+// check reports a failed comparison and writes the diff report and new output.
+func check(t testing.TB, rep *Report, save func(path string) error, ext string) {
+	t.Helper()
+	base := filepath.Join(Dir, rep.Name)
+	if rep.OK() {
+		// remove the outputs of a previous failure
+		for _, path := range []string{base + ".diff.txt", base + ext} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				t.Log(err)
+			}
+		}
+		return
+	}
+	if err := os.WriteFile(base+".diff.txt", []byte(rep.String()), 0644); err != nil {
+		t.Log(err)
+	}
+	if err := save(base + ext); err != nil {
+		t.Log(err)
+	}
+	t.Errorf("%s: %s (see %s.diff.txt)", rep.Name, strings.Join(rep.Failures, ", "), base)
+}
+
+// meshResolution returns the mesh cell size of a 3d renderer.
+func meshResolution(s sdf.SDF3, r render.Render3) float64 {
+	return parseResolution(r.Info(s), s.BoundingBox().Size().MaxComponent())
+}
+
+// contourResolution returns the mesh cell size of a 2d renderer.
+func contourResolution(s sdf.SDF2, r render.Render2) float64 {
+	return parseResolution(r.Info(s), s.BoundingBox().Size().MaxComponent())
+}
+
+// parseResolution returns the resolution from a renderer info string.
+// E.g. "25x22x80, resolution 0.58". The default is 1% of the object size.
+func parseResolution(info string, size float64) float64 {
+	if _, x, ok := strings.Cut(info, "resolution "); ok {
+		if f, err := strconv.ParseFloat(strings.Fields(x)[0], 64); err == nil && f > 0 {
+			return f
+		}
+	}
+	return size * 0.01
+}
+
+//-----------------------------------------------------------------------------
+
+// SaveLines writes 2d line segments to a text file, one "x0 y0 x1 y1" segment per line.
+func SaveLines(path string, lines []*sdf.Line2) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, l := range lines {
+		fmt.Fprintf(w, "%s %s %s %s\n", ftoa(l[0].X), ftoa(l[0].Y), ftoa(l[1].X), ftoa(l[1].Y))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadLines reads 2d line segments from a text file.
+func LoadLines(path string) ([]*sdf.Line2, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []*sdf.Line2
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%s:%d: expected 4 values", path, n)
+		}
+		var x [4]float64
+		for i := range x {
+			x[i], err = strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", path, n, err)
+			}
+		}
+		lines = append(lines, &sdf.Line2{v2.Vec{X: x[0], Y: x[1]}, v2.Vec{X: x[2], Y: x[3]}})
+	}
+	return lines, scanner.Err()
+}
+
+func ftoa(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+//-----------------------------------------------------------------------------
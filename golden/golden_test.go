@@ -0,0 +1,81 @@
+//-----------------------------------------------------------------------------
+/*
+
+Golden Output Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package golden
+
+import (
+	"testing"
+
+	"github.com/deadsy/sdfx/render"
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func testModel3() sdf.SDF3 {
+	box, _ := sdf.Box3D(v3.Vec{X: 20, Y: 20, Z: 20}, 2)
+	sphere, _ := sdf.Sphere3D(13)
+	return sdf.Intersect3D(box, sphere)
+}
+
+func testModel2() sdf.SDF2 {
+	box := sdf.Box2D(v2.Vec{X: 20, Y: 10}, 2)
+	circle, _ := sdf.Circle2D(4)
+	return sdf.Difference2D(box, circle)
+}
+
+func Test_CompareMesh(t *testing.T) {
+	s := testModel3()
+	octree := render.ToTriangles(s, render.NewMarchingCubesOctree(40))
+	uniform := render.ToTriangles(s, render.NewMarchingCubesUniform(40))
+
+	// different renderers at the same resolution match
+	rep := CompareMesh(octree, uniform, DefaultTolerance(0.6))
+	if !rep.OK() {
+		t.Errorf("octree/uniform mismatch\n%s", rep)
+	}
+
+	// a larger object doesn't
+	big := render.ToTriangles(sdf.Transform3D(s, sdf.Scale3d(v3.Vec{X: 1.1, Y: 1.1, Z: 1.1})), render.NewMarchingCubesOctree(40))
+	rep = CompareMesh(octree, big, DefaultTolerance(0.6))
+	if rep.OK() || len(rep.Failures) != 3 {
+		t.Errorf("expected hausdorff, volume and bounding box failures\n%s", rep)
+	}
+	if rep.Hausdorff < 1 || rep.Hausdorff > 2 || rep.VolumeDelta < 0.2 {
+		t.Errorf("bad metrics\n%s", rep)
+	}
+}
+
+func Test_CompareContour(t *testing.T) {
+	s := testModel2()
+	lines := render.ToLines(s, render.NewMarchingSquaresQuadtree(100))
+	rep := CompareContour(lines, render.ToLines(s, render.NewMarchingSquaresUniform(100)), DefaultTolerance(0.2))
+	if !rep.OK() {
+		t.Errorf("quadtree/uniform mismatch\n%s", rep)
+	}
+	// area of the box less the hole
+	area := 20*10 - (4-sdf.Pi)*4 - sdf.Pi*16
+	if rep.Volume[0] < area*0.99 || rep.Volume[0] > area*1.01 {
+		t.Errorf("area %g, expected %g", rep.Volume[0], area)
+	}
+	moved := render.ToLines(sdf.Transform2D(s, sdf.Translate2d(v2.Vec{X: 0.5})), render.NewMarchingSquaresQuadtree(100))
+	rep = CompareContour(lines, moved, DefaultTolerance(0.2))
+	if rep.OK() || rep.Hausdorff < 0.45 {
+		t.Errorf("expected a hausdorff failure\n%s", rep)
+	}
+}
+
+// The golden outputs were rendered with the octree and quadtree renderers.
+func Test_Golden(t *testing.T) {
+	Mesh(t, "model3", testModel3(), render.NewMarchingCubesUniform(24), nil)
+	Contour(t, "model2", testModel2(), render.NewMarchingSquaresUniform(60), nil)
+}
+
+//-----------------------------------------------------------------------------
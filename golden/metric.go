@@ -0,0 +1,395 @@
+//-----------------------------------------------------------------------------
+/*
+
+Geometric Comparison Metrics
+
+Hausdorff distance, enclosed volume/area and bounding boxes for triangle
+meshes and 2d contours.
+
+The Hausdorff distance is sampled at the vertices and centroids of each
+mesh/contour, measured to the nearest point of the other one.
+
+*/
+//-----------------------------------------------------------------------------
+
+package golden
+
+import (
+	"math"
+	"sort"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// meshVolume returns the volume enclosed by a triangle mesh.
+func meshVolume(mesh []*sdf.Triangle3) float64 {
+	vol := 0.0
+	for _, t := range mesh {
+		vol += t[0].Dot(t[1].Cross(t[2]))
+	}
+	return vol / 6
+}
+
+// meshBox returns the bounding box of a triangle mesh.
+func meshBox(mesh []*sdf.Triangle3) sdf.Box3 {
+	if len(mesh) == 0 {
+		return sdf.Box3{}
+	}
+	bb := mesh[0].BoundingBox()
+	for _, t := range mesh[1:] {
+		bb = bb.Extend(t.BoundingBox())
+	}
+	return bb
+}
+
+// contourArea returns the area enclosed by a set of line segments.
+// The segments are joined into loops (their direction is ignored) and the
+// loop areas are summed with the even-odd rule for nested loops.
+func contourArea(lines []*sdf.Line2) float64 {
+	loops := contourLoops(lines)
+	area := 0.0
+	for i, a := range loops {
+		depth := 0
+		for j, b := range loops {
+			if i != j && inPolygon(a[0], b) {
+				depth++
+			}
+		}
+		x := polygonArea(a)
+		if depth%2 == 1 {
+			x = -x
+		}
+		area += x
+	}
+	return area
+}
+
+// contourLoops joins line segments with shared end points into loops.
+func contourLoops(lines []*sdf.Line2) [][]v2.Vec {
+	// quantize the end points so near identical points match
+	bb := contourBox(lines)
+	q := math.Max(bb.Size().MaxComponent(), 1) * 1e-9
+	key := func(p v2.Vec) [2]int64 {
+		return [2]int64{int64(math.Round(p.X / q)), int64(math.Round(p.Y / q))}
+	}
+	ends := map[[2]int64][]int{}
+	for i, l := range lines {
+		ends[key(l[0])] = append(ends[key(l[0])], i)
+		ends[key(l[1])] = append(ends[key(l[1])], i)
+	}
+	used := make([]bool, len(lines))
+	var loops [][]v2.Vec
+	for i := range lines {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		loop := []v2.Vec{lines[i][0]}
+		p := lines[i][1]
+		for {
+			next := -1
+			for _, j := range ends[key(p)] {
+				if !used[j] {
+					next = j
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			used[next] = true
+			loop = append(loop, p)
+			if key(lines[next][0]) == key(p) {
+				p = lines[next][1]
+			} else {
+				p = lines[next][0]
+			}
+		}
+		loops = append(loops, loop)
+	}
+	return loops
+}
+
+// polygonArea returns the (unsigned) area of a polygon.
+func polygonArea(p []v2.Vec) float64 {
+	area := 0.0
+	for i := range p {
+		area += p[i].Cross(p[(i+1)%len(p)])
+	}
+	return math.Abs(area) / 2
+}
+
+// inPolygon returns true if a point is inside a polygon.
+func inPolygon(p v2.Vec, poly []v2.Vec) bool {
+	inside := false
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X+(p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// contourBox returns the bounding box of a set of line segments.
+func contourBox(lines []*sdf.Line2) sdf.Box2 {
+	if len(lines) == 0 {
+		return sdf.Box2{}
+	}
+	bb := lines[0].BoundingBox()
+	for _, l := range lines[1:] {
+		bb = bb.Extend(l.BoundingBox())
+	}
+	return bb
+}
+
+//-----------------------------------------------------------------------------
+
+// closestTriangle returns the closest point on a triangle to a point.
+// See: Real-Time Collision Detection, Christer Ericson, 5.1.5
+func closestTriangle(p v3.Vec, t *sdf.Triangle3) v3.Vec {
+	a, b, c := t[0], t[1], t[2]
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+	ap := p.Sub(a)
+	d1 := ab.Dot(ap)
+	d2 := ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+	bp := p.Sub(b)
+	d3 := ab.Dot(bp)
+	d4 := ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.MulScalar(d1 / (d1 - d3)))
+	}
+	cp := p.Sub(c)
+	d5 := ab.Dot(cp)
+	d6 := ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.MulScalar(d2 / (d2 - d6)))
+	}
+	va := d3*d6 - d5*d4
+	if va <= 0 && (d4-d3) >= 0 && (d5-d6) >= 0 {
+		return b.Add(c.Sub(b).MulScalar((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+	denom := 1 / (va + vb + vc)
+	v := vb * denom
+	w := vc * denom
+	if math.IsNaN(v) || math.IsNaN(w) {
+		// degenerate triangle
+		return a
+	}
+	return a.Add(ab.MulScalar(v)).Add(ac.MulScalar(w))
+}
+
+// closestLine returns the closest point on a line segment to a point.
+func closestLine(p v2.Vec, l *sdf.Line2) v2.Vec {
+	d := l[1].Sub(l[0])
+	n := d.Length2()
+	if n == 0 {
+		return l[0]
+	}
+	t := sdf.Clamp(p.Sub(l[0]).Dot(d)/n, 0, 1)
+	return l[0].Add(d.MulScalar(t))
+}
+
+//-----------------------------------------------------------------------------
+
+// grid is a uniform grid of primitive (triangle/line) indices for nearest point queries.
+type grid struct {
+	bb    sdf.Box3
+	size  float64 // cell size
+	n     [3]int  // cells per axis
+	cells [][]int // primitive indices per cell
+	seen  []int   // query stamp per primitive
+	query int     // query counter
+}
+
+// newGrid returns a grid for a set of primitive bounding boxes.
+func newGrid(boxes []sdf.Box3) *grid {
+	g := &grid{seen: make([]int, len(boxes))}
+	g.bb = boxes[0]
+	for _, b := range boxes[1:] {
+		g.bb = g.bb.Extend(b)
+	}
+	// aim for a few primitives per cell
+	size := g.bb.Size()
+	g.size = size.MaxComponent() / math.Max(1, math.Cbrt(float64(len(boxes)))*2)
+	if g.size <= 0 {
+		g.size = 1
+	}
+	for i, x := range []float64{size.X, size.Y, size.Z} {
+		g.n[i] = int(math.Floor(x/g.size)) + 1
+	}
+	g.cells = make([][]int, g.n[0]*g.n[1]*g.n[2])
+	for i, b := range boxes {
+		c0 := g.cell(b.Min)
+		c1 := g.cell(b.Max)
+		for x := c0[0]; x <= c1[0]; x++ {
+			for y := c0[1]; y <= c1[1]; y++ {
+				for z := c0[2]; z <= c1[2]; z++ {
+					k := g.index(x, y, z)
+					g.cells[k] = append(g.cells[k], i)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// cell returns the (clamped) grid cell for a point.
+func (g *grid) cell(p v3.Vec) [3]int {
+	d := p.Sub(g.bb.Min).DivScalar(g.size)
+	x := [3]float64{d.X, d.Y, d.Z}
+	var c [3]int
+	for i := range c {
+		c[i] = min(max(int(math.Floor(x[i])), 0), g.n[i]-1)
+	}
+	return c
+}
+
+// index returns the cell index for cell coordinates.
+func (g *grid) index(x, y, z int) int {
+	return (x*g.n[1]+y)*g.n[2] + z
+}
+
+// nearest returns the minimum of dist(p, primitive) over the grid primitives.
+// The cells are searched in rings around the point until no closer primitive is possible.
+func (g *grid) nearest(p v3.Vec, dist func(i int) float64) float64 {
+	g.query++
+	c := g.cell(p)
+	best := math.Inf(1)
+	maxRing := max(g.n[0], g.n[1], g.n[2])
+	for r := 0; r <= maxRing; r++ {
+		x0, x1 := max(c[0]-r, 0), min(c[0]+r, g.n[0]-1)
+		y0, y1 := max(c[1]-r, 0), min(c[1]+r, g.n[1]-1)
+		z0, z1 := max(c[2]-r, 0), min(c[2]+r, g.n[2]-1)
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				for z := z0; z <= z1; z++ {
+					// ring r only
+					if max(abs(x-c[0]), abs(y-c[1]), abs(z-c[2])) != r {
+						if abs(x-c[0]) != r && abs(y-c[1]) != r && z < z1 {
+							// skip to the far side of the ring
+							z = z1 - 1
+						}
+						continue
+					}
+					for _, i := range g.cells[g.index(x, y, z)] {
+						if g.seen[i] == g.query {
+							continue
+						}
+						g.seen[i] = g.query
+						best = math.Min(best, dist(i))
+					}
+				}
+			}
+		}
+		// primitives outside the searched rings are at least r cells away
+		if best <= float64(r)*g.size {
+			break
+		}
+	}
+	return best
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+//-----------------------------------------------------------------------------
+
+// Deviation is a sample point and its distance to the other mesh/contour.
+type Deviation struct {
+	Point    v3.Vec  // sample point (z = 0 for 2d)
+	Distance float64 // distance to the other mesh/contour
+	Golden   bool    // the point is on the golden output (else on the new output)
+}
+
+// worstCount is the number of deviations kept for the report.
+const worstCount = 10
+
+// worst returns the largest deviations (at distinct points).
+func worst(d []Deviation) []Deviation {
+	sort.Slice(d, func(i, j int) bool { return d[i].Distance > d[j].Distance })
+	var w []Deviation
+	seen := map[v3.Vec]bool{}
+	for _, x := range d {
+		if len(w) == worstCount {
+			break
+		}
+		if !seen[x.Point] {
+			seen[x.Point] = true
+			w = append(w, x)
+		}
+	}
+	return w
+}
+
+// meshDeviations returns the distances from the samples of mesh a to mesh b.
+func meshDeviations(a, b []*sdf.Triangle3, golden bool) []Deviation {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	boxes := make([]sdf.Box3, len(b))
+	for i, t := range b {
+		boxes[i] = t.BoundingBox()
+	}
+	g := newGrid(boxes)
+	var d []Deviation
+	sample := func(p v3.Vec) {
+		dist := g.nearest(p, func(i int) float64 {
+			return p.Sub(closestTriangle(p, b[i])).Length()
+		})
+		d = append(d, Deviation{p, dist, golden})
+	}
+	for _, t := range a {
+		sample(t[0])
+		sample(t[0].Add(t[1]).Add(t[2]).DivScalar(3))
+	}
+	return d
+}
+
+// contourDeviations returns the distances from the samples of contour a to contour b.
+func contourDeviations(a, b []*sdf.Line2, golden bool) []Deviation {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	boxes := make([]sdf.Box3, len(b))
+	for i, l := range b {
+		bb := l.BoundingBox()
+		boxes[i] = sdf.Box3{Min: v3.Vec{X: bb.Min.X, Y: bb.Min.Y}, Max: v3.Vec{X: bb.Max.X, Y: bb.Max.Y}}
+	}
+	g := newGrid(boxes)
+	var d []Deviation
+	sample := func(p v2.Vec) {
+		p3 := v3.Vec{X: p.X, Y: p.Y}
+		dist := g.nearest(p3, func(i int) float64 {
+			return p.Sub(closestLine(p, b[i])).Length()
+		})
+		d = append(d, Deviation{p3, dist, golden})
+	}
+	for _, l := range a {
+		sample(l[0])
+		sample(l[0].Add(l[1]).MulScalar(0.5))
+	}
+	return d
+}
+
+//-----------------------------------------------------------------------------
@@ -22,3 +22,6 @@ clean:
 	-rm -f *.stl
 	-rm -f *.dxf
 	-rm -f *.3mf
+	-rm -f *.glb
+	-rm -f *.pprof
+	-rm -f manifest.json
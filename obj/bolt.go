@@ -19,11 +19,12 @@ import (
 
 // BoltParms defines the parameters for a bolt.
 type BoltParms struct {
-	Thread      string  // name of thread
-	Style       string  // head style "hex" or "knurl"
-	Tolerance   float64 // subtract from external thread radius
-	TotalLength float64 // threaded length + shank length
-	ShankLength float64 // non threaded length
+	Thread      string    // name of thread
+	Style       string    // head style "hex" or "knurl"
+	Tolerance   float64   // subtract from external thread radius
+	Fit         *FitParms // thread fit, e.g. "6g" (optional)
+	TotalLength float64   // threaded length + shank length
+	ShankLength float64   // non threaded length
 }
 
 // Bolt returns a simple bolt suitable for 3d printing.
@@ -75,13 +76,17 @@ func Bolt(k *BoltParms) (sdf.SDF3, error) {
 	}
 	var thread sdf.SDF3
 	if threadLength != 0 {
-		r := t.Radius - k.Tolerance
+		ofs, err := threadOffset(k.Fit, t, true)
+		if err != nil {
+			return nil, err
+		}
+		r := t.Radius - k.Tolerance + ofs
 		threadOffset := threadLength/2 + shankLength
-		isoThread, err := sdf.ISOThread(r, t.Pitch, true)
+		profile, err := t.Profile(r, true)
 		if err != nil {
 			return nil, err
 		}
-		thread, err = sdf.Screw3D(isoThread, threadLength, t.Taper, t.Pitch, 1)
+		thread, err = sdf.Screw3D(profile, threadLength, t.Taper, t.Pitch, 1)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,376 @@
+//-----------------------------------------------------------------------------
+/*
+
+Parametric Enclosures
+
+A rectangular box (rounded vertical edges) with a choice of closures:
+
+* lip: the lid sits on a lip around the top of the base
+* snap: cantilever snap-fit hooks on the lid catch in the base walls
+* slide: the lid slides into grooves at the top of the walls
+* hinge: a one piece box, the lid and base are joined by a living hinge. The
+base part has the hinge strip, the lid part is laid out flat behind it. The
+union of the parts is printed as a single piece.
+
+The box has port cutouts on any face. The box is centered on the z-axis with
+its bottom at z = 0. Port positions are relative to the center of the face:
+
+* front (-y), back (+y): (x, z)
+* left (-x), right (+x): (y, z)
+* top (+z), bottom (-z): (x, y)
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+// ports
+
+// Port is a cutout in the face of an enclosure.
+type Port struct {
+	Face     string  // front, back, left, right, top or bottom
+	Type     string  // usb-c, usb-a, micro-usb, barrel, db9, round or rect
+	Position v2.Vec  // position on the face
+	Size     v2.Vec  // size of a rect port, Size.X is the diameter of a round port
+	Radius   float64 // corner radius of a rect port
+}
+
+// db9Cutout2D returns the cutout for a DE-9 d-sub connector.
+func db9Cutout2D() (sdf.SDF2, error) {
+	// d-shaped shell cutout with a 10 degree taper
+	const top = 19.3
+	const height = 9.9
+	const round = 1.0
+	bottom := top - 2*height*math.Tan(sdf.DtoR(10))
+	p := sdf.NewPolygon()
+	p.Add(-0.5*top, 0.5*height)
+	p.Add(-0.5*bottom, -0.5*height)
+	p.Add(0.5*bottom, -0.5*height)
+	p.Add(0.5*top, 0.5*height)
+	shell, err := p.Mesh2D()
+	if err != nil {
+		return nil, err
+	}
+	shell = sdf.Offset2D(sdf.Offset2D(shell, -round), round)
+	// mounting holes
+	hole, err := sdf.Circle2D(0.5 * 3.1)
+	if err != nil {
+		return nil, err
+	}
+	holes := sdf.Multi2D(hole, v2.VecSet{{-12.5, 0}, {12.5, 0}})
+	return sdf.Union2D(shell, holes), nil
+}
+
+// Port2D returns the 2d cutout for a port centered on the origin.
+func Port2D(k *Port) (sdf.SDF2, error) {
+	switch k.Type {
+	case "usb-c":
+		// receptacle opening
+		return sdf.Box2D(v2.Vec{9.6, 3.8}, 1.9), nil
+	case "usb-a":
+		return sdf.Box2D(v2.Vec{13.6, 6.2}, 0.5), nil
+	case "micro-usb":
+		return sdf.Box2D(v2.Vec{8.4, 3.4}, 0.8), nil
+	case "barrel":
+		// 5.5 mm dc plug
+		return sdf.Circle2D(0.5 * 8.0)
+	case "db9":
+		return db9Cutout2D()
+	case "round":
+		if k.Size.X <= 0 {
+			return nil, sdf.ErrMsg("round port diameter <= 0")
+		}
+		return sdf.Circle2D(0.5 * k.Size.X)
+	case "rect":
+		if k.Size.X <= 0 || k.Size.Y <= 0 {
+			return nil, sdf.ErrMsg("rect port size <= 0")
+		}
+		if k.Radius < 0 || k.Radius > 0.5*k.Size.MinComponent() {
+			return nil, sdf.ErrMsg("invalid rect port radius")
+		}
+		return sdf.Box2D(k.Size, k.Radius), nil
+	}
+	return nil, fmt.Errorf("unknown port type \"%s\"", k.Type)
+}
+
+// port3d returns the port cutout through the wall of a box.
+func port3d(k *Port, size v3.Vec, wall float64) (sdf.SDF3, error) {
+	s2, err := Port2D(k)
+	if err != nil {
+		return nil, err
+	}
+	// through the wall
+	s := sdf.Extrude3D(s2, 2*(wall+1))
+	u := k.Position.X
+	v := k.Position.Y
+	rx := sdf.RotateX(sdf.DtoR(90))
+	rz := sdf.RotateZ(sdf.DtoR(90))
+	var m sdf.M44
+	switch k.Face {
+	case "front":
+		m = sdf.Translate3d(v3.Vec{u, -0.5 * size.Y, 0.5*size.Z + v}).Mul(rx)
+	case "back":
+		m = sdf.Translate3d(v3.Vec{u, 0.5 * size.Y, 0.5*size.Z + v}).Mul(rx)
+	case "left":
+		m = sdf.Translate3d(v3.Vec{-0.5 * size.X, u, 0.5*size.Z + v}).Mul(rz).Mul(rx)
+	case "right":
+		m = sdf.Translate3d(v3.Vec{0.5 * size.X, u, 0.5*size.Z + v}).Mul(rz).Mul(rx)
+	case "top":
+		m = sdf.Translate3d(v3.Vec{u, v, size.Z})
+	case "bottom":
+		m = sdf.Translate3d(v3.Vec{u, v, 0})
+	default:
+		return nil, fmt.Errorf("unknown port face \"%s\"", k.Face)
+	}
+	return sdf.Transform3D(s, m), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// EnclosureParms defines the parameters for an enclosure.
+type EnclosureParms struct {
+	Size      v3.Vec           // outer box dimensions (width, depth, height)
+	Wall      float64          // wall thickness
+	Rounding  float64          // radius of the vertical edges
+	Closure   string           // lip, snap, slide or hinge
+	Lid       float64          // lid height (lip, snap and hinge closures)
+	Clearance float64          // fit clearance
+	Snap      CantileverTab    // snap-fit hooks (snap closure)
+	Snaps     int              // number of hooks on the front and back walls (snap closure)
+	Hinge     LivingHingeParms // living hinge (hinge closure)
+	Ports     []Port           // port cutouts
+}
+
+// enclosure is the enclosure builder state.
+type enclosure struct {
+	k     *EnclosureParms
+	split float64 // z height of the lid/base split
+}
+
+// profile returns the box profile inset from the outer wall.
+func (e *enclosure) profile(inset float64) sdf.SDF2 {
+	k := e.k
+	size := v2.Vec{k.Size.X, k.Size.Y}.SubScalar(2 * inset)
+	return sdf.Box2D(size, math.Max(0, k.Rounding-inset))
+}
+
+// extrude extrudes a profile from z0 to z1.
+func extrude(s sdf.SDF2, z0, z1 float64) sdf.SDF3 {
+	return sdf.Transform3D(sdf.Extrude3D(s, z1-z0), sdf.Translate3d(v3.Vec{0, 0, 0.5 * (z0 + z1)}))
+}
+
+// shell returns the closed (or open topped) box.
+func (e *enclosure) shell(open bool) sdf.SDF3 {
+	k := e.k
+	top := k.Size.Z - k.Wall
+	if open {
+		top = k.Size.Z + 1
+	}
+	return sdf.Difference3D(extrude(e.profile(0), 0, k.Size.Z), extrude(e.profile(k.Wall), k.Wall, top))
+}
+
+// cut returns the base and lid of a shell.
+func (e *enclosure) cut(s sdf.SDF3) (sdf.SDF3, sdf.SDF3) {
+	base := sdf.Cut3D(s, v3.Vec{0, 0, e.split}, v3.Vec{0, 0, -1})
+	lid := sdf.Cut3D(s, v3.Vec{0, 0, e.split}, v3.Vec{0, 0, 1})
+	return base, lid
+}
+
+// lip returns a base and lid with a lip closure.
+func (e *enclosure) lip() (sdf.SDF3, sdf.SDF3, error) {
+	k := e.k
+	w := k.Wall
+	c := k.Clearance
+	// the lip is the inner half of the wall
+	h := w
+	if e.split+h+c > k.Size.Z-w {
+		return nil, nil, sdf.ErrMsg("the lid is too short for the lip")
+	}
+	base, lid := e.cut(e.shell(false))
+	lip := sdf.Difference2D(e.profile(0.5*w+c), e.profile(w))
+	base = sdf.Union3D(base, extrude(lip, e.split-w, e.split+h))
+	lid = sdf.Difference3D(lid, extrude(e.profile(0.5*w), e.split-1, e.split+h+c))
+	return base, lid, nil
+}
+
+// snap returns a base and lid with a snap-fit closure.
+func (e *enclosure) snap() (sdf.SDF3, sdf.SDF3, error) {
+	k := e.k
+	w := k.Wall
+	tab, err := NewCantileverTab(&k.Snap)
+	if err != nil {
+		return nil, nil, err
+	}
+	if k.Snaps <= 0 {
+		return nil, nil, sdf.ErrMsg("snaps <= 0")
+	}
+	if k.Snap.Overhang+k.Snap.Clearance >= w {
+		return nil, nil, sdf.ErrMsg("the hook recess is deeper than the wall")
+	}
+	if k.Snap.Length+k.Snap.Clearance >= e.split-w {
+		return nil, nil, sdf.ErrMsg("the hooks are longer than the base")
+	}
+	// hooks are evenly spaced on the straight part of the front and back walls
+	straight := k.Size.X - 2*math.Max(k.Rounding, w)
+	pitch := straight / float64(k.Snaps)
+	if pitch < k.Snap.Width+2*k.Snap.Clearance {
+		return nil, nil, sdf.ErrMsg("the hooks don't fit on the walls")
+	}
+	y := 0.5*k.Size.Y - w
+	var mset []sdf.M44
+	for i := 0; i < k.Snaps; i++ {
+		x := -0.5*straight + (float64(i)+0.5)*pitch
+		mset = append(mset, sdf.Translate3d(v3.Vec{x, y, e.split}))
+		mset = append(mset, sdf.Translate3d(v3.Vec{x, -y, e.split}).Mul(sdf.RotateZ(sdf.Pi)))
+	}
+	base, lid := e.cut(e.shell(false))
+	base = AddTabs(base, tab, false, mset)
+	lid = AddTabs(lid, tab, true, mset)
+	return base, lid, nil
+}
+
+// slide returns a base and lid with a sliding lid closure.
+// The lid slides in from the +x end of the box.
+func (e *enclosure) slide() (sdf.SDF3, sdf.SDF3, error) {
+	k := e.k
+	w := k.Wall
+	c := k.Clearance
+	z0 := k.Size.Z - 1.5*w // groove bottom
+	z1 := k.Size.Z - 0.5*w // groove top
+	if z0 <= w {
+		return nil, nil, sdf.ErrMsg("the box is too short for a sliding lid")
+	}
+	base := e.shell(true)
+	// grooves in the walls
+	base = sdf.Difference3D(base, extrude(e.profile(0.5*w), z0, z1))
+	// open the +x end wall
+	x := 0.5 * k.Size.X
+	end, _ := sdf.Box3D(v3.Vec{w + 1, k.Size.Y - w, 3 * w}, 0)
+	end = sdf.Transform3D(end, sdf.Translate3d(v3.Vec{x - 0.5*(w-1), 0, k.Size.Z}))
+	base = sdf.Difference3D(base, end)
+
+	// lid panel
+	panel := extrude(e.profile(0.5*w+c), z0+c, z1-c)
+	// lid end cap
+	endCap, _ := sdf.Box3D(v3.Vec{w - c, k.Size.Y - w - 2*c, 1.5*w - c}, 0)
+	endCap = sdf.Transform3D(endCap, sdf.Translate3d(v3.Vec{x - 0.5*(w-c), 0, k.Size.Z - 0.5*(1.5*w-c)}))
+	lid := sdf.Intersect3D(sdf.Union3D(panel, endCap), extrude(e.profile(0), 0, k.Size.Z))
+	return base, lid, nil
+}
+
+// hinge returns a base and lid with a living hinge and a lip closure.
+// The lid is laid out flat behind the base, the base has the hinge strip.
+func (e *enclosure) hinge() (sdf.SDF3, sdf.SDF3, error) {
+	k := e.k
+	w := k.Wall
+	if math.Abs(e.split-0.5*k.Size.Z) > 1e-6 {
+		return nil, nil, sdf.ErrMsg("a hinged lid is half the box height")
+	}
+	if err := k.Hinge.Check(); err != nil {
+		return nil, nil, err
+	}
+	base, lid, err := e.lip()
+	if err != nil {
+		return nil, nil, err
+	}
+	base, lid, err = e.ports(base, lid)
+	if err != nil {
+		return nil, nil, err
+	}
+	// fold the lid about the hinge axis
+	y := 0.5*k.Size.Y + 0.5*k.Hinge.Length
+	z := e.split
+	m := sdf.Translate3d(v3.Vec{0, y, z}).Mul(sdf.RotateX(sdf.Pi)).Mul(sdf.Translate3d(v3.Vec{0, -y, -z}))
+	lid = sdf.Transform3D(lid, m)
+	// the hinge strip along the back edge, into the outer half of the walls
+	width := k.Size.X - 2*math.Max(k.Rounding, w)
+	strip := sdf.Extrude3D(sdf.Box2D(v2.Vec{k.Hinge.Length + w, k.Hinge.Thickness}, 0), width)
+	m = sdf.Translate3d(v3.Vec{0, y, z - 0.5*k.Hinge.Thickness}).Mul(sdf.RotateZ(sdf.DtoR(90))).Mul(sdf.RotateX(sdf.DtoR(90)))
+	strip = sdf.Transform3D(strip, m)
+	return sdf.Union3D(base, strip), lid, nil
+}
+
+// ports cuts the ports from the base and lid.
+func (e *enclosure) ports(base, lid sdf.SDF3) (sdf.SDF3, sdf.SDF3, error) {
+	k := e.k
+	if len(k.Ports) == 0 {
+		return base, lid, nil
+	}
+	s := make([]sdf.SDF3, len(k.Ports))
+	for i := range k.Ports {
+		p, err := port3d(&k.Ports[i], k.Size, k.Wall)
+		if err != nil {
+			return nil, nil, err
+		}
+		s[i] = p
+	}
+	ports := sdf.Union3D(s...)
+	return sdf.Difference3D(base, ports), sdf.Difference3D(lid, ports), nil
+}
+
+// Enclosure3D returns the base and lid of an enclosure.
+func Enclosure3D(k *EnclosureParms) ([]sdf.SDF3, error) {
+	if k.Size.X <= 0 || k.Size.Y <= 0 || k.Size.Z <= 0 {
+		return nil, sdf.ErrMsg("invalid box size")
+	}
+	if k.Wall <= 0 {
+		return nil, sdf.ErrMsg("wall <= 0")
+	}
+	minSize := math.Min(k.Size.X, k.Size.Y)
+	if 2*k.Wall >= minSize {
+		return nil, sdf.ErrMsg("wall is too thick for the box size")
+	}
+	if k.Rounding < 0 || k.Rounding > 0.5*minSize {
+		return nil, sdf.ErrMsg("invalid rounding")
+	}
+	if k.Clearance < 0 {
+		return nil, sdf.ErrMsg("clearance < 0")
+	}
+	e := &enclosure{k: k}
+	if k.Closure != "slide" {
+		if k.Lid <= k.Wall || k.Lid >= k.Size.Z-k.Wall {
+			return nil, sdf.ErrMsg("invalid lid height")
+		}
+		e.split = k.Size.Z - k.Lid
+	}
+
+	var base, lid sdf.SDF3
+	var err error
+	switch k.Closure {
+	case "lip":
+		base, lid, err = e.lip()
+	case "snap":
+		base, lid, err = e.snap()
+	case "slide":
+		base, lid, err = e.slide()
+	case "hinge":
+		// the ports are cut before the lid is folded
+		base, lid, err = e.hinge()
+		if err != nil {
+			return nil, err
+		}
+		return []sdf.SDF3{base, lid}, nil
+	default:
+		return nil, fmt.Errorf("unknown closure \"%s\"", k.Closure)
+	}
+	if err != nil {
+		return nil, err
+	}
+	base, lid, err = e.ports(base, lid)
+	if err != nil {
+		return nil, err
+	}
+	return []sdf.SDF3{base, lid}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,221 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"testing"
+
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// testEnclosure returns the enclosure parameters for a closure.
+func testEnclosure(closure string) *EnclosureParms {
+	k := Defaults("Enclosure3D").(*EnclosureParms)
+	k.Closure = closure
+	if closure == "hinge" {
+		k.Lid = 0.5 * k.Size.Z
+	}
+	return k
+}
+
+func Test_Enclosure(t *testing.T) {
+	g, err := Lookup("Enclosure3D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, closure := range []string{"lip", "snap", "slide", "hinge"} {
+		k := testEnclosure(closure)
+		parts, err := Enclosure3D(k)
+		if err != nil {
+			t.Fatalf("%s: %s", closure, err)
+		}
+		// every closure has the advertised parts
+		if len(parts) != len(g.Parts) {
+			t.Fatalf("%s: expected %d parts, got %d", closure, len(g.Parts), len(parts))
+		}
+		base, lid := parts[0], parts[1]
+		w := k.Wall
+		split := k.Size.Z - k.Lid
+
+		// the base sits on z = 0 within the box outline
+		bb := base.BoundingBox()
+		if bb.Min.Z != 0 || bb.Min.X < -0.5*k.Size.X-tolerance || bb.Max.X > 0.5*k.Size.X+tolerance {
+			t.Errorf("%s: bad base bounding box %v", closure, bb)
+		}
+		if base.Evaluate(v3.Vec{0, 0, 0.5 * w}) >= 0 {
+			t.Errorf("%s: the base has no floor", closure)
+		}
+		if base.Evaluate(v3.Vec{0, 0, 0.5 * k.Size.Z}) <= 0 {
+			t.Errorf("%s: the base is not hollow", closure)
+		}
+
+		if closure == "hinge" {
+			// the lid is laid out flat behind the base, joined by the hinge strip
+			y := k.Size.Y + k.Hinge.Length
+			if lid.Evaluate(v3.Vec{0, y, 0.5 * w}) >= 0 || lid.Evaluate(v3.Vec{0, y, 0.5 * split}) <= 0 {
+				t.Error("hinge: the lid is not laid out flat")
+			}
+			if base.Evaluate(v3.Vec{0, y, 0.5 * w}) <= 0 {
+				t.Error("hinge: the base overlaps the lid")
+			}
+			p := v3.Vec{0, 0.5*k.Size.Y + 0.5*k.Hinge.Length, split - 0.5*k.Hinge.Thickness}
+			if base.Evaluate(p) >= 0 {
+				t.Errorf("hinge: no hinge strip at %v", p)
+			}
+			continue
+		}
+
+		// the closed lid covers the top and the assembled parts don't overlap
+		top := k.Size.Z - 0.5*w
+		if closure == "slide" {
+			// the lid panel is in the grooves below the top of the walls
+			top = k.Size.Z - w
+		}
+		if lid.Evaluate(v3.Vec{0, 0, top}) >= 0 {
+			t.Errorf("%s: the lid has no top", closure)
+		}
+		const n = 30
+		for i := 0; i <= n; i++ {
+			for j := 0; j <= n; j++ {
+				for l := 0; l <= n; l++ {
+					p := v3.Vec{
+						k.Size.X * (float64(i)/n - 0.5),
+						k.Size.Y * (float64(j)/n - 0.5),
+						k.Size.Z * float64(l) / n,
+					}
+					if base.Evaluate(p) < -0.01 && lid.Evaluate(p) < -0.01 {
+						t.Fatalf("%s: base and lid overlap at %v", closure, p)
+					}
+				}
+			}
+		}
+	}
+
+	// bad parameters
+	for _, f := range []func(k *EnclosureParms){
+		func(k *EnclosureParms) { k.Closure = "velcro" },
+		func(k *EnclosureParms) { k.Size.Z = 0 },
+		func(k *EnclosureParms) { k.Wall = 0.5 * k.Size.Y },
+		func(k *EnclosureParms) { k.Rounding = k.Size.Y },
+		func(k *EnclosureParms) { k.Clearance = -1 },
+		func(k *EnclosureParms) { k.Lid = k.Wall },
+		func(k *EnclosureParms) { k.Lid = k.Size.Z },
+		func(k *EnclosureParms) { k.Snaps = 0 },
+		func(k *EnclosureParms) { k.Snaps = 20 },
+		func(k *EnclosureParms) { k.Snap.Overhang = k.Wall },
+		func(k *EnclosureParms) { k.Closure = "hinge" },
+		func(k *EnclosureParms) { k.Closure = "hinge"; k.Lid = 0.5 * k.Size.Z; k.Hinge.Thickness = 0 },
+		func(k *EnclosureParms) {
+			k.Ports = append(k.Ports, Port{Face: "side", Type: "round", Size: v2.Vec{5, 0}})
+		},
+		func(k *EnclosureParms) { k.Ports = append(k.Ports, Port{Face: "top", Type: "hdmi"}) },
+	} {
+		k := testEnclosure("snap")
+		f(k)
+		if _, err := Enclosure3D(k); err == nil {
+			t.Errorf("%+v: expected an error", k)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_EnclosurePorts(t *testing.T) {
+	size := v3.Vec{80, 60, 40}
+	const wall = 2.5
+	for _, closure := range []string{"lip", "hinge"} {
+		k := &EnclosureParms{
+			Size:      size,
+			Wall:      wall,
+			Rounding:  5,
+			Closure:   closure,
+			Lid:       20,
+			Clearance: 0.2,
+			Hinge:     LivingHingeParms{Thickness: 0.5, Length: 8, MaxStrain: 0.25},
+			Ports: []Port{
+				{Face: "front", Type: "usb-c", Position: v2.Vec{-20, -5}},
+				{Face: "back", Type: "db9", Position: v2.Vec{0, -5}},
+				{Face: "left", Type: "round", Position: v2.Vec{10, -8}, Size: v2.Vec{6, 0}},
+				{Face: "right", Type: "rect", Position: v2.Vec{-10, -8}, Size: v2.Vec{8, 4}, Radius: 1},
+				{Face: "bottom", Type: "barrel", Position: v2.Vec{15, 10}},
+			},
+		}
+		parts, err := Enclosure3D(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := parts[0]
+		// the ports go through the wall, the wall is solid away from the ports
+		x, y := 0.5*size.X-0.5*wall, 0.5*size.Y-0.5*wall
+		z := 0.5 * size.Z
+		tests := []struct {
+			p    v3.Vec
+			open bool
+		}{
+			{v3.Vec{-20, -y, z - 5}, true},
+			{v3.Vec{20, -y, z - 5}, false},
+			{v3.Vec{0, y, z - 5}, true},
+			{v3.Vec{-12.5, y, z - 5}, true},
+			{v3.Vec{20, y, z - 5}, false},
+			{v3.Vec{-x, 10, z - 8}, true},
+			{v3.Vec{-x, -10, z - 8}, false},
+			{v3.Vec{x, -10 + 3.5, z - 8 + 1.5}, true},
+			{v3.Vec{x, -10 + 5, z - 8}, false},
+			{v3.Vec{15, 10, 0.5 * wall}, true},
+			{v3.Vec{-15, 10, 0.5 * wall}, false},
+		}
+		for _, test := range tests {
+			if d := base.Evaluate(test.p); (d > 0) != test.open {
+				t.Errorf("%s: %v expected open %v, got %f", closure, test.p, test.open, d)
+			}
+		}
+	}
+
+	// port cutouts
+	for _, k := range []*Port{
+		{Type: "usb-c"},
+		{Type: "usb-a"},
+		{Type: "micro-usb"},
+		{Type: "barrel"},
+		{Type: "db9"},
+		{Type: "round", Size: v2.Vec{5, 0}},
+		{Type: "rect", Size: v2.Vec{5, 3}, Radius: 1.5},
+	} {
+		s, err := Port2D(k)
+		if err != nil {
+			t.Fatalf("%s: %s", k.Type, err)
+		}
+		if s.Evaluate(v2.Vec{}) >= 0 {
+			t.Errorf("%s: the port is not centered", k.Type)
+		}
+	}
+	for _, k := range []*Port{
+		{Type: "hdmi"},
+		{Type: "round"},
+		{Type: "rect", Size: v2.Vec{5, 0}},
+		{Type: "rect", Size: v2.Vec{5, 3}, Radius: 2},
+		{Type: "rect", Size: v2.Vec{5, 3}, Radius: -1},
+	} {
+		if _, err := Port2D(k); err == nil {
+			t.Errorf("%+v: expected an error", k)
+		}
+	}
+	if _, err := port3d(&Port{Face: "inside", Type: "barrel"}, size, wall); err == nil {
+		t.Error("expected an error for an unknown face")
+	}
+	// the db9 cutout is wider at the top
+	db9, err := Port2D(&Port{Type: "db9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db9.Evaluate(v2.Vec{9, 4}) >= 0 || db9.Evaluate(v2.Vec{9, -4}) <= 0 {
+		t.Error("bad db9 taper")
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,335 @@
+//-----------------------------------------------------------------------------
+/*
+
+Aluminium T-Slot and V-Slot Extrusions
+
+20 and 30 series profiles, with printable slot nuts, corner brackets and
+hole patterns for attaching parts to the extrusion faces.
+
+The profiles are simplified: they are solid apart from the slots and the
+center bores. The slot dimensions are typical, check them against your
+supplier's drawings.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// extrusionSeries holds the slot dimensions of an extrusion series.
+type extrusionSeries struct {
+	slot    float64 // slot opening
+	lip     float64 // thickness of the slot lips
+	inner   float64 // width of the slot cavity
+	depth   float64 // depth of the slot from the face
+	chamfer float64 // depth of the v-slot chamfer
+	bore    float64 // center bore diameter
+	corner  float64 // corner radius
+}
+
+var extrusionSeriesDB = map[int]*extrusionSeries{
+	20: {6.2, 1.8, 11.0, 6.1, 1.5, 4.2, 1.5},
+	30: {8.2, 2.2, 16.5, 9.0, 2.0, 6.8, 2.0},
+}
+
+// ExtrusionParms defines an aluminium extrusion profile.
+type ExtrusionParms struct {
+	Series int    // series (module size, mm): 20 or 30
+	Style  string // "t-slot" or "v-slot"
+	X, Y   int    // number of modules in x and y (E.g. 2040 is 1 x 2)
+}
+
+// series returns the slot dimensions for the extrusion.
+func (k *ExtrusionParms) series() (*extrusionSeries, error) {
+	s, ok := extrusionSeriesDB[k.Series]
+	if !ok {
+		return nil, fmt.Errorf("unknown extrusion series %d", k.Series)
+	}
+	return s, nil
+}
+
+// size returns the size of the extrusion profile.
+func (k *ExtrusionParms) size() v2.Vec {
+	p := float64(k.Series)
+	return v2.Vec{float64(k.X) * p, float64(k.Y) * p}
+}
+
+// slots returns the slot centers across a face with n modules.
+func (k *ExtrusionParms) slots(n int) []float64 {
+	p := float64(k.Series)
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = (float64(i) - 0.5*float64(n-1)) * p
+	}
+	return x
+}
+
+// slot2D returns a slot cavity. The face is on the x-axis and the slot goes in the -y direction.
+func slot2D(s *extrusionSeries, style string) (sdf.SDF2, error) {
+	w := 0.5 * s.slot
+	iw := 0.5 * s.inner
+	h := s.depth - (iw - w) // bottom of the cavity sides (45 degree taper)
+	if h <= s.lip {
+		return nil, sdf.ErrMsg("slot is too shallow")
+	}
+	p := sdf.NewPolygon()
+	switch style {
+	case "t-slot":
+		p.Add(-w, 1)
+		p.Add(-w, -s.lip)
+	case "v-slot":
+		// 45 degree chamfer to the slot opening
+		c := s.chamfer
+		p.Add(-(w + c + 1), 1)
+		p.Add(-w, -c)
+		p.Add(-w, -s.lip)
+	default:
+		return nil, fmt.Errorf("unknown extrusion style \"%s\"", style)
+	}
+	p.Add(-iw, -s.lip)
+	p.Add(-iw, -h)
+	p.Add(-w, -s.depth)
+	p.Add(w, -s.depth)
+	p.Add(iw, -h)
+	p.Add(iw, -s.lip)
+	p.Add(w, -s.lip)
+	if style == "v-slot" {
+		c := s.chamfer
+		p.Add(w, -c)
+		p.Add(w+c+1, 1)
+	} else {
+		p.Add(w, 1)
+	}
+	return sdf.Polygon2D(p.Vertices())
+}
+
+// Extrusion2D returns the profile of an aluminium extrusion centered on the origin.
+func Extrusion2D(k *ExtrusionParms) (sdf.SDF2, error) {
+	s, err := k.series()
+	if err != nil {
+		return nil, err
+	}
+	if k.X < 1 || k.Y < 1 {
+		return nil, sdf.ErrMsg("number of modules < 1")
+	}
+	slot, err := slot2D(s, k.Style)
+	if err != nil {
+		return nil, err
+	}
+	bore, err := sdf.Circle2D(0.5 * s.bore)
+	if err != nil {
+		return nil, err
+	}
+	size := k.size()
+	body := sdf.Box2D(size, s.corner)
+
+	var cut []sdf.SDF2
+	xs := k.slots(k.X)
+	ys := k.slots(k.Y)
+	for _, x := range xs {
+		cut = append(cut,
+			sdf.Transform2D(slot, sdf.Translate2d(v2.Vec{x, 0.5 * size.Y})),
+			sdf.Transform2D(slot, sdf.Translate2d(v2.Vec{x, -0.5 * size.Y}).Mul(sdf.Rotate2d(sdf.DtoR(180)))),
+		)
+		for _, y := range ys {
+			cut = append(cut, sdf.Transform2D(bore, sdf.Translate2d(v2.Vec{x, y})))
+		}
+	}
+	for _, y := range ys {
+		cut = append(cut,
+			sdf.Transform2D(slot, sdf.Translate2d(v2.Vec{0.5 * size.X, y}).Mul(sdf.Rotate2d(sdf.DtoR(-90)))),
+			sdf.Transform2D(slot, sdf.Translate2d(v2.Vec{-0.5 * size.X, y}).Mul(sdf.Rotate2d(sdf.DtoR(90)))),
+		)
+	}
+	return sdf.Difference2D(body, sdf.Union2D(cut...)), nil
+}
+
+//-----------------------------------------------------------------------------
+// slot nuts
+
+// SlotNutParms defines a printable nut that slides into an extrusion slot.
+type SlotNutParms struct {
+	Series    int     // extrusion series: 20 or 30
+	Style     string  // extrusion style: "t-slot" or "v-slot"
+	Length    float64 // nut length (along the slot)
+	Thread    string  // screw thread, a hex nut is trapped in the slot nut (E.g. "M3x0.5")
+	Clearance float64 // clearance to the slot
+}
+
+// SlotNut3D returns a printable slot nut with a hex nut trap. The slot is
+// along the y-axis, the extrusion face is the z = 0 plane and the nut is
+// below it.
+func SlotNut3D(k *SlotNutParms) (sdf.SDF3, error) {
+	s, ok := extrusionSeriesDB[k.Series]
+	if !ok {
+		return nil, fmt.Errorf("unknown extrusion series %d", k.Series)
+	}
+	if k.Length <= 0 {
+		return nil, sdf.ErrMsg("length <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, sdf.ErrMsg("clearance < 0")
+	}
+	t, err := sdf.ThreadLookup(k.Thread)
+	if err != nil {
+		return nil, err
+	}
+	t = t.ToMillimetre()
+	c := k.Clearance
+
+	// the slot less the clearance, below the face
+	slot, err := slot2D(s, k.Style)
+	if err != nil {
+		return nil, err
+	}
+	below := sdf.Box2D(v2.Vec{2 * s.inner, s.depth}, 0)
+	below = sdf.Transform2D(below, sdf.Translate2d(v2.Vec{0, -0.5*s.depth - c}))
+	profile := sdf.Intersect2D(sdf.Offset2D(slot, -c), below)
+	// profile in the xz plane, extruded along y
+	body := sdf.Transform3D(sdf.Extrude3D(profile, k.Length), sdf.RotateX(sdf.DtoR(90)))
+
+	// screw hole and nut trap from the bottom
+	hexHeight := t.HexHeight()
+	hexRadius := t.HexRadius() + c
+	// the nut flats are across the slot, the nut bears on the material under the lips
+	const wall = 1.0
+	flats := 2 * hexRadius * math.Cos(sdf.DtoR(30))
+	if flats > s.inner-2*c-2*wall || hexHeight > s.depth-s.lip-c-wall {
+		return nil, fmt.Errorf("the %s nut is too large for the slot", k.Thread)
+	}
+	if 2*hexRadius > k.Length {
+		return nil, fmt.Errorf("the %s nut is too large for the length", k.Thread)
+	}
+	hole, err := zCylinder(t.Radius+c, -s.depth-1, 1)
+	if err != nil {
+		return nil, err
+	}
+	z0 := -s.depth + c
+	trap, err := Hex3D(hexRadius, hexHeight+1, 0)
+	if err != nil {
+		return nil, err
+	}
+	trap = sdf.Transform3D(trap, sdf.Translate3d(v3.Vec{0, 0, z0 + 0.5*(hexHeight-1)}).Mul(sdf.RotateZ(sdf.DtoR(30))))
+	return sdf.Difference3D(body, sdf.Union3D(hole, trap)), nil
+}
+
+//-----------------------------------------------------------------------------
+// brackets
+
+// ExtrusionBracketParms defines a corner bracket for joining extrusions.
+type ExtrusionBracketParms struct {
+	Series    int     // extrusion series: 20 or 30
+	Holes     int     // number of holes in each leg
+	Thickness float64 // leg thickness
+	Gusset    float64 // gusset thickness (0 for none)
+	Hole      float64 // screw hole diameter
+}
+
+// ExtrusionBracket3D returns a corner bracket. One leg is on the z = 0
+// plane (along x), the other is on the x = 0 plane (along z). The holes
+// are on the y = 0 plane, in line with the extrusion slots.
+func ExtrusionBracket3D(k *ExtrusionBracketParms) (sdf.SDF3, error) {
+	if _, ok := extrusionSeriesDB[k.Series]; !ok {
+		return nil, fmt.Errorf("unknown extrusion series %d", k.Series)
+	}
+	if k.Holes < 1 {
+		return nil, sdf.ErrMsg("holes < 1")
+	}
+	if k.Thickness <= 0 || k.Hole <= 0 || k.Gusset < 0 {
+		return nil, sdf.ErrMsg("bracket dimensions must be > 0")
+	}
+	p := float64(k.Series)
+	if k.Hole >= p-2*k.Gusset {
+		return nil, sdf.ErrMsg("the holes are too large for the bracket")
+	}
+	t := k.Thickness
+	l := float64(k.Holes) * p
+	w := 0.5 * p
+	legs := []sdf.SDF3{
+		box3(v3.Vec{0, -w, 0}, v3.Vec{l, w, t}),
+		box3(v3.Vec{0, -w, 0}, v3.Vec{t, w, l}),
+	}
+	if k.Gusset > 0 {
+		g := sdf.NewPolygon()
+		g.Add(t, t)
+		g.Add(l, t)
+		g.Add(t, l)
+		s, err := sdf.Polygon2D(g.Vertices())
+		if err != nil {
+			return nil, err
+		}
+		// xz plane gussets on the edges of the legs
+		gusset := sdf.Extrude3D(s, k.Gusset)
+		y := w - 0.5*k.Gusset
+		legs = append(legs,
+			sdf.Transform3D(gusset, sdf.Translate3d(v3.Vec{0, y, 0}).Mul(sdf.RotateX(sdf.DtoR(90)))),
+			sdf.Transform3D(gusset, sdf.Translate3d(v3.Vec{0, -y, 0}).Mul(sdf.RotateX(sdf.DtoR(90)))),
+		)
+	}
+	hole, err := sdf.Cylinder3D(3*t, 0.5*k.Hole, 0)
+	if err != nil {
+		return nil, err
+	}
+	var holes v3.VecSet
+	for i := 0; i < k.Holes; i++ {
+		holes = append(holes, v3.Vec{(float64(i) + 0.5) * p, 0, 0})
+	}
+	h0 := sdf.Multi3D(hole, holes)
+	h1 := sdf.Transform3D(h0, sdf.RotateY(sdf.DtoR(-90)))
+	return sdf.Difference3D(sdf.Union3D(legs...), sdf.Union3D(h0, h1)), nil
+}
+
+//-----------------------------------------------------------------------------
+// hole patterns
+
+// SlotHoles2D returns holes for attaching a part to a face of an extrusion.
+// The face is "x" (normal to the x-axis, Y modules wide) or "y" (normal to
+// the y-axis, X modules wide). The holes are in line with the slots (x) and
+// there are n holes per slot at a pitch along the extrusion (y). The pattern
+// is centered on the origin.
+func SlotHoles2D(
+	k *ExtrusionParms, // extrusion
+	face string, // extrusion face
+	holeRadius float64, // radius of the holes
+	n int, // number of holes per slot
+	pitch float64, // hole pitch along the extrusion
+) (sdf.SDF2, error) {
+	var modules int
+	switch face {
+	case "x":
+		modules = k.Y
+	case "y":
+		modules = k.X
+	default:
+		return nil, fmt.Errorf("unknown extrusion face \"%s\"", face)
+	}
+	if _, err := k.series(); err != nil {
+		return nil, err
+	}
+	if modules < 1 || n < 1 {
+		return nil, sdf.ErrMsg("number of holes < 1")
+	}
+	hole, err := sdf.Circle2D(holeRadius)
+	if err != nil {
+		return nil, err
+	}
+	var holes v2.VecSet
+	for _, x := range k.slots(modules) {
+		for j := 0; j < n; j++ {
+			holes = append(holes, v2.Vec{x, (float64(j) - 0.5*float64(n-1)) * pitch})
+		}
+	}
+	return sdf.Multi2D(hole, holes), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,193 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_SlotNut(t *testing.T) {
+	for _, k := range []*SlotNutParms{
+		{Series: 20, Style: "t-slot", Length: 20, Thread: "M3x0.5", Clearance: 0.2},
+		{Series: 20, Style: "v-slot", Length: 20, Thread: "M3x0.5", Clearance: 0.1},
+		{Series: 30, Style: "t-slot", Length: 20, Thread: "M5x0.8", Clearance: 0.3},
+		{Series: 30, Style: "v-slot", Length: 20, Thread: "M6x1", Clearance: 0},
+	} {
+		nut, err := SlotNut3D(k)
+		if err != nil {
+			t.Fatalf("%+v: %s", k, err)
+		}
+		s := extrusionSeriesDB[k.Series]
+		profile, err := Extrusion2D(&ExtrusionParms{Series: k.Series, Style: k.Style, X: 1, Y: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The nut is in the top slot of the extrusion, the slot is along the y-axis.
+		// Map the nut (x, z) to the extrusion profile.
+		face := 0.5 * float64(k.Series)
+		toProfile := func(p v3.Vec) v2.Vec { return v2.Vec{p.X, face + p.Z} }
+
+		// the nut clears the slot walls by the clearance
+		const n = 60
+		bb := nut.BoundingBox()
+		for _, y := range []float64{0, 0.5*k.Length - 0.5} {
+			for i := 0; i <= n; i++ {
+				for j := 0; j <= n; j++ {
+					p := v3.Vec{
+						bb.Min.X + (bb.Max.X-bb.Min.X)*float64(i)/n,
+						y,
+						bb.Min.Z + (bb.Max.Z-bb.Min.Z)*float64(j)/n,
+					}
+					if nut.Evaluate(p) < 0 && profile.Evaluate(toProfile(p)) < k.Clearance-1e-6 {
+						t.Fatalf("%+v: the nut interferes with the slot at %v", k, p)
+					}
+				}
+			}
+		}
+		// the nut top is the clearance below the face
+		y := 0.5*k.Length - 0.5
+		if nut.Evaluate(v3.Vec{1, y, -k.Clearance + 0.05}) <= 0 || nut.Evaluate(v3.Vec{1, y, -k.Clearance - 0.05}) >= 0 {
+			t.Errorf("%+v: the nut top is not below the face", k)
+		}
+		// the nut is wider than the slot opening (it's held by the lips)
+		z := -s.lip - k.Clearance - 0.1
+		for _, x := range []float64{-0.5*s.slot - 0.5, 0.5*s.slot + 0.5} {
+			if nut.Evaluate(v3.Vec{x, y, z}) >= 0 {
+				t.Errorf("%+v: the nut doesn't catch the lip at x = %g", k, x)
+			}
+		}
+		// the screw hole goes through the nut
+		t1, _ := sdf.ThreadLookup(k.Thread)
+		for _, z := range []float64{-0.5, -0.5 * s.depth, -s.depth + 0.5} {
+			if nut.Evaluate(v3.Vec{0.9 * t1.Radius, 0, z}) <= 0 {
+				t.Errorf("%+v: no screw hole at z = %g", k, z)
+			}
+		}
+	}
+
+	// nuts that are too large, bad parameters
+	for _, k := range []*SlotNutParms{
+		{Series: 20, Style: "t-slot", Length: 20, Thread: "M4x0.7", Clearance: 0.2},
+		{Series: 20, Style: "t-slot", Length: 4, Thread: "M3x0.5", Clearance: 0.2},
+		{Series: 20, Style: "t-slot", Length: 0, Thread: "M3x0.5", Clearance: 0.2},
+		{Series: 20, Style: "t-slot", Length: 20, Thread: "M3x0.5", Clearance: -0.1},
+		{Series: 20, Style: "x-slot", Length: 20, Thread: "M3x0.5", Clearance: 0.2},
+		{Series: 25, Style: "t-slot", Length: 20, Thread: "M3x0.5", Clearance: 0.2},
+		{Series: 20, Style: "t-slot", Length: 20, Thread: "M3", Clearance: 0.2},
+	} {
+		if _, err := SlotNut3D(k); err == nil {
+			t.Errorf("%+v: expected an error", k)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ExtrusionBracket(t *testing.T) {
+	for _, k := range []*ExtrusionBracketParms{
+		{Series: 20, Holes: 2, Thickness: 4, Gusset: 3, Hole: 5.5},
+		{Series: 20, Holes: 1, Thickness: 3, Hole: 3.4},
+		{Series: 30, Holes: 2, Thickness: 5, Gusset: 4, Hole: 6.6},
+	} {
+		bracket, err := ExtrusionBracket3D(k)
+		if err != nil {
+			t.Fatalf("%+v: %s", k, err)
+		}
+		s := extrusionSeriesDB[k.Series]
+		p := float64(k.Series)
+		l := float64(k.Holes) * p
+
+		// the bracket is as wide as the extrusion face
+		bb := bracket.BoundingBox()
+		want := sdf.Box3{Min: v3.Vec{0, -0.5 * p, 0}, Max: v3.Vec{l, 0.5 * p, l}}
+		if !bb.Min.Equals(want.Min, 1e-6) || !bb.Max.Equals(want.Max, 1e-6) {
+			t.Errorf("%+v: expected bounding box %v, got %v", k, want, bb)
+		}
+
+		// the holes are at the module centers in line with the slot
+		for i := 0; i < k.Holes; i++ {
+			c := (float64(i) + 0.5) * p
+			for _, q := range []v3.Vec{
+				{c, 0, 0.5 * k.Thickness},
+				{c + 0.45*k.Hole, 0, 0.5 * k.Thickness},
+				{0.5 * k.Thickness, 0, c},
+				{0.5 * k.Thickness, 0.45 * k.Hole, c},
+			} {
+				if bracket.Evaluate(q) <= 0 {
+					t.Errorf("%+v: no hole at %v", k, q)
+				}
+			}
+			if bracket.Evaluate(v3.Vec{c + 0.55*k.Hole, 0, 0.5 * k.Thickness}) >= 0 {
+				t.Errorf("%+v: hole %d is too large", k, i)
+			}
+		}
+		// the screw clearance hole is within the slot opening
+		if k.Hole > s.slot {
+			t.Errorf("%+v: the hole is wider than the slot opening", k)
+		}
+		// the gussets are on the edges of the legs, clear of the screw heads
+		if k.Gusset > 0 {
+			q := v3.Vec{0.5 * p, 0.5*p - 0.5*k.Gusset, 0.5 * p}
+			if bracket.Evaluate(q) >= 0 {
+				t.Errorf("%+v: no gusset at %v", k, q)
+			}
+			if bracket.Evaluate(v3.Vec{0.5 * p, 0, 0.5 * p}) <= 0 {
+				t.Errorf("%+v: the gusset is in the middle of the bracket", k)
+			}
+		}
+	}
+
+	for _, k := range []*ExtrusionBracketParms{
+		{Series: 25, Holes: 2, Thickness: 4, Hole: 5.5},
+		{Series: 20, Holes: 0, Thickness: 4, Hole: 5.5},
+		{Series: 20, Holes: 2, Thickness: 0, Hole: 5.5},
+		{Series: 20, Holes: 2, Thickness: 4, Gusset: -1, Hole: 5.5},
+		{Series: 20, Holes: 2, Thickness: 4, Gusset: 8, Hole: 5.5},
+	} {
+		if _, err := ExtrusionBracket3D(k); err == nil {
+			t.Errorf("%+v: expected an error", k)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SlotHoles(t *testing.T) {
+	k := &ExtrusionParms{Series: 20, Style: "t-slot", X: 2, Y: 1}
+	profile, err := Extrusion2D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holes, err := SlotHoles2D(k, "y", 0.5*3.4, 2, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the hole centers are over the slot openings on the y face
+	for _, x := range []float64{-10, 10} {
+		for _, y := range []float64{-20, 20} {
+			if holes.Evaluate(v2.Vec{x, y}) >= 0 {
+				t.Errorf("no hole at %v", v2.Vec{x, y})
+			}
+		}
+		if profile.Evaluate(v2.Vec{x, 0.5*float64(k.Y*k.Series) - 0.5}) <= 0 {
+			t.Errorf("no slot opening at x = %g", x)
+		}
+	}
+	if math.Abs(holes.BoundingBox().Size().X-(20+3.4)) > 1e-6 {
+		t.Errorf("bad hole pattern %v", holes.BoundingBox())
+	}
+	if _, err := SlotHoles2D(k, "z", 1, 2, 40); err == nil {
+		t.Error("expected an error for an unknown face")
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,336 @@
+//-----------------------------------------------------------------------------
+/*
+
+Fits and Tolerances
+
+Mating parts are sized using a tolerance class and a print process profile.
+
+Tolerance classes:
+
+ISO 286 for plain holes and shafts: "H7", "G7", "JS8", "h6", "g6", "k6", ...
+ISO 965 for metric threads: "6H", "5G" (internal), "6g", "6h", "6e" (external).
+
+Upper case letters are holes/internal threads, lower case letters are
+shafts/external threads. The part is sized to the middle of the tolerance zone.
+
+Process profiles:
+
+A 3d printer (or other process) doesn't make parts to the exact size of the model.
+Holes come out undersized and shafts oversized, so a process profile adds a
+radial clearance. The preset profiles are starting points, measure your own
+printer and adjust as needed.
+
+All dimensions are in millimetres.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+// Process Profiles
+
+// ProcessParms defines the clearances for a printer/material/process.
+type ProcessParms struct {
+	Name         string  // name of process
+	HoleOffset   float64 // add to hole radius
+	ShaftOffset  float64 // subtract from shaft radius
+	ThreadOffset float64 // add to internal, subtract from external thread radius
+}
+
+var processDB = map[string]*ProcessParms{
+	"exact":     {"exact", 0, 0, 0},
+	"cnc":       {"cnc", 0.01, 0.01, 0.02},
+	"resin":     {"resin", 0.05, 0.02, 0.1},
+	"pla_0.4":   {"pla_0.4", 0.15, 0.05, 0.15},
+	"petg_0.4":  {"petg_0.4", 0.2, 0.1, 0.2},
+	"abs_0.4":   {"abs_0.4", 0.2, 0.1, 0.2},
+	"pla_0.6":   {"pla_0.6", 0.2, 0.1, 0.25},
+	"petg_0.6":  {"petg_0.6", 0.3, 0.15, 0.3},
+	"nylon_sls": {"nylon_sls", 0.1, 0.1, 0.2},
+}
+
+// ProcessLookup returns a preset process profile by name.
+func ProcessLookup(name string) (*ProcessParms, error) {
+	if p, ok := processDB[name]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("process \"%s\" not found", name)
+}
+
+//-----------------------------------------------------------------------------
+// ISO 286 Limits and Fits
+
+// isoSizeRanges are the upper limits of the ISO 286 nominal size ranges.
+var isoSizeRanges = []float64{3, 6, 10, 18, 30, 50, 80, 120, 180, 250, 315, 400, 500}
+
+// isoMeanSize returns the geometric mean of the size range containing d.
+func isoMeanSize(d float64) (float64, error) {
+	if d <= 0 {
+		return 0, sdf.ErrMsg("size <= 0")
+	}
+	lo := 1.0
+	for _, hi := range isoSizeRanges {
+		if d <= hi {
+			return math.Sqrt(lo * hi), nil
+		}
+		lo = hi
+	}
+	return 0, sdf.ErrMsg("size > 500 mm")
+}
+
+// itMultiplier is the IT5..IT16 standard tolerance as a multiple of the tolerance unit.
+var itMultiplier = []float64{7, 10, 16, 25, 40, 64, 100, 160, 250, 400, 640, 1000}
+
+// isoIT returns the ISO 286 standard tolerance (um) for a mean size and grade.
+func isoIT(d float64, grade int) (float64, error) {
+	if grade < 5 || grade > 16 {
+		return 0, fmt.Errorf("IT grade %d not supported (5..16)", grade)
+	}
+	i := 0.45*math.Cbrt(d) + 0.001*d
+	return itMultiplier[grade-5] * i, nil
+}
+
+// isoShaftDeviation returns the ISO 286 shaft deviations (um) for a mean size.
+func isoShaftDeviation(letter string, d float64, grade int) (lower, upper float64, err error) {
+	it, err := isoIT(d, grade)
+	if err != nil {
+		return 0, 0, err
+	}
+	// letters a..h have a fundamental upper deviation
+	es := math.NaN()
+	switch letter {
+	case "d":
+		es = -16 * math.Pow(d, 0.44)
+	case "e":
+		es = -11 * math.Pow(d, 0.41)
+	case "f":
+		es = -5.5 * math.Pow(d, 0.41)
+	case "g":
+		es = -2.5 * math.Pow(d, 0.34)
+	case "h":
+		es = 0
+	case "js":
+		return -0.5 * it, 0.5 * it, nil
+	}
+	if !math.IsNaN(es) {
+		return es - it, es, nil
+	}
+	// letters k..p have a fundamental lower deviation
+	var ei float64
+	switch letter {
+	case "k":
+		if grade <= 7 {
+			ei = 0.6 * math.Cbrt(d)
+		}
+	case "m":
+		it6, _ := isoIT(d, 6)
+		it7, _ := isoIT(d, 7)
+		ei = it7 - it6
+	case "n":
+		ei = 5 * math.Pow(d, 0.34)
+	case "p":
+		ei, _ = isoIT(d, 7)
+	default:
+		return 0, 0, fmt.Errorf("shaft deviation \"%s\" not supported", letter)
+	}
+	return ei, ei + it, nil
+}
+
+// isoHoleDeviation returns the ISO 286 hole deviations (um) for a mean size.
+// Hole deviations are the mirror image of the shaft deviations (the general rule).
+func isoHoleDeviation(letter string, d float64, grade int) (lower, upper float64, err error) {
+	switch letter {
+	case "D", "E", "F", "G", "H", "JS", "K", "M", "N", "P":
+	default:
+		return 0, 0, fmt.Errorf("hole deviation \"%s\" not supported", letter)
+	}
+	lo, hi, err := isoShaftDeviation(strings.ToLower(letter), d, grade)
+	if err != nil {
+		return 0, 0, err
+	}
+	return -hi, -lo, nil
+}
+
+//-----------------------------------------------------------------------------
+// ISO 965 Metric Thread Tolerances
+
+// isoThreadDeviation returns the ISO 965 pitch diameter deviations (um).
+func isoThreadDeviation(letter string, d, pitch float64, grade int) (lower, upper float64, err error) {
+	gradeFactor := map[int]float64{4: 0.63, 5: 0.8, 6: 1, 7: 1.25, 8: 1.6}
+	k, ok := gradeFactor[grade]
+	if !ok {
+		return 0, 0, fmt.Errorf("thread tolerance grade %d not supported (4..8)", grade)
+	}
+	// external pitch diameter tolerance
+	td2 := k * 90 * math.Pow(pitch, 0.4) * math.Pow(d, 0.1)
+	switch letter {
+	case "e":
+		es := -(50 + 11*pitch)
+		return es - td2, es, nil
+	case "f":
+		es := -(30 + 11*pitch)
+		return es - td2, es, nil
+	case "g":
+		es := -(15 + 11*pitch)
+		return es - td2, es, nil
+	case "h":
+		return -td2, 0, nil
+	case "G":
+		ei := 15 + 11*pitch
+		return ei, ei + 1.32*td2, nil
+	case "H":
+		return 0, 1.32 * td2, nil
+	}
+	return 0, 0, fmt.Errorf("thread deviation \"%s\" not supported", letter)
+}
+
+//-----------------------------------------------------------------------------
+
+var plainClass = regexp.MustCompile(`^([A-Za-z]{1,2})([0-9]{1,2})$`)
+var threadClass = regexp.MustCompile(`^([0-9])([A-Za-z])$`)
+
+func isUpper(s string) bool {
+	return s[0] >= 'A' && s[0] <= 'Z'
+}
+
+// FitParms defines the tolerance class and process used to size a mating feature.
+type FitParms struct {
+	Class   string        // tolerance class, e.g. "H7", "g6", "6H", "6g" ("" for none)
+	Process *ProcessParms // process profile (nil for none)
+}
+
+// midDeviation returns the radial offset (mm) to the middle of the tolerance zone.
+func (f *FitParms) midDeviation(d, pitch float64, hole bool) (float64, error) {
+	if f.Class == "" {
+		return 0, nil
+	}
+	var lo, hi float64
+	var letter string
+	var err error
+	if m := threadClass.FindStringSubmatch(f.Class); m != nil {
+		if pitch <= 0 {
+			return 0, fmt.Errorf("thread class \"%s\" used for a plain feature", f.Class)
+		}
+		letter = m[2]
+		grade, _ := strconv.Atoi(m[1])
+		lo, hi, err = isoThreadDeviation(letter, d, pitch, grade)
+	} else if m := plainClass.FindStringSubmatch(f.Class); m != nil {
+		letter = m[1]
+		grade, _ := strconv.Atoi(m[2])
+		var dm float64
+		dm, err = isoMeanSize(d)
+		if err != nil {
+			return 0, err
+		}
+		if isUpper(letter) {
+			lo, hi, err = isoHoleDeviation(letter, dm, grade)
+		} else {
+			lo, hi, err = isoShaftDeviation(letter, dm, grade)
+		}
+	} else {
+		return 0, fmt.Errorf("bad tolerance class \"%s\"", f.Class)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if isUpper(letter) != hole {
+		return 0, fmt.Errorf("tolerance class \"%s\" does not match the feature (hole/shaft)", f.Class)
+	}
+	// um diameter to mm radius
+	return 0.25 * (lo + hi) * 1e-3, nil
+}
+
+// HoleOffset returns the radial adjustment (mm) for a hole of diameter d (mm).
+func (f *FitParms) HoleOffset(d float64) (float64, error) {
+	if f == nil {
+		return 0, nil
+	}
+	ofs, err := f.midDeviation(d, 0, true)
+	if err != nil {
+		return 0, err
+	}
+	if f.Process != nil {
+		ofs += f.Process.HoleOffset
+	}
+	return ofs, nil
+}
+
+// ShaftOffset returns the radial adjustment (mm) for a shaft of diameter d (mm).
+func (f *FitParms) ShaftOffset(d float64) (float64, error) {
+	if f == nil {
+		return 0, nil
+	}
+	ofs, err := f.midDeviation(d, 0, false)
+	if err != nil {
+		return 0, err
+	}
+	if f.Process != nil {
+		ofs -= f.Process.ShaftOffset
+	}
+	return ofs, nil
+}
+
+// InternalThreadOffset returns the radial adjustment (mm) for an internal thread.
+func (f *FitParms) InternalThreadOffset(d, pitch float64) (float64, error) {
+	if f == nil {
+		return 0, nil
+	}
+	ofs, err := f.midDeviation(d, pitch, true)
+	if err != nil {
+		return 0, err
+	}
+	if f.Process != nil {
+		ofs += f.Process.ThreadOffset
+	}
+	return ofs, nil
+}
+
+// ExternalThreadOffset returns the radial adjustment (mm) for an external thread.
+func (f *FitParms) ExternalThreadOffset(d, pitch float64) (float64, error) {
+	if f == nil {
+		return 0, nil
+	}
+	ofs, err := f.midDeviation(d, pitch, false)
+	if err != nil {
+		return 0, err
+	}
+	if f.Process != nil {
+		ofs -= f.Process.ThreadOffset
+	}
+	return ofs, nil
+}
+
+// threadOffset returns the radial thread adjustment in the units of the thread.
+func threadOffset(f *FitParms, t *sdf.ThreadParameters, external bool) (float64, error) {
+	if f == nil {
+		return 0, nil
+	}
+	tm := t.ToMillimetre()
+	var ofs float64
+	var err error
+	if external {
+		ofs, err = f.ExternalThreadOffset(2.0*tm.Radius, tm.Pitch)
+	} else {
+		ofs, err = f.InternalThreadOffset(2.0*tm.Radius, tm.Pitch)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if t.Units == "inch" {
+		ofs *= sdf.InchesPerMillimetre
+	}
+	return ofs, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,199 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_ISO286(t *testing.T) {
+	// published ISO 286-2 deviations (um), the standard rounds the computed values
+	const tol = 1.0
+	tests := []struct {
+		class  string
+		d      float64
+		lo, hi float64
+	}{
+		{"H7", 10, 0, 15},
+		{"H7", 25, 0, 21},
+		{"H7", 50, 0, 25},
+		{"g6", 10, -14, -5},
+		{"g6", 25, -20, -7},
+		{"g6", 50, -25, -9},
+		{"f7", 25, -41, -20},
+		{"k6", 25, 2, 15},
+		{"js6", 25, -6.5, 6.5},
+	}
+	for _, test := range tests {
+		dm, err := isoMeanSize(test.d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		letter := test.class[:len(test.class)-1]
+		grade := int(test.class[len(test.class)-1] - '0')
+		var lo, hi float64
+		if isUpper(letter) {
+			lo, hi, err = isoHoleDeviation(letter, dm, grade)
+		} else {
+			lo, hi, err = isoShaftDeviation(letter, dm, grade)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(lo-test.lo) > tol || math.Abs(hi-test.hi) > tol {
+			t.Errorf("%s at %g mm: expected %g/%g um, got %.1f/%.1f um", test.class, test.d, test.lo, test.hi, lo, hi)
+		}
+	}
+	// an H7/g6 fit at 25 mm is a clearance fit, each part sits mid zone
+	f := &FitParms{Class: "H7"}
+	hole, err := f.HoleOffset(25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = &FitParms{Class: "g6"}
+	shaft, err := f.ShaftOffset(25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(hole-0.25*21e-3) > 0.25*tol*1e-3 || math.Abs(shaft+0.25*27e-3) > 0.25*tol*1e-3 {
+		t.Errorf("bad H7/g6 offsets %g %g", hole, shaft)
+	}
+}
+
+func Test_ISO965(t *testing.T) {
+	// published ISO 965-1 pitch diameter deviations for M8x1.25 (um)
+	const tol = 5.0
+	tests := []struct {
+		letter string
+		grade  int
+		lo, hi float64
+	}{
+		{"g", 6, -146, -28},
+		{"h", 6, -118, 0},
+		{"H", 6, 0, 160},
+		{"G", 6, 28, 188},
+	}
+	for _, test := range tests {
+		lo, hi, err := isoThreadDeviation(test.letter, 8, 1.25, test.grade)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(lo-test.lo) > tol || math.Abs(hi-test.hi) > tol {
+			t.Errorf("%d%s: expected %g/%g um, got %.1f/%.1f um", test.grade, test.letter, test.lo, test.hi, lo, hi)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ProcessFit(t *testing.T) {
+	pla, err := ProcessLookup("pla_0.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ProcessLookup("unobtainium"); err == nil {
+		t.Error("expected an error for an unknown process")
+	}
+
+	// no fit, no adjustment
+	var none *FitParms
+	if ofs, _ := none.HoleOffset(10); ofs != 0 {
+		t.Errorf("nil fit: expected 0, got %g", ofs)
+	}
+
+	// the process clearance is added to the class offset
+	tests := []struct {
+		f      *FitParms
+		offset func(f *FitParms) (float64, error)
+		class  func(f *FitParms) (float64, error)
+		delta  float64
+	}{
+		{
+			&FitParms{Class: "H7", Process: pla},
+			func(f *FitParms) (float64, error) { return f.HoleOffset(10) },
+			func(f *FitParms) (float64, error) { return f.midDeviation(10, 0, true) },
+			pla.HoleOffset,
+		},
+		{
+			&FitParms{Class: "g6", Process: pla},
+			func(f *FitParms) (float64, error) { return f.ShaftOffset(10) },
+			func(f *FitParms) (float64, error) { return f.midDeviation(10, 0, false) },
+			-pla.ShaftOffset,
+		},
+		{
+			&FitParms{Class: "6H", Process: pla},
+			func(f *FitParms) (float64, error) { return f.InternalThreadOffset(8, 1.25) },
+			func(f *FitParms) (float64, error) { return f.midDeviation(8, 1.25, true) },
+			pla.ThreadOffset,
+		},
+		{
+			&FitParms{Class: "6g", Process: pla},
+			func(f *FitParms) (float64, error) { return f.ExternalThreadOffset(8, 1.25) },
+			func(f *FitParms) (float64, error) { return f.midDeviation(8, 1.25, false) },
+			-pla.ThreadOffset,
+		},
+		{
+			&FitParms{Process: pla},
+			func(f *FitParms) (float64, error) { return f.HoleOffset(10) },
+			func(f *FitParms) (float64, error) { return 0, nil },
+			pla.HoleOffset,
+		},
+	}
+	for _, test := range tests {
+		ofs, err := test.offset(test.f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mid, err := test.class(test.f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(ofs-(mid+test.delta)) > tolerance {
+			t.Errorf("%s: expected %g, got %g", test.f.Class, mid+test.delta, ofs)
+		}
+	}
+
+	// inch threads are adjusted in inches
+	unc, err := sdf.ThreadLookup("unc_1/4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ofs, err := threadOffset(&FitParms{Process: pla}, unc, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(ofs+pla.ThreadOffset*sdf.InchesPerMillimetre) > tolerance {
+		t.Errorf("unc_1/4: expected %g, got %g", -pla.ThreadOffset*sdf.InchesPerMillimetre, ofs)
+	}
+
+	// mismatched classes are errors
+	for _, test := range []struct {
+		f    *FitParms
+		hole bool
+	}{
+		{&FitParms{Class: "H7"}, false},
+		{&FitParms{Class: "g6"}, true},
+		{&FitParms{Class: "6H"}, true},
+		{&FitParms{Class: "Z9"}, true},
+		{&FitParms{Class: "H7x"}, true},
+	} {
+		var err error
+		if test.hole {
+			_, err = test.f.HoleOffset(10)
+		} else {
+			_, err = test.f.ShaftOffset(10)
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", test.f.Class)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,201 @@
+//-----------------------------------------------------------------------------
+/*
+
+Hinges and Flexures
+
+* LivingHingeParms: a thin strip folded through 180 degrees
+* PinHinge3D: a print-in-place knuckle hinge
+* FlexurePivot3D: a cross-leaf (cartwheel) flexure pivot
+
+The compliant joints check their strain against the allowable strain of
+the material (see MaterialStrain).
+
+The pin hinge is rigid, it relies on the print clearance between the pin
+and the knuckles.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+// living hinges
+
+// LivingHingeParms defines a living hinge, a thin strip folded through 180 degrees.
+type LivingHingeParms struct {
+	Thickness float64 // strip thickness
+	Length    float64 // strip length
+	MaxStrain float64 // allowable strain of the material
+}
+
+// Strain returns the maximum strain of the folded strip.
+func (k *LivingHingeParms) Strain() float64 {
+	// the strip is bent into a semicircle
+	return math.Pi * k.Thickness / (2 * k.Length)
+}
+
+// Check returns an error if the geometry is invalid or over strained.
+func (k *LivingHingeParms) Check() error {
+	if k.Thickness <= 0 || k.Length <= 0 {
+		return sdf.ErrMsg("living hinge dimensions must be > 0")
+	}
+	return checkStrain("living hinge", k.Strain(), k.MaxStrain)
+}
+
+//-----------------------------------------------------------------------------
+// print-in-place hinges
+
+// PinHingeParms defines a print-in-place knuckle hinge.
+type PinHingeParms struct {
+	Length        float64 // hinge length
+	Knuckles      int     // number of knuckles (odd)
+	Diameter      float64 // knuckle diameter
+	Pin           float64 // pin diameter
+	LeafWidth     float64 // leaf width (from the hinge axis)
+	LeafThickness float64 // leaf thickness
+	Clearance     float64 // print clearance
+}
+
+// PinHinge3D returns the two leaves of a print-in-place hinge. The hinge axis
+// is parallel to the x-axis, the knuckles and leaves lie on the z = 0 plane. The
+// first leaf (+y) carries the pin, the second leaf (-y) turns on it.
+func PinHinge3D(k *PinHingeParms) ([]sdf.SDF3, error) {
+	if k.Length <= 0 || k.Diameter <= 0 || k.Pin <= 0 || k.LeafThickness <= 0 {
+		return nil, sdf.ErrMsg("hinge dimensions must be > 0")
+	}
+	if k.Knuckles < 3 || k.Knuckles%2 == 0 {
+		return nil, sdf.ErrMsg("the number of knuckles must be odd and >= 3")
+	}
+	if k.Clearance <= 0 {
+		return nil, sdf.ErrMsg("clearance <= 0")
+	}
+	r := 0.5 * k.Diameter
+	c := k.Clearance
+	if 0.5*k.Pin+c >= r {
+		return nil, sdf.ErrMsg("the pin is too large for the knuckles")
+	}
+	if k.LeafThickness > k.Diameter {
+		return nil, sdf.ErrMsg("the leaves are thicker than the knuckles")
+	}
+	if k.LeafWidth <= r+c {
+		return nil, sdf.ErrMsg("the leaves are narrower than the knuckles")
+	}
+	n := k.Knuckles
+	l := (k.Length - float64(n-1)*c) / float64(n)
+	if l <= 0 {
+		return nil, sdf.ErrMsg("the knuckles are too short")
+	}
+
+	// the knuckles and leaves sit on the z = 0 plane
+	z := r
+	axis := func(s sdf.SDF3, x float64) sdf.SDF3 {
+		return sdf.Transform3D(s, sdf.Translate3d(v3.Vec{x, 0, z}).Mul(sdf.RotateY(sdf.DtoR(90))))
+	}
+	knuckle, err := sdf.Cylinder3D(l, r, 0)
+	if err != nil {
+		return nil, err
+	}
+	bore, err := sdf.Cylinder3D(l+2*c, 0.5*k.Pin+c, 0)
+	if err != nil {
+		return nil, err
+	}
+	x0 := -0.5 * k.Length
+	leaf := [2][]sdf.SDF3{}
+	var bores []sdf.SDF3
+	for i := 0; i < n; i++ {
+		x := x0 + float64(i)*(l+c) + 0.5*l
+		j := i & 1
+		// join the knuckle to its leaf
+		y := r + c
+		if j == 1 {
+			y = -y
+		}
+		web := box3(v3.Vec{x - 0.5*l, math.Min(0, y), 0}, v3.Vec{x + 0.5*l, math.Max(0, y), k.LeafThickness})
+		leaf[j] = append(leaf[j], axis(knuckle, x), web)
+		if j == 1 {
+			bores = append(bores, axis(bore, x))
+		}
+	}
+	y := r + c
+	leaf[0] = append(leaf[0], box3(v3.Vec{x0, y, 0}, v3.Vec{-x0, k.LeafWidth, k.LeafThickness}))
+	leaf[1] = append(leaf[1], box3(v3.Vec{x0, -k.LeafWidth, 0}, v3.Vec{-x0, -y, k.LeafThickness}))
+
+	pin, err := sdf.Cylinder3D(k.Length, 0.5*k.Pin, 0)
+	if err != nil {
+		return nil, err
+	}
+	s0 := sdf.Union3D(append(leaf[0], axis(pin, 0))...)
+	s1 := sdf.Difference3D(sdf.Union3D(leaf[1]...), sdf.Union3D(bores...))
+	return []sdf.SDF3{s0, s1}, nil
+}
+
+//-----------------------------------------------------------------------------
+// flexure pivots
+
+// FlexurePivotParms defines a cross-leaf flexure pivot. Two leaves cross at
+// 90 degrees between a fixed block and a moving block.
+type FlexurePivotParms struct {
+	Thickness float64 // leaf thickness
+	Length    float64 // leaf length
+	Width     float64 // pivot width (along the pivot axis)
+	Block     v2.Vec  // size of the fixed and moving blocks
+	Angle     float64 // maximum rotation (radians)
+	MaxStrain float64 // allowable strain of the material
+}
+
+// Strain returns the maximum strain of the leaves at the maximum rotation.
+func (k *FlexurePivotParms) Strain() float64 {
+	// the leaves bend in an S shape through the rotation angle
+	return k.Thickness * k.Angle / k.Length
+}
+
+// gap returns the gap between the fixed and moving blocks.
+func (k *FlexurePivotParms) gap() float64 {
+	return k.Length * math.Sin(sdf.DtoR(45))
+}
+
+// Check returns an error if the geometry is invalid or over strained.
+func (k *FlexurePivotParms) Check() error {
+	if k.Thickness <= 0 || k.Length <= 0 || k.Width <= 0 || k.Angle <= 0 {
+		return sdf.ErrMsg("flexure pivot dimensions must be > 0")
+	}
+	if k.Block.Y <= 0 || k.Block.X < k.gap()+2*k.Thickness {
+		return sdf.ErrMsg("the blocks are too small for the leaves")
+	}
+	if 0.5*k.Block.X*math.Sin(k.Angle) >= k.gap() {
+		return sdf.ErrMsg("the blocks collide at the maximum rotation")
+	}
+	return checkStrain("flexure pivot", k.Strain(), k.MaxStrain)
+}
+
+// FlexurePivot3D returns a cross-leaf flexure pivot. The pivot axis is the
+// z-axis, the fixed block is below (-y) and the moving block above (+y) the xz plane.
+func FlexurePivot3D(k *FlexurePivotParms) (sdf.SDF3, error) {
+	if err := k.Check(); err != nil {
+		return nil, err
+	}
+	h := 0.5 * k.gap()
+	b := sdf.Box2D(k.Block, 0)
+	y := h + 0.5*k.Block.Y
+	s := []sdf.SDF2{
+		sdf.Transform2D(b, sdf.Translate2d(v2.Vec{0, -y})),
+		sdf.Transform2D(b, sdf.Translate2d(v2.Vec{0, y})),
+	}
+	// the leaves run into the blocks
+	leaf := sdf.Box2D(v2.Vec{k.Length + 2*k.Thickness, k.Thickness}, 0)
+	s = append(s,
+		sdf.Transform2D(leaf, sdf.Rotate2d(sdf.DtoR(45))),
+		sdf.Transform2D(leaf, sdf.Rotate2d(sdf.DtoR(-45))),
+	)
+	return sdf.Extrude3D(sdf.Union2D(s...), k.Width), nil
+}
+
+//-----------------------------------------------------------------------------
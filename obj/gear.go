@@ -89,6 +89,48 @@ func involuteGearTooth(
 
 //-----------------------------------------------------------------------------
 
+// involuteGearBody returns the 2D profile for the teeth and root circle of an involute gear.
+func involuteGearBody(
+	k *InvoluteGearParms,
+	addendum float64, // radial distance from pitch circle to outside circle
+	dedendum float64, // radial distance from pitch circle to root circle
+	backlash float64, // backlash expressed as units of pitch circumference
+) (sdf.SDF2, error) {
+
+	// pitch radius
+	pitchRadius := float64(k.NumberTeeth) * k.Module * 0.5
+
+	// base circle radius
+	baseRadius := pitchRadius * math.Cos(k.PressureAngle)
+
+	outerRadius := pitchRadius + addendum
+	rootRadius := pitchRadius - dedendum
+
+	tooth, err := involuteGearTooth(
+		k.NumberTeeth,
+		k.Module,
+		rootRadius,
+		baseRadius,
+		outerRadius,
+		backlash,
+		k.Facets,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	gear := sdf.RotateCopy2D(tooth, k.NumberTeeth)
+
+	root, err := sdf.Circle2D(rootRadius)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdf.Union2D(gear, root), nil
+}
+
+//-----------------------------------------------------------------------------
+
 // InvoluteGearParms defines the parameters for an involute gear.
 type InvoluteGearParms struct {
 	NumberTeeth   int     // number of gear teeth
@@ -100,64 +142,50 @@ type InvoluteGearParms struct {
 	Facets        int     // number of facets for involute flank
 }
 
-// InvoluteGear returns an 2D polygon for an involute gear.
-func InvoluteGear(k *InvoluteGearParms) (sdf.SDF2, error) {
-
+// validate checks the involute gear parameters.
+func (k *InvoluteGearParms) validate() error {
 	if k.NumberTeeth <= 0 {
-		return nil, sdf.ErrMsg("NumberTeeth <= 0")
+		return sdf.ErrMsg("NumberTeeth <= 0")
 	}
 	if k.Module <= 0 {
-		return nil, sdf.ErrMsg("Module <= 0")
+		return sdf.ErrMsg("Module <= 0")
 	}
 	if k.PressureAngle <= 0 {
-		return nil, sdf.ErrMsg("PressureAngle <= 0")
+		return sdf.ErrMsg("PressureAngle <= 0")
 	}
 	if k.Backlash < 0 {
-		return nil, sdf.ErrMsg("Backlash <= 0")
+		return sdf.ErrMsg("Backlash <= 0")
 	}
 	if k.Clearance < 0 {
-		return nil, sdf.ErrMsg("Clearance < 0")
+		return sdf.ErrMsg("Clearance < 0")
 	}
 	if k.RingWidth < 0 {
-		return nil, sdf.ErrMsg("RingWidth < 0")
+		return sdf.ErrMsg("RingWidth < 0")
 	}
 	if k.Facets <= 0 {
-		return nil, sdf.ErrMsg("Facets <= 0")
+		return sdf.ErrMsg("Facets <= 0")
 	}
+	return nil
+}
 
-	// pitch radius
-	pitchRadius := float64(k.NumberTeeth) * k.Module * 0.5
+// InvoluteGear returns an 2D polygon for an involute gear.
+func InvoluteGear(k *InvoluteGearParms) (sdf.SDF2, error) {
 
-	// base circle radius
-	baseRadius := pitchRadius * math.Cos(k.PressureAngle)
+	err := k.validate()
+	if err != nil {
+		return nil, err
+	}
 
 	// addendum: radial distance from pitch circle to outside circle
 	addendum := k.Module * 1.0
 	// dedendum: radial distance from pitch circle to root circle
 	dedendum := addendum + k.Clearance
 
-	outerRadius := pitchRadius + addendum
-	rootRadius := pitchRadius - dedendum
-
-	tooth, err := involuteGearTooth(
-		k.NumberTeeth,
-		k.Module,
-		rootRadius,
-		baseRadius,
-		outerRadius,
-		k.Backlash,
-		k.Facets,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	gear := sdf.RotateCopy2D(tooth, k.NumberTeeth)
-
-	root, err := sdf.Circle2D(rootRadius)
+	gear, err := involuteGearBody(k, addendum, dedendum, k.Backlash)
 	if err != nil {
 		return nil, err
 	}
+	rootRadius := float64(k.NumberTeeth)*k.Module*0.5 - dedendum
 
 	// ring
 	ringRadius := 0.0
@@ -169,7 +197,7 @@ func InvoluteGear(k *InvoluteGearParms) (sdf.SDF2, error) {
 		return nil, err
 	}
 
-	return sdf.Difference2D(sdf.Union2D(gear, root), ring), nil
+	return sdf.Difference2D(gear, ring), nil
 }
 
 //-----------------------------------------------------------------------------
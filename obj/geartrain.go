@@ -0,0 +1,422 @@
+//-----------------------------------------------------------------------------
+/*
+
+Gear Trains
+
+Built on the 2D involute gear profile:
+
+* Internal (ring) gears
+* Helical and herringbone gears
+* Straight bevel gears (tapered spur gear profile approximation)
+* Worms and worm wheels
+* Gear pairs: center distance, undercut/interference checks and phasing
+
+The module is always the transverse module (measured in the plane of rotation).
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+// Internal Gears
+
+// InternalGear returns the 2D profile for an internal (ring) gear.
+// k.RingWidth is the width of the ring wall outside the root circle.
+func InternalGear(k *InvoluteGearParms) (sdf.SDF2, error) {
+	err := k.validate()
+	if err != nil {
+		return nil, err
+	}
+	if k.RingWidth <= 0 {
+		return nil, sdf.ErrMsg("RingWidth <= 0")
+	}
+	// The tooth spaces of an internal gear have the shape of an external gear
+	// with the addendum and dedendum swapped. Backlash widens the spaces.
+	addendum := k.Module * 1.0
+	dedendum := addendum + k.Clearance
+	cutter, err := involuteGearBody(k, dedendum, addendum, -k.Backlash)
+	if err != nil {
+		return nil, err
+	}
+	rootRadius := float64(k.NumberTeeth)*k.Module*0.5 + dedendum
+	ring, err := sdf.Circle2D(rootRadius + k.RingWidth)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference2D(ring, cutter), nil
+}
+
+//-----------------------------------------------------------------------------
+// Helical Gears
+
+// HelicalGearParms defines the parameters for a helical gear.
+type HelicalGearParms struct {
+	Gear        InvoluteGearParms // 2D gear parameters
+	Internal    bool              // internal (ring) gear
+	Height      float64           // face width of gear
+	HelixAngle  float64           // helix angle at the pitch circle (radians), > 0 is right hand
+	Herringbone bool              // double helical gear
+}
+
+// HelicalGear3D returns a helical or herringbone gear centered on the z-axis.
+// A mating external gear needs the opposite helix angle, a mating internal gear the same helix angle.
+func HelicalGear3D(k *HelicalGearParms) (sdf.SDF3, error) {
+	if k.Height <= 0 {
+		return nil, sdf.ErrMsg("Height <= 0")
+	}
+	if math.Abs(k.HelixAngle) >= sdf.DtoR(60) {
+		return nil, sdf.ErrMsg("abs(HelixAngle) >= 60 degrees")
+	}
+	var gear2d sdf.SDF2
+	var err error
+	if k.Internal {
+		gear2d, err = InternalGear(&k.Gear)
+	} else {
+		gear2d, err = InvoluteGear(&k.Gear)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pitchRadius := float64(k.Gear.NumberTeeth) * k.Gear.Module * 0.5
+	// rotation of the gear over the face width
+	twist := k.Height * math.Tan(k.HelixAngle) / pitchRadius
+	if !k.Herringbone {
+		return sdf.TwistExtrude3D(gear2d, k.Height, twist), nil
+	}
+	// two half height helical gears, mirrored about the xy plane
+	h := 0.5 * k.Height
+	top := sdf.TwistExtrude3D(gear2d, h, 0.5*twist)
+	top = sdf.Transform3D(top, sdf.Translate3d(v3.Vec{0, 0, 0.5 * h}))
+	bottom := sdf.Transform3D(top, sdf.MirrorXY())
+	return sdf.Union3D(top, bottom), nil
+}
+
+//-----------------------------------------------------------------------------
+// Bevel Gears
+
+// BevelGearParms defines the parameters for a straight bevel gear.
+type BevelGearParms struct {
+	Gear        InvoluteGearParms // gear parameters at the large end of the tooth
+	MatingTeeth int               // number of teeth on the mating gear
+	ShaftAngle  float64           // angle between the gear shafts (radians), 0 = 90 degrees
+	FaceWidth   float64           // length of the teeth along the pitch cone
+}
+
+// PitchAngle returns the pitch cone half angle for a bevel gear.
+func (k *BevelGearParms) PitchAngle() float64 {
+	sigma := k.ShaftAngle
+	if sigma == 0 {
+		sigma = sdf.Pi * 0.5
+	}
+	n0 := float64(k.Gear.NumberTeeth)
+	n1 := float64(k.MatingTeeth)
+	return math.Atan2(math.Sin(sigma), n1/n0+math.Cos(sigma))
+}
+
+// ConeDistance returns the distance from the pitch cone apex to the large end of the teeth.
+func (k *BevelGearParms) ConeDistance() float64 {
+	pitchRadius := float64(k.Gear.NumberTeeth) * k.Gear.Module * 0.5
+	return pitchRadius / math.Sin(k.PitchAngle())
+}
+
+// BevelGear3D returns an approximate straight bevel gear.
+// The large end of the teeth is on the xy plane and the pitch cone apex is on the +z axis.
+// The teeth are the spur gear profile of the large end, scaled towards the
+// apex. This is an approximation, the tooth form is not corrected for the
+// pitch cone (no back cone or virtual tooth count), so a pair will only run
+// with generous backlash. Use it for low speed and lightly loaded drives.
+func BevelGear3D(k *BevelGearParms) (sdf.SDF3, error) {
+	err := k.Gear.validate()
+	if err != nil {
+		return nil, err
+	}
+	if k.MatingTeeth <= 0 {
+		return nil, sdf.ErrMsg("MatingTeeth <= 0")
+	}
+	if k.ShaftAngle < 0 || k.ShaftAngle >= sdf.Pi {
+		return nil, sdf.ErrMsg("ShaftAngle must be [0..Pi)")
+	}
+	if k.FaceWidth <= 0 {
+		return nil, sdf.ErrMsg("FaceWidth <= 0")
+	}
+	coneDistance := k.ConeDistance()
+	if k.FaceWidth > coneDistance/3.0 {
+		return nil, sdf.ErrMsg("FaceWidth > ConeDistance/3")
+	}
+	gear2d, err := InvoluteGear(&k.Gear)
+	if err != nil {
+		return nil, err
+	}
+	delta := k.PitchAngle()
+	height := k.FaceWidth * math.Cos(delta)
+	scale := 1.0 - k.FaceWidth/coneDistance
+	s := sdf.ScaleExtrude3D(gear2d, height, v2.Vec{scale, scale})
+	return sdf.Transform3D(s, sdf.Translate3d(v3.Vec{0, 0, 0.5 * height})), nil
+}
+
+//-----------------------------------------------------------------------------
+// Worms and Worm Wheels
+
+// WormParms defines the parameters for a worm and worm wheel.
+type WormParms struct {
+	Module        float64 // axial module of the worm (transverse module of the wheel)
+	Starts        int     // number of thread starts on the worm
+	PitchRadius   float64 // pitch radius of the worm
+	PressureAngle float64 // pressure angle (radians)
+	Clearance     float64 // additional root clearance
+	Backlash      float64 // backlash expressed as per-tooth distance at pitch line
+	Length        float64 // length of the worm
+}
+
+func (k *WormParms) validate() error {
+	if k.Module <= 0 {
+		return sdf.ErrMsg("Module <= 0")
+	}
+	if k.Starts <= 0 {
+		return sdf.ErrMsg("Starts <= 0")
+	}
+	if k.PitchRadius <= 1.25*k.Module+k.Clearance {
+		return sdf.ErrMsg("PitchRadius is too small")
+	}
+	if k.PressureAngle <= 0 {
+		return sdf.ErrMsg("PressureAngle <= 0")
+	}
+	if k.Clearance < 0 {
+		return sdf.ErrMsg("Clearance < 0")
+	}
+	if k.Backlash < 0 {
+		return sdf.ErrMsg("Backlash < 0")
+	}
+	return nil
+}
+
+// LeadAngle returns the lead angle of the worm thread.
+func (k *WormParms) LeadAngle() float64 {
+	lead := float64(k.Starts) * sdf.Pi * k.Module
+	return math.Atan(lead / (sdf.Tau * k.PitchRadius))
+}
+
+// CenterDistance returns the center distance between a worm and a worm wheel.
+func (k *WormParms) CenterDistance(wheelTeeth int) float64 {
+	return k.PitchRadius + float64(wheelTeeth)*k.Module*0.5
+}
+
+// Worm3D returns a worm centered on the z-axis.
+func Worm3D(k *WormParms) (sdf.SDF3, error) {
+	err := k.validate()
+	if err != nil {
+		return nil, err
+	}
+	if k.Length <= 0 {
+		return nil, sdf.ErrMsg("Length <= 0")
+	}
+	pitch := sdf.Pi * k.Module
+	addendum := k.Module
+	dedendum := 1.25*k.Module + k.Clearance
+	rCrest := k.PitchRadius + addendum
+	rRoot := k.PitchRadius - dedendum
+	// rack tooth profile, the tooth is centered on the y-axis
+	t := math.Tan(k.PressureAngle)
+	halfWidth := 0.25*pitch - 0.5*k.Backlash
+	xCrest := halfWidth - addendum*t
+	xRoot := halfWidth + dedendum*t
+	if xCrest <= 0 {
+		return nil, sdf.ErrMsg("PressureAngle is too large")
+	}
+	p := sdf.NewPolygon()
+	p.Add(pitch, 0)
+	p.Add(pitch, rRoot)
+	p.Add(xRoot, rRoot)
+	p.Add(xCrest, rCrest)
+	p.Add(-xCrest, rCrest)
+	p.Add(-xRoot, rRoot)
+	p.Add(-pitch, rRoot)
+	p.Add(-pitch, 0)
+	profile, err := sdf.Polygon2D(p.Vertices())
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Screw3D(profile, k.Length, 0, pitch, k.Starts)
+}
+
+// WormWheel3D returns a worm wheel (a helical gear matched to the worm lead angle).
+func WormWheel3D(
+	k *WormParms, // worm parameters
+	wheelTeeth int, // number of teeth on the worm wheel
+	height float64, // face width of the worm wheel
+	facets int, // number of facets for involute flank
+) (sdf.SDF3, error) {
+	err := k.validate()
+	if err != nil {
+		return nil, err
+	}
+	return HelicalGear3D(&HelicalGearParms{
+		Gear: InvoluteGearParms{
+			NumberTeeth:   wheelTeeth,
+			Module:        k.Module,
+			PressureAngle: k.PressureAngle,
+			Backlash:      k.Backlash,
+			Clearance:     k.Clearance,
+			Facets:        facets,
+		},
+		Height:     height,
+		HelixAngle: k.LeadAngle(),
+	})
+}
+
+//-----------------------------------------------------------------------------
+// Gear Pairs
+
+// GearPair defines a pair of meshing spur/helical gears.
+// Gear 0 is at the origin and gear 1 is placed on the +x axis.
+// If gear 1 is an internal gear it is at the origin and gear 0 is placed on the +x axis.
+// The module and pressure angle of helical gears are in the transverse plane (as for HelicalGear3D).
+type GearPair struct {
+	Module        float64 // gear module
+	PressureAngle float64 // pressure angle (radians)
+	Teeth0        int     // number of teeth on gear 0
+	Teeth1        int     // number of teeth on gear 1
+	Internal      bool    // gear 1 is an internal (ring) gear
+	HelixAngle0   float64 // helix angle of gear 0 (radians), > 0 is right hand, 0 for spur gears
+	HelixAngle1   float64 // helix angle of gear 1 (radians), > 0 is right hand, 0 for spur gears
+}
+
+// Ratio returns the speed ratio (gear 0 speed / gear 1 speed).
+func (g *GearPair) Ratio() float64 {
+	return float64(g.Teeth1) / float64(g.Teeth0)
+}
+
+// CenterDistance returns the distance between the gear centers.
+func (g *GearPair) CenterDistance() float64 {
+	if g.Internal {
+		return 0.5 * g.Module * float64(g.Teeth1-g.Teeth0)
+	}
+	return 0.5 * g.Module * float64(g.Teeth0+g.Teeth1)
+}
+
+// MinTeeth returns the minimum number of teeth on an external gear without undercut.
+func (g *GearPair) MinTeeth() int {
+	s := math.Sin(g.PressureAngle)
+	return int(math.Ceil(2.0/(s*s) - 1e-9))
+}
+
+// ContactRatio returns the average number of teeth in contact.
+func (g *GearPair) ContactRatio() float64 {
+	r0 := 0.5 * g.Module * float64(g.Teeth0)
+	r1 := 0.5 * g.Module * float64(g.Teeth1)
+	c := math.Cos(g.PressureAngle)
+	s := math.Sin(g.PressureAngle)
+	rb0, rb1 := r0*c, r1*c
+	ra0 := r0 + g.Module
+	l0 := math.Sqrt(ra0*ra0 - rb0*rb0)
+	// length of the line of action / base pitch
+	var length float64
+	if g.Internal {
+		ra1 := r1 - g.Module
+		length = l0 - math.Sqrt(ra1*ra1-rb1*rb1) + g.CenterDistance()*s
+	} else {
+		ra1 := r1 + g.Module
+		length = l0 + math.Sqrt(ra1*ra1-rb1*rb1) - g.CenterDistance()*s
+	}
+	return length / (sdf.Pi * g.Module * c)
+}
+
+// Validate checks the gear pair for mismatched helix angles, undercut, interference and a poor contact ratio.
+func (g *GearPair) Validate() error {
+	if g.Module <= 0 {
+		return sdf.ErrMsg("Module <= 0")
+	}
+	if g.PressureAngle <= 0 {
+		return sdf.ErrMsg("PressureAngle <= 0")
+	}
+	if g.Teeth0 <= 0 || g.Teeth1 <= 0 {
+		return sdf.ErrMsg("number of teeth <= 0")
+	}
+	// external gears have opposite hands, an internal gear has the same hand
+	helix1 := -g.HelixAngle0
+	if g.Internal {
+		helix1 = g.HelixAngle0
+	}
+	if math.Abs(g.HelixAngle1-helix1) > 1e-9 {
+		return fmt.Errorf("gear 1 helix angle %.2f should be %.2f degrees", sdf.RtoD(g.HelixAngle1), sdf.RtoD(helix1))
+	}
+	n := g.MinTeeth()
+	if g.Teeth0 < n {
+		return fmt.Errorf("gear 0 is undercut (%d teeth < %d)", g.Teeth0, n)
+	}
+	c := math.Cos(g.PressureAngle)
+	s := math.Sin(g.PressureAngle)
+	cd := g.CenterDistance()
+	r0 := 0.5 * g.Module * float64(g.Teeth0)
+	r1 := 0.5 * g.Module * float64(g.Teeth1)
+	if g.Internal {
+		if g.Teeth1-g.Teeth0 < 12 {
+			return fmt.Errorf("internal gear tip interference (teeth difference %d < 12)", g.Teeth1-g.Teeth0)
+		}
+	} else {
+		if g.Teeth1 < n {
+			return fmt.Errorf("gear 1 is undercut (%d teeth < %d)", g.Teeth1, n)
+		}
+		// the tip of each gear must not pass the tangency point of the other base circle
+		ra0 := r0 + g.Module
+		ra1 := r1 + g.Module
+		if ra1 > math.Sqrt(r1*c*r1*c+cd*s*cd*s)+1e-9 {
+			return sdf.ErrMsg("gear 1 tip interferes with gear 0 flank")
+		}
+		if ra0 > math.Sqrt(r0*c*r0*c+cd*s*cd*s)+1e-9 {
+			return sdf.ErrMsg("gear 0 tip interferes with gear 1 flank")
+		}
+	}
+	if cr := g.ContactRatio(); cr < 1.2 {
+		return fmt.Errorf("contact ratio %.2f < 1.2", cr)
+	}
+	return nil
+}
+
+// phase returns the rotation of gear 1 for a given rotation of gear 0.
+// Gear 0 has a tooth on the +x axis at theta = 0.
+func (g *GearPair) phase(theta float64) float64 {
+	n1 := float64(g.Teeth1)
+	if g.Internal {
+		// gear 0 is on the +x axis, the internal gear has a space on the +x axis
+		return theta / g.Ratio()
+	}
+	// a gear 1 space is needed on the -x axis
+	return -theta/g.Ratio() + sdf.Pi - sdf.Pi/n1
+}
+
+// Transform2d returns the 2D transforms that place the gears in mesh when gear 0 is rotated by theta.
+func (g *GearPair) Transform2d(theta float64) (sdf.M33, sdf.M33) {
+	m0 := sdf.Rotate2d(theta)
+	m1 := sdf.Rotate2d(g.phase(theta))
+	if !g.Internal {
+		m1 = sdf.Translate2d(v2.Vec{g.CenterDistance(), 0}).Mul(m1)
+	} else {
+		m0 = sdf.Translate2d(v2.Vec{g.CenterDistance(), 0}).Mul(m0)
+	}
+	return m0, m1
+}
+
+// Transform3d returns the 3D transforms that place the gears in mesh when gear 0 is rotated by theta.
+func (g *GearPair) Transform3d(theta float64) (sdf.M44, sdf.M44) {
+	m0 := sdf.RotateZ(theta)
+	m1 := sdf.RotateZ(g.phase(theta))
+	if !g.Internal {
+		m1 = sdf.Translate3d(v3.Vec{g.CenterDistance(), 0, 0}).Mul(m1)
+	} else {
+		m0 = sdf.Translate3d(v3.Vec{g.CenterDistance(), 0, 0}).Mul(m0)
+	}
+	return m0, m1
+}
+
+//-----------------------------------------------------------------------------
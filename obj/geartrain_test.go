@@ -0,0 +1,142 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_GearPair(t *testing.T) {
+	pa := sdf.DtoR(20)
+	tests := []struct {
+		g       GearPair
+		cd      float64
+		ratio   float64
+		contact float64
+	}{
+		{GearPair{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 40}, 30, 2, 1.6352},
+		{GearPair{Module: 2, PressureAngle: pa, Teeth0: 18, Teeth1: 18}, 36, 1, 1.5298},
+		{GearPair{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 60, Internal: true}, 20, 3, 1.9497},
+	}
+	for _, test := range tests {
+		g := test.g
+		if err := g.Validate(); err != nil {
+			t.Errorf("%+v: %s", g, err)
+		}
+		if math.Abs(g.CenterDistance()-test.cd) > tolerance {
+			t.Errorf("%+v: expected center distance %g, got %g", g, test.cd, g.CenterDistance())
+		}
+		if math.Abs(g.Ratio()-test.ratio) > tolerance {
+			t.Errorf("%+v: expected ratio %g, got %g", g, test.ratio, g.Ratio())
+		}
+		if math.Abs(g.ContactRatio()-test.contact) > 1e-4 {
+			t.Errorf("%+v: expected contact ratio %g, got %g", g, test.contact, g.ContactRatio())
+		}
+	}
+
+	if n := (&GearPair{PressureAngle: pa}).MinTeeth(); n != 18 {
+		t.Errorf("expected 18 teeth minimum at 20 degrees, got %d", n)
+	}
+	if n := (&GearPair{PressureAngle: sdf.DtoR(14.5)}).MinTeeth(); n != 32 {
+		t.Errorf("expected 32 teeth minimum at 14.5 degrees, got %d", n)
+	}
+
+	// invalid pairs
+	for _, g := range []GearPair{
+		{Module: 0, PressureAngle: pa, Teeth0: 20, Teeth1: 40},
+		{Module: 1, PressureAngle: 0, Teeth0: 20, Teeth1: 40},
+		{Module: 1, PressureAngle: pa, Teeth0: 0, Teeth1: 40},
+		{Module: 1, PressureAngle: pa, Teeth0: 12, Teeth1: 40},
+		{Module: 1, PressureAngle: pa, Teeth0: 40, Teeth1: 12},
+		{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 30, Internal: true},
+		// external helical gears need opposite hands
+		{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 40, HelixAngle0: sdf.DtoR(15), HelixAngle1: sdf.DtoR(15)},
+		{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 40, HelixAngle0: sdf.DtoR(15)},
+		// an internal helical gear needs the same hand
+		{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 60, Internal: true, HelixAngle0: sdf.DtoR(15), HelixAngle1: sdf.DtoR(-15)},
+	} {
+		if err := g.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", g)
+		}
+	}
+	// matched helical pairs
+	for _, g := range []GearPair{
+		{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 40, HelixAngle0: sdf.DtoR(15), HelixAngle1: sdf.DtoR(-15)},
+		{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 60, Internal: true, HelixAngle0: sdf.DtoR(15), HelixAngle1: sdf.DtoR(15)},
+	} {
+		if err := g.Validate(); err != nil {
+			t.Errorf("%+v: %s", g, err)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// meshGears returns the 2d gear profiles of a gear pair placed in mesh.
+func meshGears(t *testing.T, g *GearPair, theta float64) (sdf.SDF2, sdf.SDF2) {
+	k0 := &InvoluteGearParms{
+		NumberTeeth:   g.Teeth0,
+		Module:        g.Module,
+		PressureAngle: g.PressureAngle,
+		Backlash:      0.1 * g.Module,
+		Clearance:     0.1 * g.Module,
+		Facets:        7,
+	}
+	k1 := *k0
+	k1.NumberTeeth = g.Teeth1
+	k1.RingWidth = 2 * g.Module
+	gear0, err := InvoluteGear(k0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gear1 sdf.SDF2
+	if g.Internal {
+		gear1, err = InternalGear(&k1)
+	} else {
+		gear1, err = InvoluteGear(&k1)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	m0, m1 := g.Transform2d(theta)
+	return sdf.Transform2D(gear0, m0), sdf.Transform2D(gear1, m1)
+}
+
+func Test_GearMesh(t *testing.T) {
+	pa := sdf.DtoR(20)
+	for _, g := range []*GearPair{
+		{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 40},
+		{Module: 1, PressureAngle: pa, Teeth0: 19, Teeth1: 31},
+		{Module: 1, PressureAngle: pa, Teeth0: 20, Teeth1: 60, Internal: true},
+		{Module: 1, PressureAngle: pa, Teeth0: 17, Teeth1: 49, Internal: true},
+	} {
+		// the mesh point is on the line between the gear centers
+		x := 0.5 * g.Module * float64(g.Teeth0)
+		if g.Internal {
+			x += g.CenterDistance()
+		}
+		for _, theta := range []float64{0, 0.1, 0.25, 1} {
+			gear0, gear1 := meshGears(t, g, theta)
+			// the gears must not overlap around the mesh point
+			const n = 40
+			for i := 0; i <= n; i++ {
+				for j := 0; j <= n; j++ {
+					p := v2.Vec{x + 3*g.Module*(float64(i)/n-0.5), 6 * g.Module * (float64(j)/n - 0.5)}
+					if gear0.Evaluate(p) < -0.01 && gear1.Evaluate(p) < -0.01 {
+						t.Fatalf("%+v: gears overlap at %v (theta %g)", g, p, theta)
+					}
+				}
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
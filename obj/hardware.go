@@ -0,0 +1,604 @@
+//-----------------------------------------------------------------------------
+/*
+
+Hardware Catalog
+
+Dimension tables for standard hardware. Each item returns a model of the part
+and a cavity to subtract from a printed part so the hardware fits into it.
+
+* cap screws: socket head (ISO 4762, ASME B18.3), button head (ISO 7380),
+  flat head (ISO 10642). The cavity is an ISO 273 (medium) clearance hole.
+* heat-set inserts: typical brass inserts and their recommended holes.
+* nylock nuts (DIN 985) and T-nuts (DIN 1624).
+* dowel pins (ISO 8734).
+* ball bearings (ISO 15): 608, 625, 6000 series.
+
+Items are looked up by name (see HardwareNames):
+
+* "M3_socket", "M3_button", "M3_flat", "unc_4_40_socket", ...
+* "M3_insert", "M3_nylock", "M4_tnut"
+* "dowel_3"
+* "608", "625", "6000", ...
+
+The hardware sits on the z = 0 plane and goes into a part in the -z direction.
+Screw heads are above the plane (flat heads are flush with it), dowel pins are
+centered on it.
+
+The models are simplified, threads are not modelled. Without a fit the
+cavities are the nominal size. Screws and dowel pins have a typical length,
+change the Length field as needed.
+
+All dimensions are in millimetres.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// Hardware is an item from the hardware catalog.
+type Hardware interface {
+	Model() (sdf.SDF3, error)               // model of the part
+	Cavity(fit *FitParms) (sdf.SDF3, error) // clearance or press fit cavity for the part
+}
+
+// zCylinder returns a cylinder on the z-axis from z0 to z1.
+func zCylinder(r, z0, z1 float64) (sdf.SDF3, error) {
+	s, err := sdf.Cylinder3D(z1-z0, r, 0)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Transform3D(s, sdf.Translate3d(v3.Vec{0, 0, 0.5 * (z0 + z1)})), nil
+}
+
+// zHex returns a hexagonal prism (across flats f) on the z-axis from z0 to z1.
+func zHex(f, z0, z1 float64) (sdf.SDF3, error) {
+	s, err := Hex3D(f/math.Sqrt(3), z1-z0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Transform3D(s, sdf.Translate3d(v3.Vec{0, 0, 0.5 * (z0 + z1)})), nil
+}
+
+//-----------------------------------------------------------------------------
+// cap screws
+
+// CapScrew is a hex socket cap screw.
+type CapScrew struct {
+	Name         string  // catalog name
+	Standard     string  // dimension standard
+	Head         string  // head style: "socket", "button" or "flat"
+	Thread       string  // thread name (see sdf.ThreadLookup)
+	Diameter     float64 // nominal diameter
+	HeadDiameter float64 // head diameter
+	HeadHeight   float64 // head height
+	Socket       float64 // hex socket size (across flats)
+	Hole         float64 // clearance hole diameter
+	Length       float64 // length (under the head, overall for flat heads)
+}
+
+// head returns the screw head (or its cavity) enlarged by a radial offset.
+func (k *CapScrew) head(ofs float64) (sdf.SDF3, error) {
+	r := 0.5*k.HeadDiameter + ofs
+	h := k.HeadHeight
+	switch k.Head {
+	case "socket":
+		return zCylinder(r, 0, h)
+	case "button":
+		// a spherical cap on a short cylinder
+		z1 := 0.2 * h
+		zc := (r*r + z1*z1 - h*h) / (2 * (z1 - h))
+		rs := h - zc
+		a0 := math.Atan2(z1-zc, r)
+		p := sdf.NewPolygon()
+		p.Add(0, 0)
+		p.Add(r, 0)
+		const n = 16
+		for i := 0; i <= n; i++ {
+			a := a0 + (0.5*math.Pi-a0)*float64(i)/n
+			p.Add(rs*math.Cos(a), zc+rs*math.Sin(a))
+		}
+		s, err := sdf.Polygon2D(p.Vertices())
+		if err != nil {
+			return nil, err
+		}
+		return sdf.Revolve3D(s)
+	case "flat":
+		// 90 degree countersunk head, flush with the z = 0 plane
+		h := r - 0.5*k.Diameter - ofs
+		s, err := sdf.Cone3D(h, r-h, r, 0)
+		if err != nil {
+			return nil, err
+		}
+		return sdf.Transform3D(s, sdf.Translate3d(v3.Vec{0, 0, -0.5 * h})), nil
+	}
+	return nil, fmt.Errorf("unknown head style \"%s\"", k.Head)
+}
+
+// Model returns a cap screw.
+func (k *CapScrew) Model() (sdf.SDF3, error) {
+	if k.Length <= 0 {
+		return nil, sdf.ErrMsg("length <= 0")
+	}
+	head, err := k.head(0)
+	if err != nil {
+		return nil, err
+	}
+	shank, err := zCylinder(0.5*k.Diameter, -k.Length, 0)
+	if err != nil {
+		return nil, err
+	}
+	top := k.HeadHeight
+	if k.Head == "flat" {
+		top = 0
+	}
+	socket, err := zHex(k.Socket, top-0.6*k.HeadHeight, top+1)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference3D(sdf.Union3D(head, shank), socket), nil
+}
+
+// Cavity returns a clearance hole for a cap screw. Socket and button heads
+// have a counterbore to the top of the head.
+func (k *CapScrew) Cavity(fit *FitParms) (sdf.SDF3, error) {
+	if k.Length <= 0 {
+		return nil, sdf.ErrMsg("length <= 0")
+	}
+	ofs, err := fit.HoleOffset(k.Hole)
+	if err != nil {
+		return nil, err
+	}
+	hole, err := zCylinder(0.5*k.Hole+ofs, -k.Length, 0)
+	if err != nil {
+		return nil, err
+	}
+	// the head has the same clearance as the hole
+	c := 0.5*(k.Hole-k.Diameter) + ofs
+	var head sdf.SDF3
+	if k.Head == "flat" {
+		head, err = k.head(c)
+	} else {
+		head, err = zCylinder(0.5*k.HeadDiameter+c, 0, k.HeadHeight)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Union3D(hole, head), nil
+}
+
+//-----------------------------------------------------------------------------
+// heat-set inserts
+
+// HeatSetInsert is a threaded brass insert that is melted into a hole.
+type HeatSetInsert struct {
+	Name     string  // catalog name
+	Thread   string  // thread name (see sdf.ThreadLookup)
+	Size     float64 // nominal thread diameter
+	Diameter float64 // outside diameter
+	Length   float64 // insert length
+	Hole     float64 // recommended hole diameter
+}
+
+// Model returns a heat-set insert.
+func (k *HeatSetInsert) Model() (sdf.SDF3, error) {
+	body, err := zCylinder(0.5*k.Diameter, -k.Length, 0)
+	if err != nil {
+		return nil, err
+	}
+	bore, err := zCylinder(0.5*k.Size, -k.Length-1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference3D(body, bore), nil
+}
+
+// Cavity returns the hole for a heat-set insert. The hole is deeper than
+// the insert to leave room for the displaced plastic.
+func (k *HeatSetInsert) Cavity(fit *FitParms) (sdf.SDF3, error) {
+	ofs, err := fit.HoleOffset(k.Hole)
+	if err != nil {
+		return nil, err
+	}
+	return zCylinder(0.5*k.Hole+ofs, -k.Length-1, 0)
+}
+
+//-----------------------------------------------------------------------------
+// nuts
+
+// NylockNut is a hex nut with a nylon locking collar.
+type NylockNut struct {
+	Name     string  // catalog name
+	Standard string  // dimension standard
+	Thread   string  // thread name (see sdf.ThreadLookup)
+	Size     float64 // nominal thread diameter
+	Flats    float64 // width across flats
+	Height   float64 // overall height
+}
+
+// Model returns a nylock nut.
+func (k *NylockNut) Model() (sdf.SDF3, error) {
+	h := 0.75 * k.Height
+	hex, err := zHex(k.Flats, -k.Height, -k.Height+h)
+	if err != nil {
+		return nil, err
+	}
+	collar, err := zCylinder(0.45*k.Flats, -k.Height+h, 0)
+	if err != nil {
+		return nil, err
+	}
+	bore, err := zCylinder(0.5*k.Size, -k.Height-1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference3D(sdf.Union3D(hex, collar), bore), nil
+}
+
+// Cavity returns a hex nut trap for a nylock nut.
+func (k *NylockNut) Cavity(fit *FitParms) (sdf.SDF3, error) {
+	ofs, err := fit.HoleOffset(k.Flats)
+	if err != nil {
+		return nil, err
+	}
+	return zHex(k.Flats+2*ofs, -k.Height, 0)
+}
+
+// TNut is a flanged T-nut (for wood or printed parts).
+type TNut struct {
+	Name      string  // catalog name
+	Standard  string  // dimension standard
+	Thread    string  // thread name (see sdf.ThreadLookup)
+	Size      float64 // nominal thread diameter
+	Barrel    float64 // barrel diameter
+	Length    float64 // overall length
+	Flange    float64 // flange diameter
+	Thickness float64 // flange thickness
+}
+
+// Model returns a T-nut. The prongs are not modelled.
+func (k *TNut) Model() (sdf.SDF3, error) {
+	flange, err := zCylinder(0.5*k.Flange, -k.Thickness, 0)
+	if err != nil {
+		return nil, err
+	}
+	barrel, err := zCylinder(0.5*k.Barrel, -k.Length, 0)
+	if err != nil {
+		return nil, err
+	}
+	bore, err := zCylinder(0.5*k.Size, -k.Length-1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference3D(sdf.Union3D(flange, barrel), bore), nil
+}
+
+// Cavity returns the barrel hole and a flange recess for a T-nut.
+func (k *TNut) Cavity(fit *FitParms) (sdf.SDF3, error) {
+	ofs, err := fit.HoleOffset(k.Barrel)
+	if err != nil {
+		return nil, err
+	}
+	barrel, err := zCylinder(0.5*k.Barrel+ofs, -k.Length, 0)
+	if err != nil {
+		return nil, err
+	}
+	flange, err := zCylinder(0.5*k.Flange+ofs, -k.Thickness, 0)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Union3D(barrel, flange), nil
+}
+
+//-----------------------------------------------------------------------------
+// dowel pins
+
+// DowelPin is a hardened dowel pin.
+type DowelPin struct {
+	Name     string  // catalog name
+	Standard string  // dimension standard
+	Diameter float64 // pin diameter
+	Length   float64 // pin length
+}
+
+// Model returns a dowel pin with chamfered ends.
+func (k *DowelPin) Model() (sdf.SDF3, error) {
+	return sdf.Cylinder3D(k.Length, 0.5*k.Diameter, 0.1*k.Diameter)
+}
+
+// Cavity returns the hole for a dowel pin. The hole is longer than the pin
+// to leave room at the ends of blind holes.
+func (k *DowelPin) Cavity(fit *FitParms) (sdf.SDF3, error) {
+	ofs, err := fit.HoleOffset(k.Diameter)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Cylinder3D(k.Length+1, 0.5*k.Diameter+ofs, 0)
+}
+
+//-----------------------------------------------------------------------------
+// bearings
+
+// Bearing is a deep groove ball bearing.
+type Bearing struct {
+	Name     string  // catalog name
+	Standard string  // dimension standard
+	Bore     float64 // bore diameter
+	Outside  float64 // outside diameter
+	Width    float64 // bearing width
+}
+
+// race returns the radius of the inner and outer race shoulders.
+func (k *Bearing) race() (float64, float64) {
+	w := 0.5 * (k.Outside - k.Bore)
+	return 0.5*k.Bore + 0.3*w, 0.5*k.Outside - 0.3*w
+}
+
+// Model returns a ball bearing with shields.
+func (k *Bearing) Model() (sdf.SDF3, error) {
+	body, err := zCylinder(0.5*k.Outside, -k.Width, 0)
+	if err != nil {
+		return nil, err
+	}
+	bore, err := zCylinder(0.5*k.Bore, -k.Width-1, 1)
+	if err != nil {
+		return nil, err
+	}
+	// shields recessed between the races
+	r0, r1 := k.race()
+	ring, err := zCylinder(r1, -k.Width-1, 1)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := zCylinder(r0, -k.Width-1, 1)
+	if err != nil {
+		return nil, err
+	}
+	ring = sdf.Difference3D(ring, inner)
+	shields, err := zCylinder(r1+1, -k.Width+0.3, -0.3)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference3D(body, sdf.Union3D(bore, sdf.Difference3D(ring, shields))), nil
+}
+
+// Cavity returns the housing for a bearing. There is a relief under the
+// inner race so only the outer race is supported.
+func (k *Bearing) Cavity(fit *FitParms) (sdf.SDF3, error) {
+	ofs, err := fit.HoleOffset(k.Outside)
+	if err != nil {
+		return nil, err
+	}
+	housing, err := zCylinder(0.5*k.Outside+ofs, -k.Width, 0)
+	if err != nil {
+		return nil, err
+	}
+	_, r1 := k.race()
+	relief, err := zCylinder(r1, -k.Width-1, -k.Width+1)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Union3D(housing, relief), nil
+}
+
+//-----------------------------------------------------------------------------
+// catalog
+
+// hardwareDatabase maps a catalog name to a function that returns a new item.
+type hardwareDatabase map[string]func() Hardware
+
+var hardwareDB = initHardwareLookup()
+
+// add adds an item to the hardware database.
+func (m hardwareDatabase) add(name string, fn func() Hardware) {
+	if _, ok := m[name]; ok {
+		panic(fmt.Sprintf("duplicate hardware \"%s\"", name))
+	}
+	m[name] = fn
+}
+
+// capScrew is a row of a cap screw table.
+type capScrew struct {
+	size, thread string
+	d, dk, k, s  float64 // diameter, head diameter, head height, socket
+	hole, length float64 // clearance hole, typical length
+}
+
+// addCapScrews adds a table of cap screws.
+func (m hardwareDatabase) addCapScrews(head, standard string, scale float64, table []capScrew) {
+	for _, r := range table {
+		m.add(r.size+"_"+head, func() Hardware {
+			return &CapScrew{
+				Name:         r.size + "_" + head,
+				Standard:     standard,
+				Head:         head,
+				Thread:       r.thread,
+				Diameter:     scale * r.d,
+				HeadDiameter: scale * r.dk,
+				HeadHeight:   scale * r.k,
+				Socket:       scale * r.s,
+				Hole:         scale * r.hole,
+				Length:       scale * r.length,
+			}
+		})
+	}
+}
+
+// initHardwareLookup adds the standard hardware to the hardware database.
+func initHardwareLookup() hardwareDatabase {
+	m := make(hardwareDatabase)
+
+	// ISO 4762 socket head cap screws
+	m.addCapScrews("socket", "ISO 4762", 1, []capScrew{
+		{"M2", "M2x0.4", 2, 3.8, 2, 1.5, 2.4, 8},
+		{"M2.5", "M2.5x0.45", 2.5, 4.5, 2.5, 2, 2.9, 8},
+		{"M3", "M3x0.5", 3, 5.5, 3, 2.5, 3.4, 10},
+		{"M4", "M4x0.7", 4, 7, 4, 3, 4.5, 12},
+		{"M5", "M5x0.8", 5, 8.5, 5, 4, 5.5, 16},
+		{"M6", "M6x1", 6, 10, 6, 5, 6.6, 16},
+		{"M8", "M8x1.25", 8, 13, 8, 6, 9, 20},
+		{"M10", "M10x1.5", 10, 16, 10, 8, 11, 25},
+		{"M12", "M12x1.75", 12, 18, 12, 10, 13.5, 30},
+	})
+	// ASME B18.3 socket head cap screws (inches)
+	m.addCapScrews("socket", "ASME B18.3", 25.4, []capScrew{
+		{"unc_4_40", "unc_4_40", 0.112, 0.183, 0.112, 3.0 / 32.0, 0.1285, 3.0 / 8.0},
+		{"unc_6_32", "unc_6_32", 0.138, 0.226, 0.138, 7.0 / 64.0, 0.1495, 1.0 / 2.0},
+		{"unc_8_32", "unc_8_32", 0.164, 0.270, 0.164, 9.0 / 64.0, 0.1770, 1.0 / 2.0},
+		{"unc_10_24", "unc_10_24", 0.190, 0.312, 0.190, 5.0 / 32.0, 0.2010, 5.0 / 8.0},
+		{"unc_1/4", "unc_1/4", 0.250, 0.375, 0.250, 3.0 / 16.0, 0.2660, 3.0 / 4.0},
+	})
+	// ISO 7380 button head cap screws
+	m.addCapScrews("button", "ISO 7380", 1, []capScrew{
+		{"M3", "M3x0.5", 3, 5.7, 1.65, 2, 3.4, 10},
+		{"M4", "M4x0.7", 4, 7.6, 2.2, 2.5, 4.5, 12},
+		{"M5", "M5x0.8", 5, 9.5, 2.75, 3, 5.5, 16},
+		{"M6", "M6x1", 6, 10.5, 3.3, 4, 6.6, 16},
+		{"M8", "M8x1.25", 8, 14, 4.4, 5, 9, 20},
+		{"M10", "M10x1.5", 10, 17.5, 5.5, 6, 11, 25},
+		{"M12", "M12x1.75", 12, 21, 6.6, 8, 13.5, 30},
+	})
+	// ISO 10642 flat head cap screws
+	m.addCapScrews("flat", "ISO 10642", 1, []capScrew{
+		{"M3", "M3x0.5", 3, 6.72, 1.86, 2, 3.4, 10},
+		{"M4", "M4x0.7", 4, 8.96, 2.48, 2.5, 4.5, 12},
+		{"M5", "M5x0.8", 5, 11.2, 3.1, 3, 5.5, 16},
+		{"M6", "M6x1", 6, 13.44, 3.72, 4, 6.6, 16},
+		{"M8", "M8x1.25", 8, 17.92, 4.96, 5, 9, 20},
+		{"M10", "M10x1.5", 10, 22.4, 6.2, 6, 11, 25},
+		{"M12", "M12x1.75", 12, 26.88, 7.44, 8, 13.5, 30},
+	})
+
+	// heat-set inserts (typical, check the manufacturer's hole size)
+	for _, r := range []HeatSetInsert{
+		{"M2_insert", "M2x0.4", 2, 3.6, 3, 3.2},
+		{"M2.5_insert", "M2.5x0.45", 2.5, 4.0, 4, 3.6},
+		{"M3_insert", "M3x0.5", 3, 4.6, 5.7, 4.0},
+		{"M4_insert", "M4x0.7", 4, 6.3, 8.1, 5.6},
+		{"M5_insert", "M5x0.8", 5, 7.1, 9.5, 6.4},
+		{"M6_insert", "M6x1", 6, 8.7, 12.7, 8.0},
+	} {
+		m.add(r.Name, func() Hardware { k := r; return &k })
+	}
+
+	// DIN 985 nylock nuts
+	for _, r := range []NylockNut{
+		{"M3_nylock", "DIN 985", "M3x0.5", 3, 5.5, 4},
+		{"M4_nylock", "DIN 985", "M4x0.7", 4, 7, 5},
+		{"M5_nylock", "DIN 985", "M5x0.8", 5, 8, 5},
+		{"M6_nylock", "DIN 985", "M6x1", 6, 10, 6},
+		{"M8_nylock", "DIN 985", "M8x1.25", 8, 13, 8},
+		{"M10_nylock", "DIN 985", "M10x1.5", 10, 17, 10},
+		{"M12_nylock", "DIN 985", "M12x1.75", 12, 19, 12},
+	} {
+		m.add(r.Name, func() Hardware { k := r; return &k })
+	}
+
+	// DIN 1624 T-nuts
+	for _, r := range []TNut{
+		{"M4_tnut", "DIN 1624", "M4x0.7", 4, 5.5, 7, 15, 1.2},
+		{"M5_tnut", "DIN 1624", "M5x0.8", 5, 6.8, 8, 18, 1.5},
+		{"M6_tnut", "DIN 1624", "M6x1", 6, 7.8, 9, 19, 1.5},
+		{"M8_tnut", "DIN 1624", "M8x1.25", 8, 10, 11, 22, 1.5},
+		{"M10_tnut", "DIN 1624", "M10x1.5", 10, 12.5, 13, 28, 2},
+	} {
+		m.add(r.Name, func() Hardware { k := r; return &k })
+	}
+
+	// ISO 8734 dowel pins
+	for _, r := range []DowelPin{
+		{"dowel_2", "ISO 8734", 2, 8},
+		{"dowel_3", "ISO 8734", 3, 10},
+		{"dowel_4", "ISO 8734", 4, 12},
+		{"dowel_5", "ISO 8734", 5, 16},
+		{"dowel_6", "ISO 8734", 6, 20},
+		{"dowel_8", "ISO 8734", 8, 24},
+		{"dowel_10", "ISO 8734", 10, 30},
+	} {
+		m.add(r.Name, func() Hardware { k := r; return &k })
+	}
+
+	// ISO 15 deep groove ball bearings
+	for _, r := range []Bearing{
+		{"625", "ISO 15", 5, 16, 5},
+		{"608", "ISO 15", 8, 22, 7},
+		{"6000", "ISO 15", 10, 26, 8},
+		{"6001", "ISO 15", 12, 28, 8},
+		{"6002", "ISO 15", 15, 32, 9},
+		{"6003", "ISO 15", 17, 35, 10},
+		{"6004", "ISO 15", 20, 42, 12},
+		{"6005", "ISO 15", 25, 47, 12},
+	} {
+		m.add(r.Name, func() Hardware { k := r; return &k })
+	}
+
+	return m
+}
+
+// HardwareLookup returns a new item from the hardware catalog by name.
+func HardwareLookup(name string) (Hardware, error) {
+	if fn, ok := hardwareDB[name]; ok {
+		return fn(), nil
+	}
+	return nil, fmt.Errorf("hardware \"%s\" not found", name)
+}
+
+// HardwareNames returns the sorted names of the items in the hardware catalog.
+func HardwareNames() []string {
+	names := make([]string, 0, len(hardwareDB))
+	for name := range hardwareDB {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//-----------------------------------------------------------------------------
+
+// HardwareParms selects an item from the hardware catalog.
+type HardwareParms struct {
+	Name   string    // catalog name
+	Length float64   // screw or dowel pin length (0 for the typical length)
+	Fit    *FitParms // cavity fit, e.g. "H7" (optional)
+}
+
+// Hardware3D returns the model and the cavity of an item from the hardware catalog.
+func Hardware3D(k *HardwareParms) ([]sdf.SDF3, error) {
+	h, err := HardwareLookup(k.Name)
+	if err != nil {
+		return nil, err
+	}
+	if k.Length < 0 {
+		return nil, sdf.ErrMsg("length < 0")
+	}
+	if k.Length > 0 {
+		switch h := h.(type) {
+		case *CapScrew:
+			h.Length = k.Length
+		case *DowelPin:
+			h.Length = k.Length
+		default:
+			return nil, fmt.Errorf("hardware \"%s\" has a fixed length", k.Name)
+		}
+	}
+	model, err := h.Model()
+	if err != nil {
+		return nil, err
+	}
+	cavity, err := h.Cavity(k.Fit)
+	if err != nil {
+		return nil, err
+	}
+	return []sdf.SDF3{model, cavity}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,91 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_HardwareLookup(t *testing.T) {
+	// every item in the catalog builds
+	for _, name := range HardwareNames() {
+		s, err := Hardware3D(&HardwareParms{Name: name})
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		h, _ := HardwareLookup(name)
+		if _, ok := h.(*HeatSetInsert); ok {
+			// inserts are melted into an undersized hole
+			continue
+		}
+		// the model fits in the cavity
+		bb0 := s[0].BoundingBox()
+		bb1 := s[1].BoundingBox()
+		if bb0.Min.X < bb1.Min.X-tolerance || bb0.Max.X > bb1.Max.X+tolerance {
+			t.Errorf("%s: the model is wider than the cavity", name)
+		}
+	}
+	if _, err := HardwareLookup("M3_teapot"); err == nil {
+		t.Error("expected an error for an unknown item")
+	}
+
+	// the screw head has the same clearance as the hole
+	h, err := HardwareLookup("M3_socket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := h.(*CapScrew)
+	c := 0.5 * (k.Hole - k.Diameter)
+	cavity, err := k.Cavity(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		p v3.Vec
+		d float64
+	}{
+		{v3.Vec{0.5 * k.Hole, 0, -0.5 * k.Length}, 0},
+		{v3.Vec{0.5*k.HeadDiameter + c, 0, 0.5 * k.HeadHeight}, 0},
+		{v3.Vec{0, -0.5*k.HeadDiameter - c, 0.5 * k.HeadHeight}, 0},
+	}
+	for _, test := range tests {
+		if d := cavity.Evaluate(test.p); math.Abs(d-test.d) > 1e-6 {
+			t.Errorf("%v: expected %g, got %g", test.p, test.d, d)
+		}
+	}
+
+	// lengths
+	s, err := Hardware3D(&HardwareParms{Name: "M3_socket", Length: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z := s[0].BoundingBox().Min.Z; math.Abs(z+16) > 1e-6 {
+		t.Errorf("expected a 16 mm screw, got %g", -z)
+	}
+	if _, err := Hardware3D(&HardwareParms{Name: "608", Length: 10}); err == nil {
+		t.Error("expected an error for a fixed length item")
+	}
+
+	// a fit enlarges the cavity
+	s0, err := Hardware3D(&HardwareParms{Name: "dowel_3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := Hardware3D(&HardwareParms{Name: "dowel_3", Fit: &FitParms{Class: "H7", Process: &ProcessParms{HoleOffset: 0.1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := v3.Vec{1.5, 0, 0}
+	if d0, d1 := s0[1].Evaluate(p), s1[1].Evaluate(p); d1 >= d0 || d1 > -0.1 {
+		t.Errorf("expected a larger cavity, got %g and %g", d0, d1)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -35,6 +35,26 @@ func CounterBoredHole3D(
 	return sdf.Union3D(s0, s1), nil
 }
 
+// CounterBoredHoleFit3D returns the SDF3 for a counterbored hole sized with a fit.
+// The fit (e.g. "H12" and a process profile) is applied to both the hole and the counterbore.
+func CounterBoredHoleFit3D(
+	l float64, // total length (includes counterbore)
+	r float64, // hole radius
+	cbRadius float64, // counter bore radius
+	cbDepth float64, // counter bore depth
+	fit *FitParms, // hole fit
+) (sdf.SDF3, error) {
+	ofs, err := fit.HoleOffset(2.0 * r)
+	if err != nil {
+		return nil, err
+	}
+	cbOfs, err := fit.HoleOffset(2.0 * cbRadius)
+	if err != nil {
+		return nil, err
+	}
+	return CounterBoredHole3D(l, r+ofs, cbRadius+cbOfs, cbDepth)
+}
+
 // ChamferedHole3D returns the SDF3 for a chamfered hole (45 degrees).
 func ChamferedHole3D(
 	l float64, // total length (includes chamfer)
@@ -0,0 +1,497 @@
+//-----------------------------------------------------------------------------
+/*
+
+KiCad PCB Files
+
+Read the board outline and mounting holes from a KiCad .kicad_pcb file.
+
+* The outline is made from the gr_line, gr_arc, gr_rect, gr_circle and
+gr_poly items on the Edge.Cuts layer. If there are several closed loops
+(E.g. internal cutouts) the largest is the board outline.
+* The mounting holes are the footprints with "MountingHole" in their name.
+The hole diameter is the largest pad drill.
+
+KiCad uses a y-down coordinate system. The board is flipped to y-up and
+moved so that the bottom left corner of the outline is at the origin.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+// s-expressions
+
+// sexpr is an s-expression, an atom or a list.
+type sexpr struct {
+	atom string
+	list []*sexpr
+}
+
+// name returns the name (first atom) of a list.
+func (x *sexpr) name() string {
+	if x == nil || len(x.list) == 0 {
+		return ""
+	}
+	return x.list[0].atom
+}
+
+// child returns the first child list with a name (nil if there is none).
+func (x *sexpr) child(name string) *sexpr {
+	if x == nil {
+		return nil
+	}
+	for _, c := range x.list {
+		if c.name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// children returns the child lists with a name.
+func (x *sexpr) children(name string) []*sexpr {
+	var list []*sexpr
+	for _, c := range x.list {
+		if c.name() == name {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// number returns the i-th element of a list as a number.
+func (x *sexpr) number(i int) (float64, error) {
+	if x == nil || i >= len(x.list) {
+		return 0, fmt.Errorf("(%s): missing value %d", x.name(), i)
+	}
+	v, err := strconv.ParseFloat(x.list[i].atom, 64)
+	if err != nil {
+		return 0, fmt.Errorf("(%s): %w", x.name(), err)
+	}
+	return v, nil
+}
+
+// point returns the (name x y) child list as a y-up point.
+func (x *sexpr) point(name string) (v2.Vec, error) {
+	c := x.child(name)
+	if c == nil {
+		return v2.Vec{}, fmt.Errorf("(%s): no (%s)", x.name(), name)
+	}
+	px, err := c.number(1)
+	if err != nil {
+		return v2.Vec{}, err
+	}
+	py, err := c.number(2)
+	if err != nil {
+		return v2.Vec{}, err
+	}
+	return v2.Vec{X: px, Y: -py}, nil
+}
+
+// parseSexpr parses an s-expression.
+func parseSexpr(s string) (*sexpr, error) {
+	stack := []*sexpr{{}}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '(':
+			x := &sexpr{}
+			top := stack[len(stack)-1]
+			top.list = append(top.list, x)
+			stack = append(stack, x)
+			i++
+		case c == ')':
+			if len(stack) == 1 {
+				return nil, sdf.ErrMsg("unbalanced ')'")
+			}
+			stack = stack[:len(stack)-1]
+			i++
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			// quoted string
+			var b strings.Builder
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return nil, sdf.ErrMsg("unterminated string")
+			}
+			i++
+			top := stack[len(stack)-1]
+			top.list = append(top.list, &sexpr{atom: b.String()})
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune("() \t\n\r\"", rune(s[j])) {
+				j++
+			}
+			top := stack[len(stack)-1]
+			top.list = append(top.list, &sexpr{atom: s[i:j]})
+			i = j
+		}
+	}
+	if len(stack) != 1 {
+		return nil, sdf.ErrMsg("unbalanced '('")
+	}
+	if len(stack[0].list) != 1 {
+		return nil, sdf.ErrMsg("expected a single s-expression")
+	}
+	return stack[0].list[0], nil
+}
+
+//-----------------------------------------------------------------------------
+// board outline
+
+// kicadArcSegments is the number of line segments per full circle.
+const kicadArcSegments = 72
+
+// arcPoints returns points along an arc from angle a0 to a1 (radians).
+func arcPoints(center v2.Vec, radius, a0, a1 float64) []v2.Vec {
+	n := int(math.Ceil(math.Abs(a1-a0) / sdf.Tau * kicadArcSegments))
+	if n < 1 {
+		n = 1
+	}
+	p := make([]v2.Vec, n+1)
+	for i := range p {
+		a := a0 + (a1-a0)*float64(i)/float64(n)
+		p[i] = center.Add(v2.Vec{X: radius * math.Cos(a), Y: radius * math.Sin(a)})
+	}
+	return p
+}
+
+// arc3Points returns points along an arc through 3 points.
+func arc3Points(p0, p1, p2 v2.Vec) ([]v2.Vec, error) {
+	// circle center from the perpendicular bisectors
+	d := 2 * (p0.X*(p1.Y-p2.Y) + p1.X*(p2.Y-p0.Y) + p2.X*(p0.Y-p1.Y))
+	if math.Abs(d) < 1e-12 {
+		return []v2.Vec{p0, p2}, nil
+	}
+	s0 := p0.Length2()
+	s1 := p1.Length2()
+	s2 := p2.Length2()
+	c := v2.Vec{
+		X: (s0*(p1.Y-p2.Y) + s1*(p2.Y-p0.Y) + s2*(p0.Y-p1.Y)) / d,
+		Y: (s0*(p2.X-p1.X) + s1*(p0.X-p2.X) + s2*(p1.X-p0.X)) / d,
+	}
+	a0 := math.Atan2(p0.Y-c.Y, p0.X-c.X)
+	a1 := math.Atan2(p1.Y-c.Y, p1.X-c.X)
+	a2 := math.Atan2(p2.Y-c.Y, p2.X-c.X)
+	// sweep from p0 to p2 passing through p1
+	sweep := func(a, b float64) float64 {
+		x := math.Mod(b-a, sdf.Tau)
+		if x < 0 {
+			x += sdf.Tau
+		}
+		return x
+	}
+	a := sweep(a0, a2)
+	if sweep(a0, a1) > a {
+		// clockwise
+		a -= sdf.Tau
+	}
+	p := arcPoints(c, p0.Sub(c).Length(), a0, a0+a)
+	p[0], p[len(p)-1] = p0, p2
+	return p, nil
+}
+
+// edgeItem returns the points of an Edge.Cuts item and whether it is closed.
+func edgeItem(x *sexpr) ([]v2.Vec, bool, error) {
+	switch x.name() {
+	case "gr_line":
+		p0, err := x.point("start")
+		if err != nil {
+			return nil, false, err
+		}
+		p1, err := x.point("end")
+		if err != nil {
+			return nil, false, err
+		}
+		return []v2.Vec{p0, p1}, false, nil
+	case "gr_arc":
+		if x.child("mid") != nil {
+			// KiCad 6+: start, mid and end points
+			p0, err := x.point("start")
+			if err != nil {
+				return nil, false, err
+			}
+			p1, err := x.point("mid")
+			if err != nil {
+				return nil, false, err
+			}
+			p2, err := x.point("end")
+			if err != nil {
+				return nil, false, err
+			}
+			p, err := arc3Points(p0, p1, p2)
+			return p, false, err
+		}
+		// KiCad 5: center (start), start point (end) and a clockwise angle in degrees
+		c, err := x.point("start")
+		if err != nil {
+			return nil, false, err
+		}
+		p0, err := x.point("end")
+		if err != nil {
+			return nil, false, err
+		}
+		angle, err := x.child("angle").number(1)
+		if err != nil {
+			return nil, false, err
+		}
+		a0 := math.Atan2(p0.Y-c.Y, p0.X-c.X)
+		p := arcPoints(c, p0.Sub(c).Length(), a0, a0-sdf.DtoR(angle))
+		p[0] = p0
+		return p, false, nil
+	case "gr_rect":
+		p0, err := x.point("start")
+		if err != nil {
+			return nil, false, err
+		}
+		p1, err := x.point("end")
+		if err != nil {
+			return nil, false, err
+		}
+		return []v2.Vec{p0, {X: p1.X, Y: p0.Y}, p1, {X: p0.X, Y: p1.Y}}, true, nil
+	case "gr_circle":
+		c, err := x.point("center")
+		if err != nil {
+			return nil, false, err
+		}
+		p0, err := x.point("end")
+		if err != nil {
+			return nil, false, err
+		}
+		p := arcPoints(c, p0.Sub(c).Length(), 0, sdf.Tau)
+		return p[:len(p)-1], true, nil
+	case "gr_poly":
+		pts := x.child("pts")
+		if pts == nil {
+			return nil, false, sdf.ErrMsg("(gr_poly): no (pts)")
+		}
+		var p []v2.Vec
+		for _, xy := range pts.children("xy") {
+			px, err := xy.number(1)
+			if err != nil {
+				return nil, false, err
+			}
+			py, err := xy.number(2)
+			if err != nil {
+				return nil, false, err
+			}
+			p = append(p, v2.Vec{X: px, Y: -py})
+		}
+		return p, true, nil
+	}
+	return nil, false, nil
+}
+
+// kicadTolerance is the distance for joining outline segments.
+const kicadTolerance = 1e-3
+
+// joinLoops joins open polylines into closed loops.
+func joinLoops(open [][]v2.Vec) ([][]v2.Vec, error) {
+	var loops [][]v2.Vec
+	for len(open) != 0 {
+		chain := open[0]
+		open = open[1:]
+		for !chain[0].Equals(chain[len(chain)-1], kicadTolerance) {
+			end := chain[len(chain)-1]
+			found := false
+			for i, p := range open {
+				if p[len(p)-1].Equals(end, kicadTolerance) {
+					// reverse the segment
+					q := make([]v2.Vec, len(p))
+					for j := range p {
+						q[j] = p[len(p)-1-j]
+					}
+					p = q
+				}
+				if p[0].Equals(end, kicadTolerance) {
+					chain = append(chain, p[1:]...)
+					open = append(open[:i], open[i+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("board outline is not closed at %v", end)
+			}
+		}
+		loops = append(loops, chain[:len(chain)-1])
+	}
+	return loops, nil
+}
+
+// polygonArea returns the signed area of a polygon (> 0 for counter-clockwise).
+func polygonArea(p []v2.Vec) float64 {
+	var a float64
+	for i := range p {
+		j := (i + 1) % len(p)
+		a += p[i].X*p[j].Y - p[j].X*p[i].Y
+	}
+	return 0.5 * a
+}
+
+//-----------------------------------------------------------------------------
+// mounting holes
+
+// footprintHole returns the mounting hole of a footprint.
+func footprintHole(x *sexpr) (*PCBHole, error) {
+	name := ""
+	if len(x.list) > 1 {
+		name = x.list[1].atom
+	}
+	if !strings.Contains(name, "MountingHole") {
+		return nil, nil
+	}
+	at := x.child("at")
+	pos, err := x.point("at")
+	if err != nil {
+		return nil, err
+	}
+	var rotate float64
+	if len(at.list) > 3 {
+		rotate, err = at.number(3)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var h *PCBHole
+	for _, pad := range x.children("pad") {
+		drill := pad.child("drill")
+		if drill == nil {
+			continue
+		}
+		i := 1
+		if len(drill.list) > 1 && drill.list[1].atom == "oval" {
+			// use the smaller oval size
+			i = 2
+		}
+		d, err := drill.number(i)
+		if err != nil {
+			return nil, err
+		}
+		if i == 2 && len(drill.list) > 3 {
+			d1, err := drill.number(3)
+			if err != nil {
+				return nil, err
+			}
+			d = math.Min(d, d1)
+		}
+		if h != nil && d <= h.Diameter {
+			continue
+		}
+		// the pad position is relative to the footprint
+		ofs, err := pad.point("at")
+		if err != nil {
+			return nil, err
+		}
+		ofs = sdf.Rotate(sdf.DtoR(rotate)).MulPosition(ofs)
+		h = &PCBHole{Position: pos.Add(ofs), Diameter: d}
+	}
+	if h == nil {
+		return nil, fmt.Errorf("footprint %s has no drilled pads", name)
+	}
+	return h, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// ParseKiCad returns the board outline and mounting holes of a KiCad PCB file.
+func ParseKiCad(b []byte) (*Board, error) {
+	root, err := parseSexpr(string(b))
+	if err != nil {
+		return nil, err
+	}
+	if root.name() != "kicad_pcb" {
+		return nil, sdf.ErrMsg("not a kicad_pcb file")
+	}
+	board := &Board{Thickness: 1.6}
+	if t := root.child("general").child("thickness"); t != nil {
+		board.Thickness, err = t.number(1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var open, loops [][]v2.Vec
+	for _, x := range root.list {
+		if layer := x.child("layer"); layer == nil || len(layer.list) < 2 || layer.list[1].atom != "Edge.Cuts" {
+			continue
+		}
+		p, closed, err := edgeItem(x)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			continue
+		}
+		if closed {
+			loops = append(loops, p)
+		} else {
+			open = append(open, p)
+		}
+	}
+	joined, err := joinLoops(open)
+	if err != nil {
+		return nil, err
+	}
+	loops = append(loops, joined...)
+	if len(loops) == 0 {
+		return nil, sdf.ErrMsg("no Edge.Cuts board outline")
+	}
+	// the largest loop is the board outline
+	outline := loops[0]
+	for _, p := range loops[1:] {
+		if math.Abs(polygonArea(p)) > math.Abs(polygonArea(outline)) {
+			outline = p
+		}
+	}
+	if polygonArea(outline) < 0 {
+		for i, j := 0, len(outline)-1; i < j; i, j = i+1, j-1 {
+			outline[i], outline[j] = outline[j], outline[i]
+		}
+	}
+
+	// footprints (KiCad 6+) or modules (KiCad 5)
+	for _, x := range root.list {
+		if x.name() != "footprint" && x.name() != "module" {
+			continue
+		}
+		h, err := footprintHole(x)
+		if err != nil {
+			return nil, err
+		}
+		if h != nil {
+			board.Holes = append(board.Holes, *h)
+		}
+	}
+
+	// move the bottom left corner of the outline to the origin
+	origin := v2.VecSet(outline).Min()
+	board.Outline = make([]v2.Vec, len(outline))
+	for i, p := range outline {
+		board.Outline[i] = p.Sub(origin)
+	}
+	for i := range board.Holes {
+		board.Holes[i].Position = board.Holes[i].Position.Sub(origin)
+	}
+	return board, nil
+}
+
+//-----------------------------------------------------------------------------
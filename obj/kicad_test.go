@@ -0,0 +1,241 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// sexprString returns an s-expression in a canonical form.
+func sexprString(x *sexpr) string {
+	if x.list == nil {
+		return x.atom
+	}
+	s := make([]string, len(x.list))
+	for i, c := range x.list {
+		s[i] = sexprString(c)
+	}
+	return "(" + strings.Join(s, " ") + ")"
+}
+
+func Test_ParseSexpr(t *testing.T) {
+	tests := []struct {
+		s      string
+		result string
+	}{
+		{"(a)", "(a)"},
+		{"(a b c)", "(a b c)"},
+		{" ( a\t(b c)\n(d (e f)) )\r\n", "(a (b c) (d (e f)))"},
+		{`(a "b c" d)`, "(a b c d)"},
+		{`(a "(b)")`, "(a (b))"},
+		{`(a "b \"c\" \\d")`, `(a b "c" \d)`},
+		{`(a"b"c)`, "(a b c)"},
+		{"(a (b) ((c)))", "(a (b) ((c)))"},
+		{"(1.5 -2 3e2)", "(1.5 -2 3e2)"},
+	}
+	for _, test := range tests {
+		x, err := parseSexpr(test.s)
+		if err != nil {
+			t.Errorf("%q: %s", test.s, err)
+			continue
+		}
+		if s := sexprString(x); s != test.result {
+			t.Errorf("%q: expected %s, got %s", test.s, test.result, s)
+		}
+	}
+	// quoted atoms are single atoms
+	x, err := parseSexpr(`(footprint "MountingHole:M3 (pad)" (at 1 2))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.name() != "footprint" || x.list[1].atom != "MountingHole:M3 (pad)" || x.child("at") == nil {
+		t.Errorf("bad quoted atom %s", sexprString(x))
+	}
+
+	for _, s := range []string{"", "(a", "(a))", ")", "(a) (b)", `(a "b)`, "(a (b)"} {
+		if _, err := parseSexpr(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Arc3Points(t *testing.T) {
+	tests := []struct {
+		p0, p1, p2 v2.Vec
+		center     v2.Vec
+		sweep      float64 // signed sweep angle (degrees)
+	}{
+		{v2.Vec{1, 0}, v2.Vec{0, 1}, v2.Vec{-1, 0}, v2.Vec{0, 0}, 180},
+		{v2.Vec{1, 0}, v2.Vec{0, -1}, v2.Vec{-1, 0}, v2.Vec{0, 0}, -180},
+		{v2.Vec{12, 5}, v2.Vec{10 + math.Sqrt2, 5 + math.Sqrt2}, v2.Vec{10, 7}, v2.Vec{10, 5}, 90},
+		{v2.Vec{10, 7}, v2.Vec{10 + math.Sqrt2, 5 + math.Sqrt2}, v2.Vec{12, 5}, v2.Vec{10, 5}, -90},
+		{v2.Vec{0, 1}, v2.Vec{-1, 0}, v2.Vec{1, 0}, v2.Vec{0, 0}, 270},
+	}
+	for _, test := range tests {
+		p, err := arc3Points(test.p0, test.p1, test.p2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p[0] != test.p0 || p[len(p)-1] != test.p2 {
+			t.Errorf("%v: bad end points %v %v", test, p[0], p[len(p)-1])
+		}
+		r := test.p0.Sub(test.center).Length()
+		var sweep float64
+		for i, x := range p {
+			if math.Abs(x.Sub(test.center).Length()-r) > 1e-9 {
+				t.Fatalf("%v: point %v is not on the arc", test, x)
+			}
+			if i > 0 {
+				a0 := p[i-1].Sub(test.center)
+				a1 := x.Sub(test.center)
+				a := math.Atan2(a0.Cross(a1), a0.Dot(a1))
+				if math.Abs(a) > sdf.Tau/kicadArcSegments+1e-9 {
+					t.Fatalf("%v: segment %d is too long", test, i)
+				}
+				sweep += a
+			}
+		}
+		if math.Abs(sweep-sdf.DtoR(test.sweep)) > 1e-9 {
+			t.Errorf("%v: expected a %g degree sweep, got %g", test, test.sweep, sdf.RtoD(sweep))
+		}
+	}
+	// colinear points are a line
+	p, err := arc3Points(v2.Vec{0, 0}, v2.Vec{1, 1}, v2.Vec{2, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 2 {
+		t.Errorf("expected a line, got %v", p)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// kicadBoard is a board with a rounded corner (3 point arc) outline, a cutout
+// and mounting holes. The outline is from (0,0) to (50,30) in KiCad coordinates.
+const kicadBoard = `(kicad_pcb (version 20211014) (generator pcbnew)
+  (general (thickness 1.2))
+  (layers (0 "F.Cu" signal) (44 "Edge.Cuts" user))
+  (gr_line (start 0 0) (end 45 0) (layer "Edge.Cuts") (width 0.1))
+  (gr_arc (start 45 0) (mid 48.535534 1.464466) (end 50 5) (layer "Edge.Cuts") (width 0.1))
+  (gr_line (start 50 30) (end 50 5) (layer "Edge.Cuts") (width 0.1))
+  (gr_line (start 50 30) (end 0 30) (layer "Edge.Cuts") (width 0.1))
+  (gr_line (start 0 30) (end 0 0) (layer "Edge.Cuts") (width 0.1))
+  (gr_rect (start 20 10) (end 25 15) (layer "Edge.Cuts") (width 0.1))
+  (gr_line (start 0 0) (end 100 100) (layer "F.SilkS") (width 0.1))
+  (footprint "MountingHole:MountingHole_3.2mm_M3" (layer "F.Cu")
+    (at 5 25)
+    (pad "" np_thru_hole circle (at 0 0) (size 3.2 3.2) (drill 3.2) (layers *.Cu *.Mask))
+  )
+  (footprint "MountingHole:MountingHole_2.2mm_M2_Pad" (layer "F.Cu")
+    (at 40 10 90)
+    (pad "1" thru_hole circle (at 1 0) (size 4 4) (drill 2.2) (layers *.Cu *.Mask))
+    (pad "2" smd circle (at 0 0) (size 1 1) (layers F.Cu))
+  )
+  (footprint "Resistor_SMD:R_0805" (layer "F.Cu")
+    (at 30 20)
+    (pad "1" smd rect (at -1 0) (size 1 1) (layers F.Cu))
+  )
+)`
+
+func Test_ParseKiCad(t *testing.T) {
+	b, err := ParseKiCad([]byte(kicadBoard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Thickness != 1.2 {
+		t.Errorf("expected thickness 1.2, got %g", b.Thickness)
+	}
+
+	// the outline is the largest loop, counter-clockwise and y-up from the origin
+	if a := polygonArea(b.Outline); math.Abs(a-(50*30-25+25*math.Pi/4)) > 0.05 {
+		t.Errorf("bad outline area %g", a)
+	}
+	bb := v2.VecSet(b.Outline)
+	if !bb.Min().Equals(v2.Vec{0, 0}, tolerance) || !bb.Max().Equals(v2.Vec{50, 30}, tolerance) {
+		t.Errorf("bad outline bounds %v %v", bb.Min(), bb.Max())
+	}
+	// the rounded corner is at the top right in y-up coordinates
+	for _, p := range b.Outline {
+		if p.X > 45 && p.Y > 25 && math.Abs(p.Sub(v2.Vec{45, 25}).Length()-5) > 1e-5 {
+			t.Errorf("point %v is not on the corner arc", p)
+		}
+	}
+
+	// the holes are flipped and the pad offsets are rotated with the footprint
+	holes := []PCBHole{
+		{Position: v2.Vec{5, 5}, Diameter: 3.2},
+		{Position: v2.Vec{40, 21}, Diameter: 2.2},
+	}
+	if len(b.Holes) != len(holes) {
+		t.Fatalf("expected %d holes, got %d", len(holes), len(b.Holes))
+	}
+	for i, h := range holes {
+		if !b.Holes[i].Position.Equals(h.Position, 1e-9) || b.Holes[i].Diameter != h.Diameter {
+			t.Errorf("hole %d: expected %+v, got %+v", i, h, b.Holes[i])
+		}
+	}
+
+	// KiCad 5 arcs and modules
+	b, err = ParseKiCad([]byte(`(kicad_pcb
+	  (gr_circle (center 10 10) (end 20 10) (layer Edge.Cuts))
+	  (gr_arc (start 10 10) (end 12 10) (angle -90) (layer Edge.Cuts))
+	  (gr_line (start 10 8) (end 10 10) (layer Edge.Cuts))
+	  (gr_line (start 10 10) (end 12 10) (layer Edge.Cuts))
+	  (module MountingHole_3mm (at 10 15 180) (pad "" np_thru_hole oval (at 2 0) (size 3 4) (drill oval 3 4)))
+	)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Thickness != 1.6 {
+		t.Errorf("expected the default thickness, got %g", b.Thickness)
+	}
+	if a := polygonArea(b.Outline); math.Abs(a-100*math.Pi) > 0.5 {
+		t.Errorf("bad circle outline area %g", a)
+	}
+	if len(b.Holes) != 1 || !b.Holes[0].Position.Equals(v2.Vec{8, 5}, 1e-9) || b.Holes[0].Diameter != 3 {
+		t.Errorf("bad module hole %+v", b.Holes)
+	}
+}
+
+func Test_ParseKiCadErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"(kicad_pcb",
+		"(board)",
+		"(kicad_pcb (general (thickness x)))",
+		"(kicad_pcb)",
+		`(kicad_pcb (gr_line (start 0 0) (end 10 0) (layer "Edge.Cuts")))`,
+		`(kicad_pcb (gr_line (start 0 0) (layer "Edge.Cuts")))`,
+		`(kicad_pcb (gr_line (start 0) (end 10 0) (layer "Edge.Cuts")))`,
+		`(kicad_pcb (gr_line (start a b) (end 10 0) (layer "Edge.Cuts")))`,
+		`(kicad_pcb (gr_arc (start 0 0) (end 10 0) (layer "Edge.Cuts")))`,
+		`(kicad_pcb (gr_poly (layer "Edge.Cuts")))`,
+		`(kicad_pcb (gr_rect (start 0 0) (end 10 10) (layer "Edge.Cuts")) (footprint "MountingHole" (at 1 1)))`,
+		`(kicad_pcb (gr_rect (start 0 0) (end 10 10) (layer "Edge.Cuts")) (footprint "MountingHole" (pad "" (at 0 0) (drill 3))))`,
+		`(kicad_pcb (gr_rect (start 0 0) (end 10 10) (layer "Edge.Cuts")) (footprint "MountingHole" (at 1 1) (pad "" (drill 3))))`,
+		`(kicad_pcb (gr_rect (start 0 0) (end 10 10) (layer "Edge.Cuts")) (footprint "MountingHole" (at 1 1 x) (pad "" (at 0 0) (drill 3))))`,
+		`(kicad_pcb (gr_rect (start 0 0) (end 10 10) (layer "Edge.Cuts")) (footprint "MountingHole" (at 1 1) (pad "" (at 0 0) (drill oval))))`,
+	} {
+		if _, err := ParseKiCad([]byte(s)); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+	// truncated files return an error or a board, they don't panic
+	for i := range kicadBoard {
+		ParseKiCad([]byte(kicadBoard[:i]))
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -17,10 +17,11 @@ import (
 //-----------------------------------------------------------------------------
 
 type ThreadedCylinderParms struct {
-	Height    float64 // height of cylinder
-	Diameter  float64 // diameter of cylinder
-	Thread    string  // name of thread
-	Tolerance float64 // add to internal thread radius
+	Height    float64   // height of cylinder
+	Diameter  float64   // diameter of cylinder
+	Thread    string    // name of thread
+	Tolerance float64   // add to internal thread radius
+	Fit       *FitParms // thread fit, e.g. "6H" (optional)
 }
 
 // Object returns a cylinder with an internal thread.
@@ -45,11 +46,15 @@ func (k *ThreadedCylinderParms) Object() (sdf.SDF3, error) {
 	}
 	// internal thread
 	t = t.ToMillimetre()
-	isoThread, err := sdf.ISOThread(t.Radius+k.Tolerance, t.Pitch, false)
+	ofs, err := threadOffset(k.Fit, t, false)
 	if err != nil {
 		return nil, err
 	}
-	thread, err := sdf.Screw3D(isoThread, k.Height, t.Taper, t.Pitch, 1)
+	profile, err := t.Profile(t.Radius+k.Tolerance+ofs, false)
+	if err != nil {
+		return nil, err
+	}
+	thread, err := sdf.Screw3D(profile, k.Height, t.Taper, t.Pitch, 1)
 	if err != nil {
 		return nil, err
 	}
@@ -60,9 +65,10 @@ func (k *ThreadedCylinderParms) Object() (sdf.SDF3, error) {
 
 // NutParms defines the parameters for a nut.
 type NutParms struct {
-	Thread    string  // name of thread
-	Style     string  // head style "hex" or "knurl"
-	Tolerance float64 // add to internal thread radius
+	Thread    string    // name of thread
+	Style     string    // head style "hex" or "knurl"
+	Tolerance float64   // add to internal thread radius
+	Fit       *FitParms // thread fit, e.g. "6H" (optional)
 }
 
 // Nut returns a simple nut suitable for 3d printing.
@@ -93,11 +99,15 @@ func Nut(k *NutParms) (sdf.SDF3, error) {
 	}
 
 	// internal thread
-	isoThread, err := sdf.ISOThread(t.Radius+k.Tolerance, t.Pitch, false)
+	ofs, err := threadOffset(k.Fit, t, false)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := t.Profile(t.Radius+k.Tolerance+ofs, false)
 	if err != nil {
 		return nil, err
 	}
-	thread, err := sdf.Screw3D(isoThread, nh, t.Taper, t.Pitch, 1)
+	thread, err := sdf.Screw3D(profile, nh, t.Taper, t.Pitch, 1)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,240 @@
+//-----------------------------------------------------------------------------
+/*
+
+Casting Patterns and Core Boxes
+
+A casting pattern is the part enlarged for the shrinkage of the metal as it
+cools, with extra material (stock) on the faces that will be machined and
+core prints to locate the cores in the mold.
+
+A core box is the mold for a sand core. It's split into two halves so the
+core can be rammed and removed.
+
+The shrinkage values are the usual pattern maker's allowances for sand
+castings. Check them against your foundry practice.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+// Shrinkage
+
+// alloyShrinkage is the linear shrinkage (per axis) for casting alloys.
+var alloyShrinkage = map[string]v3.Vec{
+	"aluminium": {X: 0.013, Y: 0.013, Z: 0.013},
+	"brass":     {X: 0.015, Y: 0.015, Z: 0.015},
+	"bronze":    {X: 0.015, Y: 0.015, Z: 0.015},
+	"iron":      {X: 0.010, Y: 0.010, Z: 0.010}, // grey iron
+	"steel":     {X: 0.020, Y: 0.020, Z: 0.020},
+}
+
+// AlloyShrinkage returns the linear shrinkage (per axis) for a casting alloy.
+func AlloyShrinkage(alloy string) (v3.Vec, error) {
+	name := strings.ToLower(alloy)
+	if name == "aluminum" {
+		name = "aluminium"
+	}
+	x, ok := alloyShrinkage[name]
+	if !ok {
+		return v3.Vec{}, fmt.Errorf("unknown alloy \"%s\" (%s)", alloy, strings.Join(Alloys(), ", "))
+	}
+	return x, nil
+}
+
+// Alloys returns the names of the casting alloys with shrinkage values.
+func Alloys() []string {
+	names := make([]string, 0, len(alloyShrinkage))
+	for k := range alloyShrinkage {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shrinkage defines the shrinkage allowance for a pattern or core box.
+type Shrinkage struct {
+	Alloy   string  // casting alloy (E.g. "aluminium")
+	Shrink  v3.Vec  // per axis linear shrinkage, overrides the alloy value if non-zero
+	Pattern float64 // shrinkage of a printed pattern material (E.g. PLA ~0.002)
+}
+
+// Scale returns the per axis scale factors for the shrinkage allowance.
+func (k *Shrinkage) Scale() (v3.Vec, error) {
+	shrink := k.Shrink
+	if shrink == (v3.Vec{}) && k.Alloy != "" {
+		var err error
+		shrink, err = AlloyShrinkage(k.Alloy)
+		if err != nil {
+			return v3.Vec{}, err
+		}
+	}
+	if shrink.MinComponent() < 0 || shrink.MaxComponent() >= 1 {
+		return v3.Vec{}, sdf.ErrMsg("shrinkage must be >= 0 and < 1")
+	}
+	if k.Pattern < 0 || k.Pattern >= 1 {
+		return v3.Vec{}, sdf.ErrMsg("pattern shrinkage must be >= 0 and < 1")
+	}
+	p := 1 / (1 - k.Pattern)
+	return v3.Vec{
+		X: p / (1 - shrink.X),
+		Y: p / (1 - shrink.Y),
+		Z: p / (1 - shrink.Z),
+	}, nil
+}
+
+// apply scales an SDF3 for the shrinkage allowance.
+func (k *Shrinkage) apply(s sdf.SDF3) (sdf.SDF3, error) {
+	scale, err := k.Scale()
+	if err != nil {
+		return nil, err
+	}
+	if scale == (v3.Vec{X: 1, Y: 1, Z: 1}) {
+		return s, nil
+	}
+	return sdf.Transform3D(s, sdf.Scale3d(scale)), nil
+}
+
+//-----------------------------------------------------------------------------
+// Machining Stock
+
+// MachinedFace is a planar face that is machined after casting.
+// The face is on a plane through Point with an outward Normal, any material
+// beyond the plane (as for Cut3D) is part of the face.
+type MachinedFace struct {
+	Point  v3.Vec  // a point on the face plane
+	Normal v3.Vec  // outward normal of the face
+	Stock  float64 // thickness of the machining stock
+}
+
+// stockSDF3 adds machining stock to a face.
+// The material beyond the section plane (just inside the face) is moved out
+// by the stock thickness and the gap is filled with the section.
+type stockSDF3 struct {
+	sdf   sdf.SDF3
+	c     v3.Vec  // point on the section plane
+	n     v3.Vec  // unit normal of the section plane
+	stock float64 // stock thickness
+	bb    sdf.Box3
+}
+
+// machiningStock returns an SDF3 with machining stock added to a face.
+func machiningStock(s sdf.SDF3, k *MachinedFace) (sdf.SDF3, error) {
+	if k.Normal.Length() == 0 {
+		return nil, sdf.ErrMsg("face normal has zero length")
+	}
+	if k.Stock < 0 {
+		return nil, sdf.ErrMsg("stock < 0")
+	}
+	if k.Stock == 0 {
+		return s, nil
+	}
+	n := k.Normal.Normalize()
+	bb := s.BoundingBox()
+	return &stockSDF3{
+		sdf: s,
+		// take the section half the stock thickness inside the face
+		c:     k.Point.Sub(n.MulScalar(0.5 * k.Stock)),
+		n:     n,
+		stock: k.Stock,
+		bb:    bb.Extend(bb.Translate(n.MulScalar(k.Stock))),
+	}, nil
+}
+
+// Evaluate returns the minimum distance to the machining stock SDF3.
+func (s *stockSDF3) Evaluate(p v3.Vec) float64 {
+	h := p.Sub(s.c).Dot(s.n)
+	return s.sdf.Evaluate(p.Sub(s.n.MulScalar(sdf.Clamp(h, 0, s.stock))))
+}
+
+// BoundingBox returns the bounding box of the machining stock SDF3.
+func (s *stockSDF3) BoundingBox() sdf.Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Pattern
+
+// PatternParms defines the parameters for a casting pattern.
+type PatternParms struct {
+	Shrinkage                 // shrinkage allowance
+	Machined   []MachinedFace // faces with machining stock
+	CorePrints sdf.SDF3       // core prints to locate the cores in the mold (optional)
+}
+
+// Pattern returns a casting pattern for a part.
+// Machining stock is added and core prints are unioned in part coordinates,
+// then the pattern is scaled for the shrinkage allowance.
+func Pattern(part sdf.SDF3, k *PatternParms) (sdf.SDF3, error) {
+	s := part
+	for i := range k.Machined {
+		var err error
+		s, err = machiningStock(s, &k.Machined[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if k.CorePrints != nil {
+		s = sdf.Union3D(s, k.CorePrints)
+	}
+	return k.Shrinkage.apply(s)
+}
+
+//-----------------------------------------------------------------------------
+// Core Box
+
+// CoreBoxParms defines the parameters for a core box.
+type CoreBoxParms struct {
+	Shrinkage         // shrinkage allowance (the same as the pattern)
+	Wall      float64 // wall thickness around the core
+	Split     v3.Vec  // split plane normal (the plane goes through the core center)
+	Draft     float64 // draft angle for the core wrt the split plane (radians)
+}
+
+// CoreBox returns the two halves of a core box for a core (including its core prints).
+// The first half is on the same side of the split plane as the normal.
+func CoreBox(core sdf.SDF3, k *CoreBoxParms) ([]sdf.SDF3, error) {
+	if k.Wall <= 0 {
+		return nil, sdf.ErrMsg("wall <= 0")
+	}
+	if k.Split.Length() == 0 {
+		return nil, sdf.ErrMsg("split normal has zero length")
+	}
+	bb := core.BoundingBox()
+	c := bb.Center()
+	if k.Draft != 0 {
+		var err error
+		core, err = sdf.Draft3D(core, k.Split, c, k.Draft)
+		if err != nil {
+			return nil, err
+		}
+	}
+	box, err := sdf.Box3D(bb.Size().AddScalar(2*k.Wall), 0)
+	if err != nil {
+		return nil, err
+	}
+	box = sdf.Transform3D(box, sdf.Translate3d(c))
+	s, err := k.Shrinkage.apply(sdf.Difference3D(box, core))
+	if err != nil {
+		return nil, err
+	}
+	// the split plane is scaled with the core box
+	scale, _ := k.Shrinkage.Scale()
+	c = c.Mul(scale)
+	return []sdf.SDF3{
+		sdf.Cut3D(s, c, k.Split),
+		sdf.Cut3D(s, c, k.Split.Neg()),
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,87 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+const tolerance = 1e-9
+
+//-----------------------------------------------------------------------------
+
+func Test_Shrinkage(t *testing.T) {
+	tests := []struct {
+		k     Shrinkage
+		scale v3.Vec
+	}{
+		{Shrinkage{}, v3.Vec{1, 1, 1}},
+		{Shrinkage{Alloy: "aluminium"}, v3.Vec{1 / 0.987, 1 / 0.987, 1 / 0.987}},
+		{Shrinkage{Alloy: "Aluminum", Pattern: 0.002}, v3.Vec{1 / (0.998 * 0.987), 1 / (0.998 * 0.987), 1 / (0.998 * 0.987)}},
+		{Shrinkage{Alloy: "iron", Shrink: v3.Vec{0.01, 0.02, 0}}, v3.Vec{1 / 0.99, 1 / 0.98, 1}},
+	}
+	for _, test := range tests {
+		scale, err := test.k.Scale()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !scale.Equals(test.scale, tolerance) {
+			t.Errorf("%+v: expected %v, got %v", test.k, test.scale, scale)
+		}
+	}
+	for _, k := range []Shrinkage{
+		{Alloy: "unobtainium"},
+		{Shrink: v3.Vec{-0.01, 0, 0}},
+		{Pattern: 1},
+	} {
+		if _, err := k.Scale(); err == nil {
+			t.Errorf("%+v: expected an error", k)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MachiningStock(t *testing.T) {
+	box, err := sdf.Box3D(v3.Vec{20, 20, 20}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const stock = 2.0
+	s, err := machiningStock(box, &MachinedFace{Point: v3.Vec{0, 0, 10}, Normal: v3.Vec{0, 0, 3}, Stock: stock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the top face is moved out by the stock, the other faces are unchanged
+	tests := []struct {
+		p v3.Vec
+		d float64
+	}{
+		{v3.Vec{0, 0, 10 + stock}, 0},
+		{v3.Vec{3, -2, 13 + stock}, 3},
+		{v3.Vec{0, 0, 10 + 0.5*stock}, -0.5 * stock},
+		{v3.Vec{10, 0, 10 + 0.5*stock}, 0},
+		{v3.Vec{0, -10, 5}, 0},
+		{v3.Vec{0, 0, -10}, 0},
+		{v3.Vec{0, 0, -12}, 2},
+	}
+	for _, test := range tests {
+		if d := s.Evaluate(test.p); math.Abs(d-test.d) > tolerance {
+			t.Errorf("%v: expected %g, got %g", test.p, test.d, d)
+		}
+	}
+	bb := s.BoundingBox()
+	if !bb.Min.Equals(v3.Vec{-10, -10, -10}, tolerance) || !bb.Max.Equals(v3.Vec{10, 10, 10 + stock}, tolerance) {
+		t.Errorf("bad bounding box %v", bb)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,270 @@
+//-----------------------------------------------------------------------------
+/*
+
+PCB Mounting Kits
+
+Make a base plate with standoffs (and an optional enclosure) for a PCB
+described by its outline and mounting holes.
+
+The board can be described with a JSON file:
+
+	{
+		"Size": {"X": 100, "Y": 60},
+		"Thickness": 1.6,
+		"Holes": [
+			{"Position": {"X": 3.5, "Y": 3.5}, "Diameter": 3.2},
+			{"Position": {"X": 96.5, "Y": 56.5}, "Diameter": 3.2, "Stub": true}
+		]
+	}
+
+or read from a KiCad .kicad_pcb file (see kicad.go).
+
+The board is on the xy plane with its mounting holes at the board coordinates.
+The bottom of the base plate is at z = 0.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// PCBHole is a board mounting hole.
+type PCBHole struct {
+	Position v2.Vec  // hole center
+	Diameter float64 // hole diameter
+	Stub     bool    // use a support stub rather than a screw hole
+}
+
+// Board is a printed circuit board outline and mounting holes.
+type Board struct {
+	Outline   []v2.Vec  // board outline polygon
+	Size      v2.Vec    // size of a rectangular board at the origin (if there is no outline)
+	Thickness float64   // board thickness
+	Holes     []PCBHole // mounting holes
+}
+
+// LoadBoard loads a board description (KiCad for *.kicad_pcb, else JSON).
+func LoadBoard(path string) (*Board, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".kicad_pcb" {
+		board, err := ParseKiCad(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return board, nil
+	}
+	board := &Board{}
+	if err := json.Unmarshal(b, board); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return board, nil
+}
+
+// outline returns the board outline polygon.
+func (b *Board) outline() []v2.Vec {
+	if len(b.Outline) != 0 {
+		return b.Outline
+	}
+	return []v2.Vec{{}, {X: b.Size.X}, b.Size, {Y: b.Size.Y}}
+}
+
+// Outline2D returns the 2d board outline.
+func (b *Board) Outline2D() (sdf.SDF2, error) {
+	if len(b.Outline) == 0 && (b.Size.X <= 0 || b.Size.Y <= 0) {
+		return nil, sdf.ErrMsg("board has no outline or size")
+	}
+	return sdf.Polygon2D(b.outline())
+}
+
+// BoundingBox returns the bounding box of the board outline.
+func (b *Board) BoundingBox() sdf.Box2 {
+	p := v2.VecSet(b.outline())
+	return sdf.Box2{Min: p.Min(), Max: p.Max()}
+}
+
+//-----------------------------------------------------------------------------
+
+// PCBCutout is a connector cutout in a side of an enclosure.
+type PCBCutout struct {
+	Side     string  // board side: "left" (-x), "right" (+x), "front" (-y) or "back" (+y)
+	Position float64 // cutout center along the side (board x or y coordinate)
+	Height   float64 // cutout center above the top of the board
+	Size     v2.Vec  // cutout width and height
+	Radius   float64 // corner radius (E.g. half the size for a round hole)
+}
+
+// PCBMountParms defines the parameters for a PCB mounting kit.
+type PCBMountParms struct {
+	File          string        // board file (*.kicad_pcb or JSON), used instead of Board
+	Board         Board         // board description
+	Standoff      StandoffParms // standoffs (the pillar height sets the board height)
+	BaseThickness float64       // base plate thickness
+	BaseMargin    float64       // base plate margin around the board outline
+	Enclosure     bool          // make an enclosure that fits over the base plate
+	Wall          float64       // enclosure wall thickness
+	Height        float64       // enclosure inside height above the base plate
+	Clearance     float64       // clearance between the base plate and the enclosure
+	Cutouts       []PCBCutout   // enclosure connector cutouts
+}
+
+// board returns the board for a PCB mounting kit.
+func (k *PCBMountParms) board() (*Board, error) {
+	if k.File != "" {
+		return LoadBoard(k.File)
+	}
+	return &k.Board, nil
+}
+
+// pcbStandoffs returns the standoffs for the board mounting holes.
+func pcbStandoffs(k *PCBMountParms, board *Board) (sdf.SDF3, error) {
+	zOfs := k.BaseThickness + 0.5*k.Standoff.PillarHeight
+	var s []sdf.SDF3
+	var screws v3.VecSet
+	for _, h := range board.Holes {
+		p := v3.Vec{X: h.Position.X, Y: h.Position.Y, Z: zOfs}
+		if !h.Stub {
+			screws = append(screws, p)
+			continue
+		}
+		// a support stub fits through the board hole
+		sk := k.Standoff
+		sk.HoleDepth = -2.0 * board.Thickness
+		sk.HoleDiameter = h.Diameter - 0.4
+		stub, err := Standoff3D(&sk)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, sdf.Transform3D(stub, sdf.Translate3d(p)))
+	}
+	if len(screws) != 0 {
+		screw, err := Standoff3D(&k.Standoff)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, sdf.Multi3D(screw, screws))
+	}
+	return sdf.Union3D(s...), nil
+}
+
+// pcbCutout returns a connector cutout through the enclosure wall.
+func pcbCutout(k *PCBMountParms, c *PCBCutout, bb sdf.Box2, zBoard float64) (sdf.SDF3, error) {
+	if c.Size.X <= 0 || c.Size.Y <= 0 {
+		return nil, sdf.ErrMsg("cutout size <= 0")
+	}
+	// from the side of the board to outside the enclosure wall
+	depth := k.BaseMargin + k.Clearance + k.Wall + 1.0
+	s := sdf.Extrude3D(sdf.Box2D(c.Size, c.Radius), depth)
+	z := zBoard + c.Height
+	var m sdf.M44
+	switch c.Side {
+	case "front":
+		m = sdf.Translate3d(v3.Vec{X: c.Position, Y: bb.Min.Y - 0.5*depth, Z: z}).Mul(sdf.RotateX(sdf.DtoR(90)))
+	case "back":
+		m = sdf.Translate3d(v3.Vec{X: c.Position, Y: bb.Max.Y + 0.5*depth, Z: z}).Mul(sdf.RotateX(sdf.DtoR(90)))
+	case "left":
+		m = sdf.Translate3d(v3.Vec{X: bb.Min.X - 0.5*depth, Y: c.Position, Z: z}).Mul(sdf.RotateZ(sdf.DtoR(90))).Mul(sdf.RotateX(sdf.DtoR(90)))
+	case "right":
+		m = sdf.Translate3d(v3.Vec{X: bb.Max.X + 0.5*depth, Y: c.Position, Z: z}).Mul(sdf.RotateZ(sdf.DtoR(90))).Mul(sdf.RotateX(sdf.DtoR(90)))
+	default:
+		return nil, fmt.Errorf("unknown cutout side \"%s\"", c.Side)
+	}
+	return sdf.Transform3D(s, m), nil
+}
+
+// pcbEnclosure returns an enclosure that fits over the base plate.
+func pcbEnclosure(k *PCBMountParms, board *Board, plate sdf.SDF2) (sdf.SDF3, error) {
+	if k.Wall <= 0 {
+		return nil, sdf.ErrMsg("wall <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, sdf.ErrMsg("clearance < 0")
+	}
+	zBoard := k.BaseThickness + k.Standoff.PillarHeight + board.Thickness
+	if k.Height <= zBoard-k.BaseThickness {
+		return nil, sdf.ErrMsg("enclosure height is less than the board height")
+	}
+	inner := sdf.Offset2D(plate, k.Clearance)
+	outer := sdf.Offset2D(inner, k.Wall)
+	hInner := k.BaseThickness + k.Height
+	hOuter := hInner + k.Wall
+	s0 := sdf.Transform3D(sdf.Extrude3D(outer, hOuter), sdf.Translate3d(v3.Vec{Z: 0.5 * hOuter}))
+	s1 := sdf.Transform3D(sdf.Extrude3D(inner, hInner), sdf.Translate3d(v3.Vec{Z: 0.5 * hInner}))
+	s := sdf.Difference3D(s0, s1)
+	if len(k.Cutouts) == 0 {
+		return s, nil
+	}
+	bb := board.BoundingBox()
+	cutouts := make([]sdf.SDF3, len(k.Cutouts))
+	for i := range k.Cutouts {
+		c, err := pcbCutout(k, &k.Cutouts[i], bb, zBoard)
+		if err != nil {
+			return nil, err
+		}
+		cutouts[i] = c
+	}
+	return sdf.Difference3D(s, sdf.Union3D(cutouts...)), nil
+}
+
+// PCBMount3D returns a base plate with standoffs and an optional enclosure for a board.
+func PCBMount3D(k *PCBMountParms) ([]sdf.SDF3, error) {
+	if k.BaseThickness <= 0 {
+		return nil, sdf.ErrMsg("base thickness <= 0")
+	}
+	if k.BaseMargin < 0 {
+		return nil, sdf.ErrMsg("base margin < 0")
+	}
+	board, err := k.board()
+	if err != nil {
+		return nil, err
+	}
+	if len(board.Holes) == 0 {
+		return nil, sdf.ErrMsg("board has no mounting holes")
+	}
+	outline, err := board.Outline2D()
+	if err != nil {
+		return nil, err
+	}
+
+	// base plate
+	plate := outline
+	if k.BaseMargin > 0 {
+		plate = sdf.Offset2D(outline, k.BaseMargin)
+	}
+	s0 := sdf.Extrude3D(plate, k.BaseThickness)
+	s0 = sdf.Transform3D(s0, sdf.Translate3d(v3.Vec{Z: 0.5 * k.BaseThickness}))
+
+	// standoffs
+	s1, err := pcbStandoffs(k, board)
+	if err != nil {
+		return nil, err
+	}
+	base := sdf.Union3D(s0, s1)
+	base.(*sdf.UnionSDF3).SetMin(sdf.PolyMin(k.BaseThickness))
+
+	if !k.Enclosure {
+		return []sdf.SDF3{base}, nil
+	}
+	enclosure, err := pcbEnclosure(k, board, plate)
+	if err != nil {
+		return nil, err
+	}
+	return []sdf.SDF3{base, enclosure}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,263 @@
+//-----------------------------------------------------------------------------
+/*
+
+Planetary Gearbox
+
+A simple planetary gearbox with a fixed ring gear, sun gear input and
+planet carrier output. The reduction ratio is 1 + ring teeth / sun teeth.
+
+Assembly conditions:
+
+* ring teeth = sun teeth + 2 * planet teeth (the gears share a module)
+* (sun teeth + ring teeth) / number of planets is an integer (equal spacing)
+* adjacent planets don't collide (neighbour condition)
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// PlanetaryParms defines the parameters for a planetary gearbox.
+type PlanetaryParms struct {
+	Ratio            float64          // target reduction ratio
+	Planets          int              // number of planet gears
+	Module           float64          // gear module
+	PressureAngle    float64          // gear pressure angle (radians)
+	Backlash         float64          // backlash expressed as per-tooth distance at pitch circumference
+	Clearance        float64          // additional root clearance
+	Facets           int              // number of facets for involute flank
+	MinTeeth         int              // minimum teeth on the sun and planets (0 = no undercut)
+	MaxRingTeeth     int              // maximum teeth on the ring gear
+	Height           float64          // face width of the gears
+	RingWidth        float64          // width of the ring gear wall
+	SunShaft         KeywayParameters // sun bore and key slot (ShaftRadius == 0 for none, KeyRadius > ShaftRadius)
+	CarrierShaft     KeywayParameters // carrier bore and key slot (ShaftRadius == 0 for none, KeyRadius > ShaftRadius)
+	PinRadius        float64          // radius of the carrier pins
+	PinClearance     float64          // radial clearance between carrier pin and planet bore
+	CarrierThickness float64          // thickness of the carrier plate
+	CarrierGap       float64          // axial gap between the carrier plate and the gears
+}
+
+// PlanetaryTeeth stores the tooth counts for a planetary gearbox.
+type PlanetaryTeeth struct {
+	Sun, Planet, Ring int
+}
+
+// Ratio returns the reduction ratio (sun input, carrier output, ring fixed).
+func (t *PlanetaryTeeth) Ratio() float64 {
+	return 1.0 + float64(t.Ring)/float64(t.Sun)
+}
+
+// PlanetaryParts stores the parts of a planetary gearbox.
+// The parts are centered on the z-axis, with the gears centered on the xy plane.
+type PlanetaryParts struct {
+	Teeth   PlanetaryTeeth
+	Sun     sdf.SDF3
+	Planet  sdf.SDF3  // a single planet gear at the origin
+	Ring    sdf.SDF3  // ring gear
+	Carrier sdf.SDF3  // carrier plate and pins (below the gears)
+	Planets []sdf.M44 // transforms to position each planet
+}
+
+// Assembly returns all of the parts in their assembled positions.
+func (p *PlanetaryParts) Assembly() sdf.SDF3 {
+	s := []sdf.SDF3{p.Sun, p.Ring, p.Carrier}
+	for _, m := range p.Planets {
+		s = append(s, sdf.Transform3D(p.Planet, m))
+	}
+	return sdf.Union3D(s...)
+}
+
+//-----------------------------------------------------------------------------
+
+// PlanetaryTeethSearch returns the valid tooth counts closest to the target ratio.
+func PlanetaryTeethSearch(k *PlanetaryParms) (*PlanetaryTeeth, error) {
+	if k.Ratio <= 2 {
+		return nil, sdf.ErrMsg("Ratio <= 2")
+	}
+	if k.Planets < 2 {
+		return nil, sdf.ErrMsg("Planets < 2")
+	}
+	if k.MaxRingTeeth <= 0 {
+		return nil, sdf.ErrMsg("MaxRingTeeth <= 0")
+	}
+	minTeeth := k.MinTeeth
+	if minTeeth <= 0 {
+		g := GearPair{PressureAngle: k.PressureAngle}
+		minTeeth = g.MinTeeth()
+	}
+	var best *PlanetaryTeeth
+	bestError := math.Inf(1)
+	s := math.Sin(sdf.Pi / float64(k.Planets))
+	for zs := minTeeth; zs+2*minTeeth <= k.MaxRingTeeth; zs++ {
+		for zp := minTeeth; zs+2*zp <= k.MaxRingTeeth; zp++ {
+			zr := zs + 2*zp
+			// equal spacing
+			if (zs+zr)%k.Planets != 0 {
+				continue
+			}
+			// neighbour condition: planet tips must clear each other
+			if float64(zs+zp)*s <= float64(zp+2) {
+				continue
+			}
+			t := PlanetaryTeeth{zs, zp, zr}
+			e := math.Abs(t.Ratio() - k.Ratio)
+			if e < bestError-1e-9 {
+				best = &t
+				bestError = e
+			}
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no tooth counts for %d planets with ring teeth <= %d", k.Planets, k.MaxRingTeeth)
+	}
+	return best, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// hubBore returns a bore with a key slot for a hub, or nil.
+// The key slot is cut into the hub, so KeyRadius must be > ShaftRadius.
+// Use KeyWidth == 0 for a plain bore.
+func hubBore(k KeywayParameters, height float64) (sdf.SDF3, error) {
+	if k.ShaftRadius == 0 {
+		return nil, nil
+	}
+	if k.KeyWidth != 0 && k.KeyRadius <= k.ShaftRadius {
+		return nil, sdf.ErrMsg("KeyRadius <= ShaftRadius, no key slot in the hub")
+	}
+	k.ShaftLength = height
+	return Keyway3D(&k)
+}
+
+// Planetary returns the parts for a planetary gearbox.
+func Planetary(k *PlanetaryParms) (*PlanetaryParts, error) {
+	if k.Height <= 0 {
+		return nil, sdf.ErrMsg("Height <= 0")
+	}
+	if k.RingWidth <= 0 {
+		return nil, sdf.ErrMsg("RingWidth <= 0")
+	}
+	if k.PinRadius <= 0 {
+		return nil, sdf.ErrMsg("PinRadius <= 0")
+	}
+	if k.PinClearance < 0 {
+		return nil, sdf.ErrMsg("PinClearance < 0")
+	}
+	if k.CarrierThickness <= 0 {
+		return nil, sdf.ErrMsg("CarrierThickness <= 0")
+	}
+	if k.CarrierGap < 0 {
+		return nil, sdf.ErrMsg("CarrierGap < 0")
+	}
+
+	t, err := PlanetaryTeethSearch(k)
+	if err != nil {
+		return nil, err
+	}
+
+	gear := InvoluteGearParms{
+		Module:        k.Module,
+		PressureAngle: k.PressureAngle,
+		Backlash:      k.Backlash,
+		Clearance:     k.Clearance,
+		Facets:        k.Facets,
+	}
+	pitchRadius := func(n int) float64 { return float64(n) * k.Module * 0.5 }
+	orbitRadius := pitchRadius(t.Sun) + pitchRadius(t.Planet)
+	if k.PinRadius+k.PinClearance >= pitchRadius(t.Planet)-1.25*k.Module-k.Clearance {
+		return nil, sdf.ErrMsg("PinRadius is too large for the planet gears")
+	}
+
+	// sun gear
+	gear.NumberTeeth = t.Sun
+	sun2d, err := InvoluteGear(&gear)
+	if err != nil {
+		return nil, err
+	}
+	sun := sdf.Extrude3D(sun2d, k.Height)
+	bore, err := hubBore(k.SunShaft, k.Height)
+	if err != nil {
+		return nil, err
+	}
+	sun = sdf.Difference3D(sun, bore)
+
+	// planet gear
+	gear.NumberTeeth = t.Planet
+	planet2d, err := InvoluteGear(&gear)
+	if err != nil {
+		return nil, err
+	}
+	planet := sdf.Extrude3D(planet2d, k.Height)
+	pin, err := sdf.Cylinder3D(k.Height, k.PinRadius+k.PinClearance, 0)
+	if err != nil {
+		return nil, err
+	}
+	planet = sdf.Difference3D(planet, pin)
+
+	// ring gear
+	gear.NumberTeeth = t.Ring
+	gear.RingWidth = k.RingWidth
+	ring2d, err := InternalGear(&gear)
+	if err != nil {
+		return nil, err
+	}
+	ring := sdf.Extrude3D(ring2d, k.Height)
+
+	// Work out the planet positions and phases.
+	// The sun has a tooth on the +x axis, each planet is meshed with it.
+	// The assembly conditions guarantee the ring meshes with all planets.
+	sunPlanet := GearPair{Module: k.Module, PressureAngle: k.PressureAngle, Teeth0: t.Sun, Teeth1: t.Planet}
+	planets := make([]sdf.M44, k.Planets)
+	for i := range planets {
+		phi := sdf.Tau * float64(i) / float64(k.Planets)
+		_, m := sunPlanet.Transform3d(-phi)
+		planets[i] = sdf.RotateZ(phi).Mul(m)
+	}
+	planetRing := GearPair{Module: k.Module, PressureAngle: k.PressureAngle, Teeth0: t.Planet, Teeth1: t.Ring, Internal: true}
+	_, mRing := planetRing.Transform3d(sunPlanet.phase(0))
+	ring = sdf.Transform3D(ring, mRing)
+
+	// carrier plate and pins
+	carrierRadius := orbitRadius + 2.0*k.PinRadius
+	plate, err := sdf.Cylinder3D(k.CarrierThickness, carrierRadius, 0)
+	if err != nil {
+		return nil, err
+	}
+	bore, err = hubBore(k.CarrierShaft, k.CarrierThickness)
+	if err != nil {
+		return nil, err
+	}
+	plate = sdf.Difference3D(plate, bore)
+	zPlate := -0.5*k.Height - k.CarrierGap - 0.5*k.CarrierThickness
+	plate = sdf.Transform3D(plate, sdf.Translate3d(v3.Vec{0, 0, zPlate}))
+	pinLength := k.Height + k.CarrierGap + 0.5*k.CarrierThickness
+	pin, err = sdf.Cylinder3D(pinLength, k.PinRadius, 0)
+	if err != nil {
+		return nil, err
+	}
+	pin = sdf.Transform3D(pin, sdf.Translate3d(v3.Vec{orbitRadius, 0, 0.5*k.Height - 0.5*pinLength}))
+	pins := sdf.RotateCopy3D(pin, k.Planets)
+	carrier := sdf.Union3D(plate, pins)
+
+	return &PlanetaryParts{
+		Teeth:   *t,
+		Sun:     sun,
+		Planet:  planet,
+		Ring:    ring,
+		Carrier: carrier,
+		Planets: planets,
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
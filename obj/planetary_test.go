@@ -0,0 +1,156 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// validTeeth returns true if the tooth counts meet the assembly conditions.
+func validTeeth(t *PlanetaryTeeth, k *PlanetaryParms, minTeeth int) bool {
+	if t.Sun < minTeeth || t.Planet < minTeeth || t.Ring > k.MaxRingTeeth {
+		return false
+	}
+	if t.Ring != t.Sun+2*t.Planet {
+		return false
+	}
+	// equal spacing
+	if (t.Sun+t.Ring)%k.Planets != 0 {
+		return false
+	}
+	// neighbour condition: the distance between planet centers must exceed the tip diameter
+	orbit := 0.5 * float64(t.Sun+t.Planet)
+	return 2*orbit*math.Sin(sdf.Pi/float64(k.Planets)) > float64(t.Planet+2)
+}
+
+func Test_PlanetaryTeethSearch(t *testing.T) {
+	pa := sdf.DtoR(20)
+	for _, k := range []*PlanetaryParms{
+		{Ratio: 4, Planets: 3, PressureAngle: pa, MaxRingTeeth: 100},
+		{Ratio: 5.5, Planets: 3, PressureAngle: pa, MaxRingTeeth: 120},
+		{Ratio: 3.2, Planets: 4, PressureAngle: pa, MaxRingTeeth: 90},
+		{Ratio: 7, Planets: 5, MinTeeth: 12, MaxRingTeeth: 150},
+		{Ratio: 12, Planets: 2, MinTeeth: 10, MaxRingTeeth: 80},
+	} {
+		teeth, err := PlanetaryTeethSearch(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		minTeeth := k.MinTeeth
+		if minTeeth == 0 {
+			minTeeth = 18
+		}
+		if !validTeeth(teeth, k, minTeeth) {
+			t.Errorf("%+v: invalid tooth counts %+v", k, teeth)
+		}
+		// no valid combination is closer to the target ratio
+		e := math.Abs(teeth.Ratio() - k.Ratio)
+		for zs := minTeeth; zs <= k.MaxRingTeeth; zs++ {
+			for zp := minTeeth; zs+2*zp <= k.MaxRingTeeth; zp++ {
+				x := &PlanetaryTeeth{zs, zp, zs + 2*zp}
+				if validTeeth(x, k, minTeeth) && math.Abs(x.Ratio()-k.Ratio) < e-1e-9 {
+					t.Errorf("%+v: %+v (%g) is closer than %+v (%g)", k, x, x.Ratio(), teeth, teeth.Ratio())
+				}
+			}
+		}
+	}
+
+	// an exact ratio: 18/18/54 gives 4:1 with 3 planets
+	teeth, err := PlanetaryTeethSearch(&PlanetaryParms{Ratio: 4, Planets: 3, PressureAngle: pa, MaxRingTeeth: 54})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *teeth != (PlanetaryTeeth{18, 18, 54}) {
+		t.Errorf("expected 18/18/54, got %+v", teeth)
+	}
+
+	// bad parameters, too few ring teeth, too many planets for the neighbour condition
+	for _, k := range []*PlanetaryParms{
+		{Ratio: 1.5, Planets: 3, PressureAngle: pa, MaxRingTeeth: 100},
+		{Ratio: 4, Planets: 1, PressureAngle: pa, MaxRingTeeth: 100},
+		{Ratio: 4, Planets: 3, PressureAngle: pa},
+		{Ratio: 4, Planets: 3, PressureAngle: pa, MaxRingTeeth: 50},
+		{Ratio: 3, Planets: 10, PressureAngle: pa, MaxRingTeeth: 60},
+	} {
+		if _, err := PlanetaryTeethSearch(k); err == nil {
+			t.Errorf("%+v: expected an error", k)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Planetary(t *testing.T) {
+	k := &PlanetaryParms{
+		Ratio:            4,
+		Planets:          3,
+		Module:           1,
+		PressureAngle:    sdf.DtoR(20),
+		Backlash:         0.1,
+		Clearance:        0.1,
+		Facets:           7,
+		MaxRingTeeth:     60,
+		Height:           5,
+		RingWidth:        3,
+		SunShaft:         KeywayParameters{ShaftRadius: 2.5, KeyRadius: 3, KeyWidth: 1},
+		CarrierShaft:     KeywayParameters{ShaftRadius: 2.5},
+		PinRadius:        2,
+		PinClearance:     0.2,
+		CarrierThickness: 3,
+		CarrierGap:       0.5,
+	}
+	parts, err := Planetary(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts.Planets) != k.Planets {
+		t.Fatalf("expected %d planets, got %d", k.Planets, len(parts.Planets))
+	}
+	// the planets are equally spaced on the orbit radius
+	orbit := 0.5 * k.Module * float64(parts.Teeth.Sun+parts.Teeth.Planet)
+	for i, m := range parts.Planets {
+		c := m.MulPosition(v3.Vec{})
+		phi := sdf.Tau * float64(i) / float64(k.Planets)
+		if !c.Equals(v3.Vec{orbit * math.Cos(phi), orbit * math.Sin(phi), 0}, 1e-6) {
+			t.Errorf("planet %d: bad position %v", i, c)
+		}
+	}
+	// the gears mesh without overlapping in the gear plane
+	planets := make([]sdf.SDF3, len(parts.Planets))
+	for i, m := range parts.Planets {
+		planets[i] = sdf.Transform3D(parts.Planet, m)
+	}
+	gears := append([]sdf.SDF3{parts.Sun, parts.Ring}, planets...)
+	r := orbit + 0.5*k.Module*float64(parts.Teeth.Planet) + k.Module
+	const n = 200
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= n; j++ {
+			p := v3.Vec{r * (2*float64(i)/n - 1), r * (2*float64(j)/n - 1), 0}
+			inside := 0
+			for _, g := range gears {
+				if g.Evaluate(p) < -0.01 {
+					inside++
+				}
+			}
+			if inside > 1 {
+				t.Fatalf("gears overlap at %v", p)
+			}
+		}
+	}
+
+	// the hub key slot must be outside the bore
+	k.SunShaft.KeyRadius = 2
+	if _, err := Planetary(k); err == nil {
+		t.Error("expected an error for KeyRadius <= ShaftRadius")
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,609 @@
+//-----------------------------------------------------------------------------
+/*
+
+Object Generator Registry
+
+The object generators in this package are registered by name along with a
+default parameter set. This allows tools to list the generators, build an
+object from a parameter set (e.g. decoded from JSON) and serialize the object
+as the generator name and parameters (see sdf/serialize.go).
+
+	k := obj.Defaults("Bolt").(*obj.BoltParms)
+	k.TotalLength = 40
+	s, err := obj.Generate("Bolt", k)
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	"github.com/deadsy/sdfx/vec/v2i"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+	"github.com/deadsy/sdfx/vec/v3i"
+)
+
+//-----------------------------------------------------------------------------
+
+// Generator is a named object generator.
+type Generator struct {
+	Name  string                   // generator name
+	Doc   string                   // short description
+	Parms func() any               // returns a pointer to a default parameter set
+	Build func(k any) (any, error) // builds an SDF2/SDF3 (or []SDF3) from a parameter set
+	Parts []string                 // part names (multi-part generators only)
+}
+
+var generators = struct {
+	sync.RWMutex
+	m map[string]*Generator
+}{
+	m: map[string]*Generator{},
+}
+
+// Register registers an object generator.
+func Register(g *Generator) {
+	generators.Lock()
+	generators.m[g.Name] = g
+	generators.Unlock()
+	// allow generated objects to be decoded
+	sdf.RegisterGenerator(g.Name, func(b []byte) (any, error) {
+		k := g.Parms()
+		if err := json.Unmarshal(b, k); err != nil {
+			return nil, err
+		}
+		return Generate(g.Name, k)
+	})
+}
+
+// Lookup returns a named object generator.
+func Lookup(name string) (*Generator, error) {
+	generators.RLock()
+	defer generators.RUnlock()
+	g, ok := generators.m[name]
+	if !ok {
+		return nil, fmt.Errorf("generator \"%s\" not found", name)
+	}
+	return g, nil
+}
+
+// Generators returns all of the object generators sorted by name.
+func Generators() []*Generator {
+	generators.RLock()
+	defer generators.RUnlock()
+	list := make([]*Generator, 0, len(generators.m))
+	for _, g := range generators.m {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Defaults returns the default parameter set for a named generator (nil if not found).
+func Defaults(name string) any {
+	g, err := Lookup(name)
+	if err != nil {
+		return nil
+	}
+	return g.Parms()
+}
+
+// Generate builds an object with a named generator.
+// The object is tagged with the generator name and parameters.
+// Multi-part generators return []sdf.SDF3.
+func Generate(name string, k any) (any, error) {
+	g, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	s, err := g.Build(k)
+	if err != nil {
+		return nil, err
+	}
+	switch x := s.(type) {
+	case sdf.SDF3:
+		return sdf.Generated3D(x, name, k), nil
+	case sdf.SDF2:
+		return sdf.Generated2D(x, name, k), nil
+	case []sdf.SDF3:
+		parts := make([]sdf.SDF3, len(x))
+		for i := range x {
+			parts[i] = sdf.GeneratedPart3D(x[i], name, k, i)
+		}
+		return parts, nil
+	}
+	return nil, fmt.Errorf("%s: generator returned %T", name, s)
+}
+
+//-----------------------------------------------------------------------------
+
+// build2 adapts a typed SDF2 generator function.
+func build2[T any](fn func(*T) (sdf.SDF2, error)) func(any) (any, error) {
+	return func(k any) (any, error) {
+		p, ok := k.(*T)
+		if !ok {
+			return nil, fmt.Errorf("parameters are %T, not %T", k, p)
+		}
+		s, err := fn(p)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+}
+
+// build3 adapts a typed SDF3 generator function.
+func build3[T any](fn func(*T) (sdf.SDF3, error)) func(any) (any, error) {
+	return func(k any) (any, error) {
+		p, ok := k.(*T)
+		if !ok {
+			return nil, fmt.Errorf("parameters are %T, not %T", k, p)
+		}
+		s, err := fn(p)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+}
+
+// buildN adapts a typed multi-part SDF3 generator function.
+func buildN[T any](fn func(*T) ([]sdf.SDF3, error)) func(any) (any, error) {
+	return func(k any) (any, error) {
+		p, ok := k.(*T)
+		if !ok {
+			return nil, fmt.Errorf("parameters are %T, not %T", k, p)
+		}
+		s, err := fn(p)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+}
+
+// defaultGear returns the default 2d gear parameters.
+func defaultGear() InvoluteGearParms {
+	return InvoluteGearParms{
+		NumberTeeth:   20,
+		Module:        1,
+		PressureAngle: sdf.DtoR(20),
+		Facets:        7,
+	}
+}
+
+func init() {
+	Register(&Generator{
+		Name:  "Angle2D",
+		Doc:   "angle profile",
+		Parms: func() any { return &AngleParms{X: AngleLeg{25, 3}, Y: AngleLeg{25, 3}, RootRadius: 2, Length: 100} },
+		Build: build2(Angle2D),
+	})
+	Register(&Generator{
+		Name:  "Angle3D",
+		Doc:   "angle extrusion",
+		Parms: func() any { return &AngleParms{X: AngleLeg{25, 3}, Y: AngleLeg{25, 3}, RootRadius: 2, Length: 100} },
+		Build: build3(Angle3D),
+	})
+	Register(&Generator{
+		Name: "Arrow3D",
+		Doc:  "arrow along the z-axis",
+		Parms: func() any {
+			return &ArrowParms{Axis: [2]float64{50, 1}, Head: [2]float64{5, 2}, Tail: [2]float64{2, 2}, Style: "cb"}
+		},
+		Build: build3(Arrow3D),
+	})
+	Register(&Generator{
+		Name:  "Bolt",
+		Doc:   "threaded bolt",
+		Parms: func() any { return &BoltParms{Thread: "M8x1.25", Style: "hex", TotalLength: 30, ShankLength: 10} },
+		Build: build3(Bolt),
+	})
+	Register(&Generator{
+		Name:  "Nut",
+		Doc:   "threaded nut",
+		Parms: func() any { return &NutParms{Thread: "M8x1.25", Style: "hex"} },
+		Build: build3(Nut),
+	})
+	Register(&Generator{
+		Name: "PanelBox3D",
+		Doc:  "panel box (panel, top and bottom parts)",
+		Parms: func() any {
+			return &PanelBoxParms{
+				Size:       v3.Vec{50, 40, 60},
+				Wall:       2.5,
+				Panel:      3,
+				Rounding:   5,
+				FrontInset: 2,
+				BackInset:  2,
+				Clearance:  0.05,
+				Hole:       3.4,
+				SideTabs:   "TbtbT",
+			}
+		},
+		Build: func(k any) (any, error) {
+			p, ok := k.(*PanelBoxParms)
+			if !ok {
+				return nil, fmt.Errorf("parameters are %T, not %T", k, p)
+			}
+			return PanelBox3D(p)
+		},
+		Parts: []string{"panel", "top", "bottom"},
+	})
+	Register(&Generator{
+		Name:  "Washer2D",
+		Doc:   "2d washer",
+		Parms: func() any { return &WasherParms{InnerRadius: 4.2, OuterRadius: 8} },
+		Build: build2(Washer2D),
+	})
+	Register(&Generator{
+		Name:  "Washer3D",
+		Doc:   "washer",
+		Parms: func() any { return &WasherParms{Thickness: 1.6, InnerRadius: 4.2, OuterRadius: 8} },
+		Build: build3(Washer3D),
+	})
+	Register(&Generator{
+		Name: "Standoff3D",
+		Doc:  "pcb standoff",
+		Parms: func() any {
+			return &StandoffParms{
+				PillarHeight:   15,
+				PillarDiameter: 6,
+				HoleDepth:      10,
+				HoleDiameter:   2.4,
+				NumberWebs:     4,
+				WebHeight:      8,
+				WebDiameter:    14,
+				WebWidth:       2,
+			}
+		},
+		Build: build3(Standoff3D),
+	})
+	Register(&Generator{
+		Name: "PCBMount3D",
+		Doc:  "pcb mounting kit (base plate with standoffs and an optional enclosure)",
+		Parms: func() any {
+			return &PCBMountParms{
+				Board: Board{
+					Size:      v2.Vec{100, 60},
+					Thickness: 1.6,
+					Holes: []PCBHole{
+						{Position: v2.Vec{3.5, 3.5}, Diameter: 3.2},
+						{Position: v2.Vec{96.5, 3.5}, Diameter: 3.2},
+						{Position: v2.Vec{3.5, 56.5}, Diameter: 3.2},
+						{Position: v2.Vec{96.5, 56.5}, Diameter: 3.2},
+					},
+				},
+				Standoff: StandoffParms{
+					PillarHeight:   10,
+					PillarDiameter: 6,
+					HoleDepth:      8,
+					HoleDiameter:   2.4,
+				},
+				BaseThickness: 3,
+				BaseMargin:    4,
+				Enclosure:     true,
+				Wall:          2,
+				Height:        30,
+				Clearance:     0.3,
+				Cutouts: []PCBCutout{
+					{Side: "front", Position: 20, Height: 1.6, Size: v2.Vec{9.5, 3.8}, Radius: 1.5},
+				},
+			}
+		},
+		Build: buildN(PCBMount3D),
+		Parts: []string{"base", "enclosure"},
+	})
+	Register(&Generator{
+		Name: "Enclosure3D",
+		Doc:  "enclosure with a lip, snap-fit, sliding or hinged lid (base and lid parts)",
+		Parms: func() any {
+			return &EnclosureParms{
+				Size:      v3.Vec{80, 60, 40},
+				Wall:      2.5,
+				Rounding:  5,
+				Closure:   "snap",
+				Lid:       12,
+				Clearance: 0.2,
+				Snap:      CantileverTab{Length: 12, Thickness: 1.5, Width: 6, Overhang: 1, MaxStrain: 0.02, Clearance: 0.2},
+				Snaps:     2,
+				Hinge:     LivingHingeParms{Thickness: 0.5, Length: 8, MaxStrain: 0.25},
+				Ports: []Port{
+					{Face: "front", Type: "usb-c", Position: v2.Vec{-20, -5}},
+					{Face: "front", Type: "barrel", Position: v2.Vec{0, -5}},
+					{Face: "back", Type: "db9", Position: v2.Vec{0, -5}},
+				},
+			}
+		},
+		Build: buildN(Enclosure3D),
+		Parts: []string{"base", "lid"},
+	})
+	Register(&Generator{
+		Name:  "InvoluteGear",
+		Doc:   "2d involute gear",
+		Parms: func() any { k := defaultGear(); return &k },
+		Build: build2(InvoluteGear),
+	})
+	Register(&Generator{
+		Name:  "InternalGear",
+		Doc:   "2d internal (ring) gear",
+		Parms: func() any { k := defaultGear(); k.NumberTeeth = 60; k.RingWidth = 3; return &k },
+		Build: build2(InternalGear),
+	})
+	Register(&Generator{
+		Name:  "HelicalGear3D",
+		Doc:   "helical gear",
+		Parms: func() any { return &HelicalGearParms{Gear: defaultGear(), Height: 10, HelixAngle: sdf.DtoR(15)} },
+		Build: build3(HelicalGear3D),
+	})
+	Register(&Generator{
+		Name:  "BevelGear3D",
+		Doc:   "bevel gear",
+		Parms: func() any { return &BevelGearParms{Gear: defaultGear(), MatingTeeth: 20, FaceWidth: 4} },
+		Build: build3(BevelGear3D),
+	})
+	Register(&Generator{
+		Name: "Worm3D",
+		Doc:  "worm for a worm gear",
+		Parms: func() any {
+			return &WormParms{Module: 1, Starts: 1, PitchRadius: 5, PressureAngle: sdf.DtoR(20), Length: 20}
+		},
+		Build: build3(Worm3D),
+	})
+	Register(&Generator{
+		Name:  "Knurl3D",
+		Doc:   "knurled cylinder",
+		Parms: func() any { return &KnurlParms{Length: 20, Radius: 10, Pitch: 2, Height: 0.8, Theta: sdf.DtoR(45)} },
+		Build: build3(Knurl3D),
+	})
+	Register(&Generator{
+		Name:  "Keyway2D",
+		Doc:   "2d shaft with a keyway",
+		Parms: func() any { return &KeywayParameters{ShaftRadius: 5, KeyRadius: 4, KeyWidth: 2} },
+		Build: build2(Keyway2D),
+	})
+	Register(&Generator{
+		Name:  "Keyway3D",
+		Doc:   "shaft with a keyway",
+		Parms: func() any { return &KeywayParameters{ShaftRadius: 5, KeyRadius: 4, KeyWidth: 2, ShaftLength: 20} },
+		Build: build3(Keyway3D),
+	})
+	panel := func() any {
+		return &PanelParms{
+			Size:         v2.Vec{100, 60},
+			CornerRadius: 5,
+			HoleDiameter: 3.5,
+			HoleMargin:   [4]float64{5, 5, 5, 5},
+			HolePattern:  [4]string{"x", "x", "x", "x"},
+			Thickness:    3,
+		}
+	}
+	Register(&Generator{
+		Name:  "Panel2D",
+		Doc:   "2d panel with mounting holes",
+		Parms: panel,
+		Build: build2(Panel2D),
+	})
+	Register(&Generator{
+		Name:  "Panel3D",
+		Doc:   "panel with mounting holes",
+		Parms: panel,
+		Build: build3(Panel3D),
+	})
+	Register(&Generator{
+		Name:  "EuroRackPanel2D",
+		Doc:   "2d eurorack module panel",
+		Parms: func() any { return &EuroRackParms{U: 3, HP: 8, CornerRadius: 3} },
+		Build: build2(EuroRackPanel2D),
+	})
+	Register(&Generator{
+		Name:  "EuroRackPanel3D",
+		Doc:   "eurorack module panel",
+		Parms: func() any { return &EuroRackParms{U: 3, HP: 8, CornerRadius: 3, Thickness: 2} },
+		Build: build3(EuroRackPanel3D),
+	})
+	Register(&Generator{
+		Name:  "PanelHole3D",
+		Doc:   "panel hole with an anti-rotation indent",
+		Parms: func() any { return &PanelHoleParms{Diameter: 7, Thickness: 2, Indent: v3.Vec{2, 2, 1}, Offset: 5} },
+		Build: build3(PanelHole3D),
+	})
+	Register(&Generator{
+		Name:  "FingerButton2D",
+		Doc:   "2d cutout for a flexible finger button",
+		Parms: func() any { return &FingerButtonParms{Width: 4, Gap: 0.6, Length: 20} },
+		Build: build2(FingerButton2D),
+	})
+	Register(&Generator{
+		Name: "TruncRectPyramid3D",
+		Doc:  "truncated rectangular pyramid",
+		Parms: func() any {
+			return &TruncRectPyramidParms{Size: v3.Vec{40, 30, 20}, BaseAngle: sdf.DtoR(80), BaseRadius: 5, RoundRadius: 2}
+		},
+		Build: build3(TruncRectPyramid3D),
+	})
+	Register(&Generator{
+		Name:  "GfBase",
+		Doc:   "gridfinity base",
+		Parms: func() any { return &GfBaseParms{Size: v2i.Vec{2, 2}} },
+		Build: build3(func(k *GfBaseParms) (sdf.SDF3, error) { return GfBase(k), nil }),
+	})
+	Register(&Generator{
+		Name:  "GfBody",
+		Doc:   "gridfinity container body",
+		Parms: func() any { return &GfBodyParms{Size: v3i.Vec{2, 2, 3}} },
+		Build: build3(func(k *GfBodyParms) (sdf.SDF3, error) { return GfBody(k), nil }),
+	})
+	Register(&Generator{
+		Name: "DrainCover",
+		Doc:  "drain cover",
+		Parms: func() any {
+			return &DrainCoverParms{
+				WallDiameter:   48,
+				WallHeight:     12,
+				WallThickness:  3,
+				OuterWidth:     5,
+				InnerWidth:     4.5,
+				CoverThickness: 3,
+				GrateNumber:    8,
+				GrateWidth:     1.1,
+			}
+		},
+		Build: build3(DrainCover),
+	})
+	Register(&Generator{
+		Name: "CantileverSnap3D",
+		Doc:  "cantilever snap-fit test piece (lower and upper parts)",
+		Parms: func() any {
+			return &CantileverTab{Length: 12, Thickness: 1.5, Width: 6, Overhang: 1, MaxStrain: 0.02, Clearance: 0.2}
+		},
+		Build: buildN(CantileverSnap3D),
+		Parts: []string{"lower", "upper"},
+	})
+	Register(&Generator{
+		Name: "AnnularSnap3D",
+		Doc:  "annular snap-fit test piece (lower and upper parts)",
+		Parms: func() any {
+			return &AnnularTab{Diameter: 12, Length: 8, Undercut: 0.1, MaxStrain: 0.02, Clearance: 0.2}
+		},
+		Build: buildN(AnnularSnap3D),
+		Parts: []string{"lower", "upper"},
+	})
+	Register(&Generator{
+		Name: "Dovetail3D",
+		Doc:  "dovetail test piece (lower and upper parts)",
+		Parms: func() any {
+			return &DovetailTab{Width: 10, Height: 5, Length: 30, Angle: sdf.DtoR(15), MaxStrain: 0.02, Clearance: 0.2}
+		},
+		Build: buildN(Dovetail3D),
+		Parts: []string{"lower", "upper"},
+	})
+	Register(&Generator{
+		Name: "TorsionSnap3D",
+		Doc:  "torsion snap latch",
+		Parms: func() any {
+			return &TorsionSnapParms{
+				BarDiameter:    2,
+				BarLength:      8,
+				LeverLength:    20,
+				TailLength:     10,
+				LeverWidth:     8,
+				LeverThickness: 3,
+				Overhang:       1.5,
+				MaxStrain:      0.02,
+				Clearance:      0.5,
+			}
+		},
+		Build: build3(TorsionSnap3D),
+	})
+	Register(&Generator{
+		Name: "SnapPin3D",
+		Doc:  "split snap pin with barbs",
+		Parms: func() any {
+			return &SnapPinParms{
+				Hole:         6,
+				Grip:         8,
+				HeadDiameter: 10,
+				HeadHeight:   2,
+				Slot:         1.2,
+				SlotLength:   10,
+				Overhang:     0.5,
+				MaxStrain:    0.02,
+				Clearance:    0.1,
+			}
+		},
+		Build: build3(SnapPin3D),
+	})
+	Register(&Generator{
+		Name: "PinHinge3D",
+		Doc:  "print-in-place knuckle hinge (two leaves)",
+		Parms: func() any {
+			return &PinHingeParms{
+				Length:        40,
+				Knuckles:      5,
+				Diameter:      6,
+				Pin:           3,
+				LeafWidth:     20,
+				LeafThickness: 3,
+				Clearance:     0.4,
+			}
+		},
+		Build: buildN(PinHinge3D),
+		Parts: []string{"leaf0", "leaf1"},
+	})
+	Register(&Generator{
+		Name: "FlexurePivot3D",
+		Doc:  "cross-leaf flexure pivot",
+		Parms: func() any {
+			return &FlexurePivotParms{
+				Thickness: 0.8,
+				Length:    20,
+				Width:     10,
+				Block:     v2.Vec{20, 10},
+				Angle:     sdf.DtoR(15),
+				MaxStrain: 0.02,
+			}
+		},
+		Build: build3(FlexurePivot3D),
+	})
+	Register(&Generator{
+		Name:  "Hardware3D",
+		Doc:   "standard hardware from the catalog (model and cavity parts)",
+		Parms: func() any { return &HardwareParms{Name: "M3_socket"} },
+		Build: buildN(Hardware3D),
+		Parts: []string{"model", "cavity"},
+	})
+	Register(&Generator{
+		Name:  "Extrusion2D",
+		Doc:   "aluminium t-slot/v-slot extrusion profile",
+		Parms: func() any { return &ExtrusionParms{Series: 20, Style: "t-slot", X: 1, Y: 2} },
+		Build: build2(Extrusion2D),
+	})
+	Register(&Generator{
+		Name:  "Channel2D",
+		Doc:   "channel profile",
+		Parms: func() any { return &BeamParms{Width: 45, Height: 80, Web: 6, Flange: 8, RootRadius: 8} },
+		Build: build2(Channel2D),
+	})
+	Register(&Generator{
+		Name:  "IBeam2D",
+		Doc:   "I-beam profile",
+		Parms: func() any { return &BeamParms{Width: 55, Height: 100, Web: 4.1, Flange: 5.7, RootRadius: 7} },
+		Build: build2(IBeam2D),
+	})
+	Register(&Generator{
+		Name:  "Tube2D",
+		Doc:   "round tube profile",
+		Parms: func() any { return &TubeParms{Diameter: 25, Wall: 2} },
+		Build: build2(Tube2D),
+	})
+	Register(&Generator{
+		Name:  "RHS2D",
+		Doc:   "rectangular hollow section profile",
+		Parms: func() any { return &RHSParms{Size: v2.Vec{40, 20}, Wall: 2, Radius: 4} },
+		Build: build2(RHS2D),
+	})
+	Register(&Generator{
+		Name: "SlotNut3D",
+		Doc:  "printable slot nut for an aluminium extrusion",
+		Parms: func() any {
+			return &SlotNutParms{Series: 20, Style: "t-slot", Length: 12, Thread: "M3x0.5", Clearance: 0.2}
+		},
+		Build: build3(SlotNut3D),
+	})
+	Register(&Generator{
+		Name:  "ExtrusionBracket3D",
+		Doc:   "corner bracket for aluminium extrusions",
+		Parms: func() any { return &ExtrusionBracketParms{Series: 20, Holes: 2, Thickness: 4, Gusset: 3, Hole: 5.5} },
+		Build: build3(ExtrusionBracket3D),
+	})
+}
+
+//-----------------------------------------------------------------------------
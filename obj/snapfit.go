@@ -0,0 +1,549 @@
+//-----------------------------------------------------------------------------
+/*
+
+Snap-Fits and Compliant Joints
+
+Joints that rely on the flexing of the printed material. The maximum strain
+for the geometry is checked against the allowable strain of the material
+(see MaterialStrain).
+
+The strains are the usual snap-fit design approximations (E.g. the Bayer
+snap-fit design guide) for beams of constant section.
+
+Tabs:
+
+* CantileverTab: a hook on a beam that catches in a recess
+* AnnularTab: a pin with a bead that snaps into a groove
+* DovetailTab: a sliding dovetail (press fit with a negative clearance)
+
+Parts:
+
+* TorsionSnap3D: a latch lever on a torsion bar
+* SnapPin3D: a split pin with barbs
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+// materials
+
+// materialStrain is the allowable strain (for a single assembly) of print materials.
+var materialStrain = map[string]float64{
+	"PLA":   0.02,
+	"ABS":   0.025,
+	"PETG":  0.03,
+	"ASA":   0.025,
+	"Nylon": 0.04,
+	"PP":    0.25, // living hinges
+	"TPU":   0.5,
+}
+
+// MaterialStrain returns the allowable strain for a named print material.
+func MaterialStrain(name string) (float64, error) {
+	for k, v := range materialStrain {
+		if strings.EqualFold(k, name) {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("material \"%s\" not found", name)
+}
+
+// checkStrain returns an error if the strain is more than the allowable strain.
+func checkStrain(name string, strain, maxStrain float64) error {
+	if maxStrain <= 0 {
+		return fmt.Errorf("%s: max strain <= 0", name)
+	}
+	if strain > maxStrain {
+		return fmt.Errorf("%s: strain %.3f exceeds the allowable strain %.3f", name, strain, maxStrain)
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// cantilever snap-fit tabs
+
+// CantileverTab is a cantilever snap-fit hook. The hook hangs from the upper
+// body (along the -z axis) and catches in a recess in the lower body (in the
+// +y direction). The lower body surface is the xz plane.
+type CantileverTab struct {
+	Length    float64 // beam length
+	Thickness float64 // beam thickness
+	Width     float64 // beam width
+	Overhang  float64 // hook overhang (the beam deflection on assembly)
+	MaxStrain float64 // allowable strain of the material
+	Clearance float64 // print clearance
+}
+
+// Strain returns the maximum strain of the beam on assembly.
+func (t *CantileverTab) Strain() float64 {
+	// constant rectangular section beam
+	return 1.5 * t.Overhang * t.Thickness / (t.Length * t.Length)
+}
+
+// MaxOverhang returns the largest overhang for the allowable strain.
+func (t *CantileverTab) MaxOverhang() float64 {
+	return t.MaxStrain * t.Length * t.Length / (1.5 * t.Thickness)
+}
+
+// Check returns an error if the geometry is invalid or over strained.
+func (t *CantileverTab) Check() error {
+	if t.Length <= 0 || t.Thickness <= 0 || t.Width <= 0 || t.Overhang <= 0 {
+		return sdf.ErrMsg("cantilever dimensions must be > 0")
+	}
+	if t.Clearance < 0 {
+		return sdf.ErrMsg("clearance < 0")
+	}
+	return checkStrain("cantilever", t.Strain(), t.MaxStrain)
+}
+
+// NewCantileverTab returns a new cantilever snap-fit tab.
+func NewCantileverTab(k *CantileverTab) (Tab, error) {
+	if err := k.Check(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// hookHeight returns the height of the hook at the end of the beam.
+func (t *CantileverTab) hookHeight() float64 {
+	return 1.5 * t.Overhang
+}
+
+// hook returns the beam and hook.
+func (t *CantileverTab) hook() sdf.SDF3 {
+	l := t.Length
+	o := t.Overhang
+	// profile in the yz plane
+	p := sdf.NewPolygon()
+	p.Add(-t.Thickness, t.Thickness)
+	p.Add(-t.Thickness, -l)
+	p.Add(0, -l)
+	p.Add(o, -l+o)              // 45 degree lead-in
+	p.Add(o, -l+t.hookHeight()) // hook face
+	p.Add(0, -l+t.hookHeight()) // catch
+	p.Add(0, 0)                 // root
+	p.Add(t.Thickness, 0)       // root block
+	p.Add(t.Thickness, t.Thickness)
+	s, _ := sdf.Polygon2D(p.Vertices())
+	// extrude along x
+	m := sdf.RotateZ(sdf.DtoR(90)).Mul(sdf.RotateX(sdf.DtoR(90)))
+	return sdf.Transform3D(sdf.Extrude3D(s, t.Width), m)
+}
+
+// recess returns the recess for the hook.
+func (t *CantileverTab) recess() sdf.SDF3 {
+	c := t.Clearance
+	size := v3.Vec{t.Width + 2*c, t.Overhang + c, t.hookHeight() + 2*c}
+	s, _ := sdf.Box3D(size, 0)
+	ofs := v3.Vec{0, 0.5 * size.Y, -t.Length - c + 0.5*size.Z}
+	return sdf.Transform3D(s, sdf.Translate3d(ofs))
+}
+
+// Body returns the upper/lower body of a cantilever tab.
+func (t *CantileverTab) Body(upper bool, m sdf.M44) sdf.SDF3 {
+	if upper {
+		return sdf.Transform3D(t.hook(), m)
+	}
+	return nil
+}
+
+// Envelope returns the upper/lower envelope of a cantilever tab.
+func (t *CantileverTab) Envelope(upper bool, m sdf.M44) sdf.SDF3 {
+	if upper {
+		return nil
+	}
+	return sdf.Transform3D(t.recess(), m)
+}
+
+// box3 returns a box with the given corners.
+func box3(min, max v3.Vec) sdf.SDF3 {
+	s, _ := sdf.Box3D(max.Sub(min), 0)
+	return sdf.Transform3D(s, sdf.Translate3d(min.Add(max).MulScalar(0.5)))
+}
+
+// tabParts returns lower and upper bodies joined by a tab at the origin.
+func tabParts(tab Tab, lower, upper sdf.SDF3) []sdf.SDF3 {
+	m := []sdf.M44{sdf.Identity3d()}
+	return []sdf.SDF3{AddTabs(lower, tab, false, m), AddTabs(upper, tab, true, m)}
+}
+
+// CantileverSnap3D returns a test piece for a cantilever snap-fit.
+// The lower part is a wall with the recess, the upper part is a plate with the hook.
+func CantileverSnap3D(k *CantileverTab) ([]sdf.SDF3, error) {
+	tab, err := NewCantileverTab(k)
+	if err != nil {
+		return nil, err
+	}
+	const margin = 2.0
+	x := 0.5*k.Width + k.Clearance + margin
+	wall := k.Overhang + k.Clearance + margin
+	h := k.Length + k.hookHeight() + margin
+	lower := box3(v3.Vec{-x, 0, -h}, v3.Vec{x, wall, 0})
+	upper := box3(v3.Vec{-x, -k.Thickness - margin, 0}, v3.Vec{x, wall, k.Thickness})
+	return tabParts(tab, lower, upper), nil
+}
+
+//-----------------------------------------------------------------------------
+// annular snap-fit tabs
+
+// AnnularTab is a pin with a bead on the lower body that snaps into a groove
+// in a socket in the upper body. The pin is on the z-axis.
+type AnnularTab struct {
+	Diameter  float64 // pin diameter
+	Length    float64 // pin length
+	Undercut  float64 // radial height of the bead
+	MaxStrain float64 // allowable strain of the material
+	Clearance float64 // print clearance
+}
+
+// Strain returns the hoop strain of the socket on assembly.
+func (t *AnnularTab) Strain() float64 {
+	return 2 * t.Undercut / t.Diameter
+}
+
+// Check returns an error if the geometry is invalid or over strained.
+func (t *AnnularTab) Check() error {
+	if t.Diameter <= 0 || t.Length <= 0 || t.Undercut <= 0 {
+		return sdf.ErrMsg("annular snap dimensions must be > 0")
+	}
+	if t.Length < 4*t.Undercut {
+		return sdf.ErrMsg("the pin is too short for the bead")
+	}
+	if t.Clearance < 0 {
+		return sdf.ErrMsg("clearance < 0")
+	}
+	return checkStrain("annular snap", t.Strain(), t.MaxStrain)
+}
+
+// NewAnnularTab returns a new annular snap-fit tab.
+func NewAnnularTab(k *AnnularTab) (Tab, error) {
+	if err := k.Check(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// pin returns the pin (or socket) enlarged by a clearance.
+func (t *AnnularTab) pin(c float64) sdf.SDF3 {
+	r := 0.5*t.Diameter + c
+	u := t.Undercut
+	l := t.Length
+	// 45 degree bead near the end of the pin
+	p := sdf.NewPolygon()
+	p.Add(0, 0)
+	p.Add(r, 0)
+	p.Add(r, l-3*u)
+	p.Add(r+u, l-2*u)
+	p.Add(r, l-u)
+	p.Add(r, l+c)
+	p.Add(0, l+c)
+	s, _ := sdf.Polygon2D(p.Vertices())
+	s3, _ := sdf.Revolve3D(s)
+	return s3
+}
+
+// Body returns the upper/lower body of an annular tab.
+func (t *AnnularTab) Body(upper bool, m sdf.M44) sdf.SDF3 {
+	if upper {
+		return nil
+	}
+	return sdf.Transform3D(t.pin(0), m)
+}
+
+// Envelope returns the upper/lower envelope of an annular tab.
+func (t *AnnularTab) Envelope(upper bool, m sdf.M44) sdf.SDF3 {
+	if upper {
+		return sdf.Transform3D(t.pin(t.Clearance), m)
+	}
+	return nil
+}
+
+// AnnularSnap3D returns a test piece for an annular snap-fit.
+// The lower part is a plate with the pin, the upper part is a block with the socket.
+func AnnularSnap3D(k *AnnularTab) ([]sdf.SDF3, error) {
+	tab, err := NewAnnularTab(k)
+	if err != nil {
+		return nil, err
+	}
+	const margin = 3.0
+	x := 0.5*k.Diameter + k.Undercut + k.Clearance + margin
+	lower := box3(v3.Vec{-x, -x, -margin}, v3.Vec{x, x, 0})
+	upper := box3(v3.Vec{-x, -x, 0}, v3.Vec{x, x, k.Length + margin})
+	return tabParts(tab, lower, upper), nil
+}
+
+//-----------------------------------------------------------------------------
+// dovetail tabs
+
+// DovetailTab is a dovetail on the lower body that slides (along the x-axis)
+// into a slot in the upper body.
+type DovetailTab struct {
+	Width     float64 // width at the base of the dovetail
+	Height    float64 // height of the dovetail
+	Length    float64 // length of the dovetail
+	Angle     float64 // dovetail angle (radians, E.g. 15 degrees)
+	MaxStrain float64 // allowable strain of the material (press fits)
+	Clearance float64 // print clearance (< 0 for a press fit)
+}
+
+// Strain returns the strain of a press fit dovetail (0 for a sliding fit).
+func (t *DovetailTab) Strain() float64 {
+	if t.Clearance >= 0 {
+		return 0
+	}
+	return -2 * t.Clearance / t.Width
+}
+
+// Check returns an error if the geometry is invalid or over strained.
+func (t *DovetailTab) Check() error {
+	if t.Width <= 0 || t.Height <= 0 || t.Length <= 0 {
+		return sdf.ErrMsg("dovetail dimensions must be > 0")
+	}
+	if t.Angle < 0 || t.Angle >= sdf.DtoR(45) {
+		return sdf.ErrMsg("dovetail angle must be 0..45 degrees")
+	}
+	if t.Clearance >= 0 {
+		return nil
+	}
+	return checkStrain("dovetail", t.Strain(), t.MaxStrain)
+}
+
+// NewDovetailTab returns a new dovetail tab.
+func NewDovetailTab(k *DovetailTab) (Tab, error) {
+	if err := k.Check(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// dovetail returns the dovetail (or slot) enlarged by a clearance.
+func (t *DovetailTab) dovetail(c, length float64) sdf.SDF3 {
+	w0 := 0.5 * t.Width
+	w1 := w0 + t.Height*math.Tan(t.Angle)
+	// profile in the yz plane, extending below the base
+	p := sdf.NewPolygon()
+	p.Add(-w0, -t.Height)
+	p.Add(w0, -t.Height)
+	p.Add(w0, 0)
+	p.Add(w1, t.Height)
+	p.Add(-w1, t.Height)
+	p.Add(-w0, 0)
+	s, _ := sdf.Polygon2D(p.Vertices())
+	if c != 0 {
+		s = sdf.Offset2D(s, c)
+	}
+	// extrude along x
+	m := sdf.RotateZ(sdf.DtoR(90)).Mul(sdf.RotateX(sdf.DtoR(90)))
+	return sdf.Transform3D(sdf.Extrude3D(s, length), m)
+}
+
+// Body returns the upper/lower body of a dovetail tab.
+func (t *DovetailTab) Body(upper bool, m sdf.M44) sdf.SDF3 {
+	if upper {
+		return nil
+	}
+	return sdf.Transform3D(t.dovetail(0, t.Length), m)
+}
+
+// Envelope returns the upper/lower envelope of a dovetail tab.
+func (t *DovetailTab) Envelope(upper bool, m sdf.M44) sdf.SDF3 {
+	if upper {
+		// the slot is open at both ends
+		return sdf.Transform3D(t.dovetail(t.Clearance, t.Length+2), m)
+	}
+	return nil
+}
+
+// Dovetail3D returns a test piece for a dovetail.
+// The lower part is a block with the dovetail, the upper part is a block with the slot.
+func Dovetail3D(k *DovetailTab) ([]sdf.SDF3, error) {
+	tab, err := NewDovetailTab(k)
+	if err != nil {
+		return nil, err
+	}
+	const margin = 3.0
+	x := 0.5 * k.Length
+	y := 0.5*k.Width + k.Height*math.Tan(k.Angle) + math.Abs(k.Clearance) + margin
+	lower := box3(v3.Vec{-x, -y, -margin}, v3.Vec{x, y, 0})
+	upper := box3(v3.Vec{-x, -y, 0}, v3.Vec{x, y, k.Height + margin})
+	return tabParts(tab, lower, upper), nil
+}
+
+//-----------------------------------------------------------------------------
+// torsion snaps
+
+// poisson is the Poisson's ratio used to convert strain to shear strain.
+const poisson = 0.35
+
+// TorsionSnapParms defines a torsion snap, a latch lever on a torsion bar.
+// Pushing the tail of the lever lifts a tooth at the other end.
+type TorsionSnapParms struct {
+	BarDiameter    float64 // torsion bar diameter
+	BarLength      float64 // length of each torsion bar (from the lever to a post)
+	LeverLength    float64 // length of the lever from the torsion bar to the tooth
+	TailLength     float64 // length of the lever behind the torsion bar
+	LeverWidth     float64 // lever width
+	LeverThickness float64 // lever thickness
+	Overhang       float64 // tooth height (the tooth lift on assembly)
+	MaxStrain      float64 // allowable strain of the material
+	Clearance      float64 // clearance under the lever
+}
+
+// Strain returns the shear strain of the torsion bars on assembly.
+func (k *TorsionSnapParms) Strain() float64 {
+	twist := k.Overhang / k.LeverLength
+	return 0.5 * k.BarDiameter * twist / k.BarLength
+}
+
+// Check returns an error if the geometry is invalid or over strained.
+func (k *TorsionSnapParms) Check() error {
+	if k.BarDiameter <= 0 || k.BarLength <= 0 || k.LeverLength <= 0 || k.TailLength < 0 {
+		return sdf.ErrMsg("torsion bar and lever lengths must be > 0")
+	}
+	if k.LeverWidth <= 0 || k.LeverThickness <= 0 || k.Overhang <= 0 {
+		return sdf.ErrMsg("lever dimensions must be > 0")
+	}
+	if k.LeverThickness < k.BarDiameter {
+		return sdf.ErrMsg("the lever is thinner than the torsion bar")
+	}
+	if k.Clearance < 0 {
+		return sdf.ErrMsg("clearance < 0")
+	}
+	// allowable shear strain
+	return checkStrain("torsion snap", k.Strain(), (1+poisson)*k.MaxStrain)
+}
+
+// TorsionSnap3D returns a torsion snap. The torsion bar is on the x-axis
+// and the posts at its ends stand on the z = 0 plane.
+func TorsionSnap3D(k *TorsionSnapParms) (sdf.SDF3, error) {
+	if err := k.Check(); err != nil {
+		return nil, err
+	}
+	t := k.LeverThickness
+	o := k.Overhang
+	tilt := k.Overhang / k.LeverLength
+	// the lever clears the base when it is pushed
+	z := 0.5*t + math.Max(o, k.TailLength*tilt) + k.Clearance
+	post := 2 * k.BarDiameter
+	x := 0.5*k.LeverWidth + k.BarLength
+
+	bar, err := sdf.Cylinder3D(2*x+post, 0.5*k.BarDiameter, 0)
+	if err != nil {
+		return nil, err
+	}
+	bar = sdf.Transform3D(bar, sdf.Translate3d(v3.Vec{0, 0, z}).Mul(sdf.RotateY(sdf.DtoR(90))))
+	posts := []sdf.SDF3{
+		box3(v3.Vec{-x - post, -0.5 * post, 0}, v3.Vec{-x, 0.5 * post, z + 0.5*k.BarDiameter}),
+		box3(v3.Vec{x, -0.5 * post, 0}, v3.Vec{x + post, 0.5 * post, z + 0.5*k.BarDiameter}),
+	}
+	l := k.LeverLength
+	lever := box3(v3.Vec{-0.5 * k.LeverWidth, -k.TailLength, z - 0.5*t}, v3.Vec{0.5 * k.LeverWidth, l, z + 0.5*t})
+
+	// tooth with a 45 degree lead-in, profile in the yz plane
+	p := sdf.NewPolygon()
+	p.Add(l-2*o, z)
+	p.Add(l-2*o, z-0.5*t-o)
+	p.Add(l-o, z-0.5*t-o)
+	p.Add(l, z-0.5*t)
+	p.Add(l, z)
+	s, err := sdf.Polygon2D(p.Vertices())
+	if err != nil {
+		return nil, err
+	}
+	m := sdf.RotateZ(sdf.DtoR(90)).Mul(sdf.RotateX(sdf.DtoR(90)))
+	tooth := sdf.Transform3D(sdf.Extrude3D(s, k.LeverWidth), m)
+
+	return sdf.Union3D(append(posts, bar, lever, tooth)...), nil
+}
+
+//-----------------------------------------------------------------------------
+// snap pins
+
+// SnapPinParms defines a split snap pin with barbs. The pin holds parts
+// with aligned holes together.
+type SnapPinParms struct {
+	Hole         float64 // diameter of the hole for the pin
+	Grip         float64 // thickness of the parts held by the pin
+	HeadDiameter float64 // pin head diameter
+	HeadHeight   float64 // pin head height
+	Slot         float64 // width of the slot that splits the pin
+	SlotLength   float64 // length of the slot from the pin tip
+	Overhang     float64 // barb overhang beyond the hole
+	MaxStrain    float64 // allowable strain of the material
+	Clearance    float64 // clearance between the pin and the hole
+}
+
+// Strain returns the maximum strain of the pin prongs on assembly.
+func (k *SnapPinParms) Strain() float64 {
+	// the prongs are cantilevers deflected by the overhang
+	t := 0.5*k.Hole - k.Clearance - 0.5*k.Slot
+	return 1.5 * k.Overhang * t / (k.SlotLength * k.SlotLength)
+}
+
+// barbLength returns the length of the 30 degree barb lead-in.
+func (k *SnapPinParms) barbLength() float64 {
+	return (0.5*k.Hole + k.Overhang - 0.25*k.Hole) / math.Tan(sdf.DtoR(30))
+}
+
+// Check returns an error if the geometry is invalid or over strained.
+func (k *SnapPinParms) Check() error {
+	if k.Hole <= 0 || k.Grip <= 0 || k.HeadHeight <= 0 || k.Overhang <= 0 {
+		return sdf.ErrMsg("snap pin dimensions must be > 0")
+	}
+	if k.HeadDiameter <= k.Hole {
+		return sdf.ErrMsg("the head is smaller than the hole")
+	}
+	if k.Clearance < 0 {
+		return sdf.ErrMsg("clearance < 0")
+	}
+	if k.Slot < 2*k.Overhang || k.Slot >= k.Hole-2*k.Clearance {
+		return sdf.ErrMsg("the slot must be wider than the barbs deflect and narrower than the pin")
+	}
+	if k.SlotLength <= k.barbLength() || k.SlotLength > k.Grip+k.barbLength() {
+		return sdf.ErrMsg("the slot must be longer than the barbs and shorter than the pin")
+	}
+	return checkStrain("snap pin", k.Strain(), k.MaxStrain)
+}
+
+// SnapPin3D returns a split snap pin. The head is below the z = 0 plane and
+// the pin is on the z-axis.
+func SnapPin3D(k *SnapPinParms) (sdf.SDF3, error) {
+	if err := k.Check(); err != nil {
+		return nil, err
+	}
+	r := 0.5*k.Hole - k.Clearance
+	z := k.Grip + k.Clearance
+	tip := z + k.barbLength()
+	p := sdf.NewPolygon()
+	p.Add(0, -k.HeadHeight)
+	p.Add(0.5*k.HeadDiameter, -k.HeadHeight)
+	p.Add(0.5*k.HeadDiameter, 0)
+	p.Add(r, 0)
+	p.Add(r, z)
+	p.Add(0.5*k.Hole+k.Overhang, z)
+	p.Add(0.25*k.Hole, tip)
+	p.Add(0, tip)
+	s, err := sdf.Polygon2D(p.Vertices())
+	if err != nil {
+		return nil, err
+	}
+	pin, err := sdf.Revolve3D(s)
+	if err != nil {
+		return nil, err
+	}
+	slot := box3(v3.Vec{-k.HeadDiameter, -0.5 * k.Slot, tip - k.SlotLength}, v3.Vec{k.HeadDiameter, 0.5 * k.Slot, tip + 1})
+	return sdf.Difference3D(pin, slot), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,187 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_MaterialStrain(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		strain float64
+	}{
+		{"PLA", 0.02},
+		{"pla", 0.02},
+		{"PETG", 0.03},
+		{"nylon", 0.04},
+		{"PP", 0.25},
+	} {
+		strain, err := MaterialStrain(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strain != test.strain {
+			t.Errorf("%s: expected %g, got %g", test.name, test.strain, strain)
+		}
+	}
+	if _, err := MaterialStrain("balsa"); err == nil {
+		t.Error("expected an error for an unknown material")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// strainTest is a strain calculation with a known answer.
+type strainTest struct {
+	name   string
+	strain func() float64
+	check  func() error
+	result float64
+}
+
+func runStrainTests(t *testing.T, tests []strainTest) {
+	t.Helper()
+	for _, test := range tests {
+		if s := test.strain(); math.Abs(s-test.result) > 1e-9 {
+			t.Errorf("%s: expected strain %g, got %g", test.name, test.result, s)
+		}
+		if err := test.check(); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+	}
+}
+
+func Test_CantileverStrain(t *testing.T) {
+	pla, err := MaterialStrain("PLA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bayer snap-fit guide, rectangular section of constant thickness:
+	// strain = 1.5 * y * h / l^2
+	k := &CantileverTab{Length: 12, Thickness: 1.5, Width: 6, Overhang: 1, MaxStrain: pla}
+	runStrainTests(t, []strainTest{
+		{"cantilever", k.Strain, k.Check, 1.5 * 1 * 1.5 / 144},
+	})
+	// the largest overhang is at the allowable strain
+	if o := k.MaxOverhang(); math.Abs(o-pla*144/(1.5*1.5)) > 1e-9 {
+		t.Errorf("bad max overhang %g", o)
+	}
+	k.Overhang = 0.999 * k.MaxOverhang()
+	if err := k.Check(); err != nil {
+		t.Errorf("under the allowable strain: %s", err)
+	}
+	k.Overhang = 1.001 * k.MaxOverhang()
+	if err := k.Check(); err == nil {
+		t.Error("over the allowable strain: expected an error")
+	}
+	if _, err := CantileverSnap3D(k); err == nil {
+		t.Error("over the allowable strain: expected an error from CantileverSnap3D")
+	}
+	// a stiffer material takes the same overhang
+	k.MaxStrain, _ = MaterialStrain("nylon")
+	if _, err := NewCantileverTab(k); err != nil {
+		t.Error(err)
+	}
+	// no allowable strain
+	k.MaxStrain = 0
+	if err := k.Check(); err == nil {
+		t.Error("expected an error for MaxStrain 0")
+	}
+}
+
+func Test_SnapStrain(t *testing.T) {
+	pla, _ := MaterialStrain("PLA")
+	abs, _ := MaterialStrain("ABS")
+
+	annular := &AnnularTab{Diameter: 10, Length: 6, Undercut: 0.12, MaxStrain: abs}
+	dovetail := &DovetailTab{Width: 10, Height: 4, Length: 20, Angle: 0.25, MaxStrain: pla, Clearance: -0.09}
+	sliding := &DovetailTab{Width: 10, Height: 4, Length: 20, Angle: 0.25, Clearance: 0.2}
+	torsion := &TorsionSnapParms{
+		BarDiameter: 3, BarLength: 5, LeverLength: 20, TailLength: 8,
+		LeverWidth: 6, LeverThickness: 3, Overhang: 1, MaxStrain: pla, Clearance: 0.5,
+	}
+	pin := &SnapPinParms{
+		Hole: 5, Grip: 6, HeadDiameter: 8, HeadHeight: 2, Slot: 1.5,
+		SlotLength: 6, Overhang: 0.3, MaxStrain: abs, Clearance: 0.1,
+	}
+	runStrainTests(t, []strainTest{
+		// hoop strain 2 * undercut / diameter
+		{"annular", annular.Strain, annular.Check, 0.024},
+		// press fit 2 * interference / width
+		{"dovetail", dovetail.Strain, dovetail.Check, 0.018},
+		{"sliding dovetail", sliding.Strain, sliding.Check, 0},
+		// shear strain 0.5 * d * (overhang / lever) / bar length
+		{"torsion", torsion.Strain, torsion.Check, 0.5 * 3 * (1.0 / 20) / 5},
+		// prong cantilever with t = 0.5 * hole - clearance - 0.5 * slot
+		{"snap pin", pin.Strain, pin.Check, 1.5 * 0.3 * 1.65 / 36},
+	})
+
+	// fail over the allowable strain
+	annular.MaxStrain = pla
+	if err := annular.Check(); err == nil {
+		t.Error("annular: expected an error over the allowable strain")
+	}
+	dovetail.Clearance = -0.11
+	if err := dovetail.Check(); err == nil {
+		t.Error("dovetail: expected an error over the allowable strain")
+	}
+	// the torsion bar is checked against the allowable shear strain
+	torsion.Overhang = 1.3 * pla / 0.015
+	if err := torsion.Check(); err != nil {
+		t.Errorf("torsion: %s", err)
+	}
+	torsion.Overhang = 1.4 * pla / 0.015
+	if err := torsion.Check(); err == nil {
+		t.Error("torsion: expected an error over the allowable shear strain")
+	}
+	pin.Overhang = 0.7
+	if err := pin.Check(); err == nil {
+		t.Error("snap pin: expected an error over the allowable strain")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_FlexureStrain(t *testing.T) {
+	pla, _ := MaterialStrain("PLA")
+	pp, _ := MaterialStrain("PP")
+
+	hinge := &LivingHingeParms{Thickness: 0.5, Length: 8, MaxStrain: pp}
+	pivot := &FlexurePivotParms{Thickness: 0.5, Length: 10, Width: 5, Block: v2.Vec{15, 5}, Angle: 0.2, MaxStrain: pla}
+	runStrainTests(t, []strainTest{
+		// a strip folded into a semicircle, pi * t / (2 * l)
+		{"living hinge", hinge.Strain, hinge.Check, math.Pi * 0.5 / 16},
+		// an S bent leaf, t * angle / l
+		{"flexure pivot", pivot.Strain, pivot.Check, 0.01},
+	})
+
+	// a PLA living hinge breaks
+	hinge.MaxStrain = pla
+	if err := hinge.Check(); err == nil {
+		t.Error("living hinge: expected an error over the allowable strain")
+	}
+	// the shortest hinge for the material
+	hinge.MaxStrain = pp
+	hinge.Length = 1.001 * math.Pi * hinge.Thickness / (2 * pp)
+	if err := hinge.Check(); err != nil {
+		t.Errorf("living hinge: %s", err)
+	}
+	hinge.Length = 0.999 * math.Pi * hinge.Thickness / (2 * pp)
+	if err := hinge.Check(); err == nil {
+		t.Error("living hinge: expected an error over the allowable strain")
+	}
+	pivot.Angle = 0.41
+	if err := pivot.Check(); err == nil {
+		t.Error("flexure pivot: expected an error over the allowable strain")
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,282 @@
+//-----------------------------------------------------------------------------
+/*
+
+Structural Sections
+
+Profiles for channel, I-beam, round tube and rectangular hollow sections,
+and a catalog of standard sizes (including angle and aluminium extrusions).
+
+The profiles are 2d, use sdf.Extrude3D to make a length of the section.
+Channel and I-beam flanges are parallel (tapered flanges are approximated).
+
+Catalog names (see ProfileNames):
+
+* angle (EN 10056): "L25x25x3", ...
+* channel (UPN): "UPN80", ...
+* I-beam (IPE): "IPE100", ...
+* rectangular/square hollow sections (EN 10219): "RHS40x20x2", ...
+* circular hollow sections: "CHS25x2", ...
+* extrusions: "2020", "2040", "3030", ..., "vslot_2020", ...
+
+All dimensions are in millimetres.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// BeamParms defines the parameters for a channel or an I-beam.
+type BeamParms struct {
+	Width      float64 // flange width
+	Height     float64 // section height
+	Web        float64 // web thickness
+	Flange     float64 // flange thickness
+	RootRadius float64 // radius of the web to flange fillets
+}
+
+// check returns an error if the beam parameters are invalid.
+func (k *BeamParms) check(web float64) error {
+	if k.Width <= 0 || k.Height <= 0 || k.Web <= 0 || k.Flange <= 0 {
+		return sdf.ErrMsg("beam dimensions must be > 0")
+	}
+	if k.Web >= k.Width {
+		return sdf.ErrMsg("web is wider than the flanges")
+	}
+	if 2*k.Flange >= k.Height {
+		return sdf.ErrMsg("flanges are thicker than the section")
+	}
+	if k.RootRadius < 0 {
+		return sdf.ErrMsg("root radius < 0")
+	}
+	if k.RootRadius > web || k.RootRadius > 0.5*k.Height-k.Flange {
+		return sdf.ErrMsg("root radius is too large")
+	}
+	return nil
+}
+
+// Channel2D returns a channel profile. The back of the web is on the y-axis,
+// the flanges are in the +x direction and the section is centered on the x-axis.
+func Channel2D(k *BeamParms) (sdf.SDF2, error) {
+	if err := k.check(k.Width - k.Web); err != nil {
+		return nil, err
+	}
+	h := 0.5 * k.Height
+	p := sdf.NewPolygon()
+	p.Add(0, -h)
+	p.Add(k.Width, -h)
+	p.Add(k.Width, -h+k.Flange)
+	p.Add(k.Web, -h+k.Flange).Smooth(k.RootRadius, 6)
+	p.Add(k.Web, h-k.Flange).Smooth(k.RootRadius, 6)
+	p.Add(k.Width, h-k.Flange)
+	p.Add(k.Width, h)
+	p.Add(0, h)
+	return sdf.Polygon2D(p.Vertices())
+}
+
+// IBeam2D returns an I-beam profile centered on the origin. The web is on the y-axis.
+func IBeam2D(k *BeamParms) (sdf.SDF2, error) {
+	if err := k.check(0.5 * (k.Width - k.Web)); err != nil {
+		return nil, err
+	}
+	w := 0.5 * k.Width
+	t := 0.5 * k.Web
+	h := 0.5 * k.Height
+	p := sdf.NewPolygon()
+	p.Add(-w, -h)
+	p.Add(w, -h)
+	p.Add(w, -h+k.Flange)
+	p.Add(t, -h+k.Flange).Smooth(k.RootRadius, 6)
+	p.Add(t, h-k.Flange).Smooth(k.RootRadius, 6)
+	p.Add(w, h-k.Flange)
+	p.Add(w, h)
+	p.Add(-w, h)
+	p.Add(-w, h-k.Flange)
+	p.Add(-t, h-k.Flange).Smooth(k.RootRadius, 6)
+	p.Add(-t, -h+k.Flange).Smooth(k.RootRadius, 6)
+	p.Add(-w, -h+k.Flange)
+	return sdf.Polygon2D(p.Vertices())
+}
+
+//-----------------------------------------------------------------------------
+
+// TubeParms defines the parameters for a round tube.
+type TubeParms struct {
+	Diameter float64 // outside diameter
+	Wall     float64 // wall thickness
+}
+
+// Tube2D returns a round tube profile centered on the origin.
+func Tube2D(k *TubeParms) (sdf.SDF2, error) {
+	if k.Wall <= 0 || 2*k.Wall >= k.Diameter {
+		return nil, sdf.ErrMsg("bad tube wall thickness")
+	}
+	s0, err := sdf.Circle2D(0.5 * k.Diameter)
+	if err != nil {
+		return nil, err
+	}
+	s1, err := sdf.Circle2D(0.5*k.Diameter - k.Wall)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference2D(s0, s1), nil
+}
+
+// RHSParms defines the parameters for a rectangular hollow section.
+type RHSParms struct {
+	Size   v2.Vec  // outside size
+	Wall   float64 // wall thickness
+	Radius float64 // outside corner radius
+}
+
+// RHS2D returns a rectangular hollow section profile centered on the origin.
+func RHS2D(k *RHSParms) (sdf.SDF2, error) {
+	if k.Wall <= 0 || 2*k.Wall >= math.Min(k.Size.X, k.Size.Y) {
+		return nil, sdf.ErrMsg("bad section wall thickness")
+	}
+	if k.Radius < 0 || 2*k.Radius > math.Min(k.Size.X, k.Size.Y) {
+		return nil, sdf.ErrMsg("bad section corner radius")
+	}
+	s0 := sdf.Box2D(k.Size, k.Radius)
+	s1 := sdf.Box2D(k.Size.SubScalar(2*k.Wall), math.Max(k.Radius-k.Wall, 0))
+	return sdf.Difference2D(s0, s1), nil
+}
+
+//-----------------------------------------------------------------------------
+// catalog
+
+// profileDatabase maps a catalog name to a profile function.
+type profileDatabase map[string]func() (sdf.SDF2, error)
+
+var profileDB = initProfileLookup()
+
+// add adds a profile to the profile database.
+func (m profileDatabase) add(name string, fn func() (sdf.SDF2, error)) {
+	if _, ok := m[name]; ok {
+		panic(fmt.Sprintf("duplicate profile \"%s\"", name))
+	}
+	m[name] = fn
+}
+
+// initProfileLookup adds the standard profiles to the profile database.
+func initProfileLookup() profileDatabase {
+	m := make(profileDatabase)
+
+	// EN 10056 equal angle: leg, thickness, root radius
+	for _, r := range [][3]float64{
+		{20, 3, 3.5},
+		{25, 3, 3.5},
+		{30, 3, 5},
+		{40, 4, 6},
+		{50, 5, 7},
+	} {
+		k := AngleParms{X: AngleLeg{r[0], r[1]}, Y: AngleLeg{r[0], r[1]}, RootRadius: r[2]}
+		m.add(fmt.Sprintf("L%gx%gx%g", r[0], r[0], r[1]), func() (sdf.SDF2, error) { return Angle2D(&k) })
+	}
+
+	// UPN channel: height, width, web, flange, root radius
+	for _, r := range [][5]float64{
+		{50, 38, 5, 7, 7},
+		{65, 42, 5.5, 7.5, 7.5},
+		{80, 45, 6, 8, 8},
+		{100, 50, 6, 8.5, 8.5},
+		{120, 55, 7, 9, 9},
+	} {
+		k := BeamParms{Width: r[1], Height: r[0], Web: r[2], Flange: r[3], RootRadius: r[4]}
+		m.add(fmt.Sprintf("UPN%g", r[0]), func() (sdf.SDF2, error) { return Channel2D(&k) })
+	}
+
+	// IPE I-beam: height, width, web, flange, root radius
+	for _, r := range [][5]float64{
+		{80, 46, 3.8, 5.2, 5},
+		{100, 55, 4.1, 5.7, 7},
+		{120, 64, 4.4, 6.3, 7},
+		{140, 73, 4.7, 6.9, 7},
+		{160, 82, 5, 7.4, 9},
+	} {
+		k := BeamParms{Width: r[1], Height: r[0], Web: r[2], Flange: r[3], RootRadius: r[4]}
+		m.add(fmt.Sprintf("IPE%g", r[0]), func() (sdf.SDF2, error) { return IBeam2D(&k) })
+	}
+
+	// EN 10219 cold formed hollow sections: width, height, wall (outside radius 2 x wall)
+	for _, r := range [][3]float64{
+		{20, 20, 2},
+		{25, 25, 2},
+		{30, 30, 2},
+		{40, 40, 3},
+		{50, 50, 3},
+		{40, 20, 2},
+		{50, 25, 2.5},
+		{60, 40, 3},
+	} {
+		k := RHSParms{Size: v2.Vec{r[0], r[1]}, Wall: r[2], Radius: 2 * r[2]}
+		m.add(fmt.Sprintf("RHS%gx%gx%g", r[0], r[1], r[2]), func() (sdf.SDF2, error) { return RHS2D(&k) })
+	}
+
+	// circular hollow sections: diameter, wall
+	for _, r := range [][2]float64{
+		{20, 2},
+		{25, 2},
+		{25.4, 1.6},
+		{30, 2},
+		{33.7, 3.2},
+		{42.4, 3.2},
+		{48.3, 3.2},
+	} {
+		k := TubeParms{Diameter: r[0], Wall: r[1]}
+		m.add(fmt.Sprintf("CHS%gx%g", r[0], r[1]), func() (sdf.SDF2, error) { return Tube2D(&k) })
+	}
+
+	// aluminium extrusions
+	for _, style := range []string{"t-slot", "v-slot"} {
+		for _, r := range [][3]int{
+			{20, 1, 1},
+			{20, 1, 2},
+			{20, 1, 3},
+			{20, 1, 4},
+			{20, 2, 2},
+			{30, 1, 1},
+			{30, 1, 2},
+			{30, 2, 2},
+		} {
+			k := ExtrusionParms{Series: r[0], Style: style, X: r[1], Y: r[2]}
+			name := fmt.Sprintf("%d%d", r[0]*r[1], r[0]*r[2])
+			if style == "v-slot" {
+				name = "vslot_" + name
+			}
+			m.add(name, func() (sdf.SDF2, error) { return Extrusion2D(&k) })
+		}
+	}
+
+	return m
+}
+
+// ProfileLookup returns a standard profile by name.
+func ProfileLookup(name string) (sdf.SDF2, error) {
+	if fn, ok := profileDB[name]; ok {
+		return fn()
+	}
+	return nil, fmt.Errorf("profile \"%s\" not found", name)
+}
+
+// ProfileNames returns the sorted names of the profiles in the catalog.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profileDB))
+	for name := range profileDB {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//-----------------------------------------------------------------------------
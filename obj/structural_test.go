@@ -0,0 +1,90 @@
+//-----------------------------------------------------------------------------
+
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// profileSize returns the outside size of a catalog profile from its name.
+// Channels and I-beams return a zero width, the name only has the height.
+func profileSize(t *testing.T, name string) v2.Vec {
+	var a, b, c float64
+	var x, y int
+	switch {
+	case strings.HasPrefix(name, "L"):
+		if _, err := fmt.Sscanf(name, "L%gx%gx%g", &a, &b, &c); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		return v2.Vec{a, b}
+	case strings.HasPrefix(name, "UPN"):
+		fmt.Sscanf(name, "UPN%g", &b)
+		return v2.Vec{0, b}
+	case strings.HasPrefix(name, "IPE"):
+		fmt.Sscanf(name, "IPE%g", &b)
+		return v2.Vec{0, b}
+	case strings.HasPrefix(name, "RHS"):
+		if _, err := fmt.Sscanf(name, "RHS%gx%gx%g", &a, &b, &c); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		return v2.Vec{a, b}
+	case strings.HasPrefix(name, "CHS"):
+		if _, err := fmt.Sscanf(name, "CHS%gx%g", &a, &c); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		return v2.Vec{a, a}
+	}
+	// extrusions, E.g. 2040 or vslot_2040
+	if _, err := fmt.Sscanf(strings.TrimPrefix(name, "vslot_"), "%2d%2d", &x, &y); err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+	return v2.Vec{float64(x), float64(y)}
+}
+
+func Test_ProfileCatalog(t *testing.T) {
+	names := ProfileNames()
+	if len(names) != 46 {
+		t.Fatalf("expected 46 profiles, got %d", len(names))
+	}
+	for _, name := range names {
+		s, err := ProfileLookup(name)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		// the profile size matches the name
+		size := s.BoundingBox().Size()
+		want := profileSize(t, name)
+		if want.X == 0 {
+			want.X = size.X
+		}
+		if !size.Equals(want, 1e-6) {
+			t.Errorf("%s: expected size %v, got %v", name, want, size)
+		}
+		// the profile isn't empty
+		bb := s.BoundingBox()
+		solid := false
+		const n = 50
+		for i := 0; i <= n && !solid; i++ {
+			for j := 0; j <= n && !solid; j++ {
+				p := bb.Min.Add(size.Mul(v2.Vec{float64(i), float64(j)}).DivScalar(n))
+				solid = s.Evaluate(p) < 0
+			}
+		}
+		if !solid {
+			t.Errorf("%s: empty profile", name)
+		}
+	}
+	if _, err := ProfileLookup("L1x1x1"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+//-----------------------------------------------------------------------------
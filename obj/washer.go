@@ -19,10 +19,11 @@ import (
 
 // WasherParms defines the parameters for a washer.
 type WasherParms struct {
-	Thickness   float64 // thickness (3d only)
-	InnerRadius float64 // inner radius
-	OuterRadius float64 // outer radius
-	Remove      float64 // fraction of complete washer removed
+	Thickness   float64   // thickness (3d only)
+	InnerRadius float64   // inner radius
+	OuterRadius float64   // outer radius
+	Remove      float64   // fraction of complete washer removed
+	Fit         *FitParms // fit of the inner hole, e.g. "H11" (optional, 3d only)
 }
 
 //-----------------------------------------------------------------------------
@@ -54,6 +55,16 @@ func Washer3D(k *WasherParms) (sdf.SDF3, error) {
 	if k.Thickness <= 0 {
 		return nil, sdf.ErrMsg("Thickness <= 0")
 	}
+	if k.Fit != nil {
+		ofs, err := k.Fit.HoleOffset(2.0 * k.InnerRadius)
+		if err != nil {
+			return nil, err
+		}
+		fitted := *k
+		fitted.InnerRadius += ofs
+		fitted.Fit = nil
+		k = &fitted
+	}
 	if k.InnerRadius >= k.OuterRadius {
 		return nil, sdf.ErrMsg("InnerRadius >= OuterRadius")
 	}
@@ -0,0 +1,412 @@
+//-----------------------------------------------------------------------------
+/*
+
+Live Preview Server
+
+Serve SDF2/SDF3 models to a browser on localhost.
+
+SDF3 models are rendered to triangle meshes and shown in a WebGL viewer.
+SDF2 models are rendered as PNG images with the contour overlaid.
+The viewer has no external dependencies, so it works offline.
+
+Models are rebuilt (and the browser updated) when a watched file changes,
+when Rebuild is called, or when the rebuild endpoint is posted to.
+The viewer also reloads when the server is restarted, so a "go run" loop
+on the model source gives a live preview of code changes.
+
+*/
+//-----------------------------------------------------------------------------
+
+package preview
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/deadsy/sdfx/render"
+	"github.com/deadsy/sdfx/sdf"
+	"github.com/deadsy/sdfx/vec/v2i"
+)
+
+//-----------------------------------------------------------------------------
+
+//go:embed viewer.html
+var viewerHTML []byte
+
+// ModelFunc returns the SDF2 or SDF3 for a model.
+type ModelFunc func() (any, error)
+
+// Parms are the preview server parameters.
+type Parms struct {
+	Addr  string        // listen address (default "localhost:8080")
+	Cells int           // initial mesh cells along the longest axis (default 100)
+	Watch []string      // files and directories to watch for changes
+	Poll  time.Duration // file watch polling interval (default 500ms)
+}
+
+// model is a registered model.
+type model struct {
+	name string
+	fn   ModelFunc
+	s    any   // built SDF2/SDF3
+	err  error // build error
+}
+
+// Server is a live preview server.
+type Server struct {
+	k       Parms
+	boot    string // server instance id
+	mu      sync.Mutex
+	models  map[string]*model
+	version int                   // incremented on each rebuild
+	cache   map[string][]byte     // rendered outputs for the current version
+	clients map[chan int]struct{} // event stream clients
+}
+
+// NewServer returns a preview server.
+func NewServer(k *Parms) *Server {
+	s := &Server{
+		k:       *k,
+		boot:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		models:  map[string]*model{},
+		cache:   map[string][]byte{},
+		clients: map[chan int]struct{}{},
+	}
+	if s.k.Addr == "" {
+		s.k.Addr = "localhost:8080"
+	}
+	if s.k.Cells <= 0 {
+		s.k.Cells = 100
+	}
+	if s.k.Poll <= 0 {
+		s.k.Poll = 500 * time.Millisecond
+	}
+	return s
+}
+
+// Register registers a model function, it is called on each rebuild.
+func (s *Server) Register(name string, fn ModelFunc) {
+	m := &model{name: name, fn: fn}
+	m.s, m.err = build(fn)
+	s.mu.Lock()
+	s.models[name] = m
+	s.mu.Unlock()
+	s.changed()
+}
+
+// Show registers a fixed SDF2/SDF3.
+func (s *Server) Show(name string, x any) {
+	s.Register(name, func() (any, error) { return x, nil })
+}
+
+// Rebuild rebuilds all of the models and updates the viewers.
+func (s *Server) Rebuild() {
+	s.mu.Lock()
+	models := make([]*model, 0, len(s.models))
+	for _, m := range s.models {
+		models = append(models, m)
+	}
+	s.mu.Unlock()
+	for _, m := range models {
+		x, err := build(m.fn)
+		s.mu.Lock()
+		m.s, m.err = x, err
+		s.mu.Unlock()
+	}
+	s.changed()
+}
+
+// build calls a model function and checks the result.
+func build(fn ModelFunc) (x any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			x, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	x, err = fn()
+	if err != nil {
+		return nil, err
+	}
+	switch x.(type) {
+	case sdf.SDF2, sdf.SDF3:
+		return x, nil
+	}
+	return nil, fmt.Errorf("model is %T, not an SDF2 or SDF3", x)
+}
+
+// changed clears the render cache and notifies the viewers.
+func (s *Server) changed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	s.cache = map[string][]byte{}
+	for c := range s.clients {
+		select {
+		case c <- s.version:
+		default:
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// Handler returns the http handler for the preview server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleViewer)
+	mux.HandleFunc("/api/models", s.handleModels)
+	mux.HandleFunc("/api/mesh", s.handleMesh)
+	mux.HandleFunc("/api/image", s.handleImage)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	mux.HandleFunc("/api/rebuild", s.handleRebuild)
+	return mux
+}
+
+// ListenAndServe watches the files and serves the viewer.
+func (s *Server) ListenAndServe() error {
+	if len(s.k.Watch) != 0 {
+		go watch(s.k.Watch, s.k.Poll, s.Rebuild)
+	}
+	fmt.Printf("preview at http://%s/\n", s.k.Addr)
+	return http.ListenAndServe(s.k.Addr, s.Handler())
+}
+
+//-----------------------------------------------------------------------------
+
+func (s *Server) handleViewer(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(viewerHTML)
+}
+
+// modelInfo is the viewer description of a model.
+type modelInfo struct {
+	Name  string    `json:"name"`
+	Dim   int       `json:"dim"` // 2 or 3 (0 on error)
+	Error string    `json:"error,omitempty"`
+	Min   []float64 `json:"min,omitempty"` // bounding box
+	Max   []float64 `json:"max,omitempty"`
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	info := struct {
+		Boot    string      `json:"boot"`
+		Version int         `json:"version"`
+		Cells   int         `json:"cells"`
+		Models  []modelInfo `json:"models"`
+	}{
+		Boot:    s.boot,
+		Version: s.version,
+		Cells:   s.k.Cells,
+		Models:  []modelInfo{},
+	}
+	for _, m := range s.models {
+		mi := modelInfo{Name: m.name}
+		switch x := m.s.(type) {
+		case sdf.SDF3:
+			bb := x.BoundingBox()
+			mi.Dim = 3
+			mi.Min = []float64{bb.Min.X, bb.Min.Y, bb.Min.Z}
+			mi.Max = []float64{bb.Max.X, bb.Max.Y, bb.Max.Z}
+		case sdf.SDF2:
+			bb := x.BoundingBox()
+			mi.Dim = 2
+			mi.Min = []float64{bb.Min.X, bb.Min.Y}
+			mi.Max = []float64{bb.Max.X, bb.Max.Y}
+		}
+		if m.err != nil {
+			mi.Error = m.err.Error()
+		}
+		info.Models = append(info.Models, mi)
+	}
+	s.mu.Unlock()
+	sort.Slice(info.Models, func(i, j int) bool { return info.Models[i].Name < info.Models[j].Name })
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&info)
+}
+
+// lookup returns the current SDF and cache key for a render request.
+func (s *Server) lookup(r *http.Request, kind string) (any, string, int, error) {
+	name := r.URL.Query().Get("name")
+	cells, err := strconv.Atoi(r.URL.Query().Get("cells"))
+	if err != nil || cells <= 0 {
+		cells = s.k.Cells
+	}
+	cells = min(cells, 1000)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.models[name]
+	if !ok {
+		return nil, "", 0, fmt.Errorf("model \"%s\" not found", name)
+	}
+	if m.err != nil {
+		return nil, "", 0, m.err
+	}
+	return m.s, fmt.Sprintf("%s/%s/%d/%d", kind, name, cells, s.version), cells, nil
+}
+
+// cached returns a render result from the cache, rendering it if needed.
+func (s *Server) cached(key string, fn func() ([]byte, error)) ([]byte, error) {
+	s.mu.Lock()
+	b, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return b, nil
+	}
+	b, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cache[key] = b
+	s.mu.Unlock()
+	return b, nil
+}
+
+func (s *Server) handleMesh(w http.ResponseWriter, r *http.Request) {
+	x, key, cells, err := s.lookup(r, "mesh")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s3, ok := x.(sdf.SDF3)
+	if !ok {
+		http.Error(w, "not an SDF3", http.StatusBadRequest)
+		return
+	}
+	b, err := s.cached(key, func() ([]byte, error) {
+		return encodeMesh(render.ToTriangles(s3, render.NewMarchingCubesOctree(cells))), nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(b)
+}
+
+func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
+	x, key, cells, err := s.lookup(r, "image")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s2, ok := x.(sdf.SDF2)
+	if !ok {
+		http.Error(w, "not an SDF2", http.StatusBadRequest)
+		return
+	}
+	b, err := s.cached(key, func() ([]byte, error) {
+		return encodeImage(s2, cells)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(b)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	c := make(chan int, 1)
+	s.mu.Lock()
+	s.clients[c] = struct{}{}
+	version := s.version
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+	fmt.Fprintf(w, "data: %s %d\n\n", s.boot, version)
+	flusher.Flush()
+	for {
+		select {
+		case version := <-c:
+			fmt.Fprintf(w, "data: %s %d\n\n", s.boot, version)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) handleRebuild(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	s.Rebuild()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//-----------------------------------------------------------------------------
+
+// encodeMesh returns the binary viewer mesh for a set of triangles.
+// The format is a little endian uint32 triangle count followed by the
+// float32 position and normal (x, y, z, nx, ny, nz) of each vertex.
+func encodeMesh(mesh []*sdf.Triangle3) []byte {
+	buf := make([]byte, 4+len(mesh)*3*6*4)
+	binary.LittleEndian.PutUint32(buf, uint32(len(mesh)))
+	i := 4
+	put := func(x float64) {
+		binary.LittleEndian.PutUint32(buf[i:], math.Float32bits(float32(x)))
+		i += 4
+	}
+	for _, t := range mesh {
+		n := t.Normal()
+		for _, v := range t {
+			put(v.X)
+			put(v.Y)
+			put(v.Z)
+			put(n.X)
+			put(n.Y)
+			put(n.Z)
+		}
+	}
+	return buf
+}
+
+// encodeImage renders an SDF2 to a PNG image with the contour overlaid.
+func encodeImage(s sdf.SDF2, cells int) ([]byte, error) {
+	bb := s.BoundingBox().ScaleAboutCenter(1.1)
+	size := bb.Size()
+	scale := float64(cells) / max(size.X, size.Y)
+	pixels := v2i.Vec{max(int(size.X*scale), 2), max(int(size.Y*scale), 2)}
+	d, err := render.NewPNG("", bb, pixels)
+	if err != nil {
+		return nil, err
+	}
+	d.RenderSDF2(s)
+	for _, l := range render.ToLines(s, render.NewMarchingSquaresQuadtree(cells)) {
+		d.Line(l[0], l[1])
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, d.Image()); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+//-----------------------------------------------------------------------------
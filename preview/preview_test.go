@@ -0,0 +1,88 @@
+//-----------------------------------------------------------------------------
+/*
+
+Preview Server Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+func get(t *testing.T, url string) []byte {
+	r, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.StatusCode != http.StatusOK {
+		t.Fatalf("%s: %s %s", url, r.Status, b)
+	}
+	return b
+}
+
+func Test_Preview(t *testing.T) {
+	s := NewServer(&Parms{Cells: 20})
+	sphere, _ := sdf.Sphere3D(10)
+	circle, _ := sdf.Circle2D(5)
+	s.Show("sphere", sphere)
+	s.Show("circle", circle)
+	s.Register("bad", func() (any, error) { return nil, errors.New("bad model") })
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	var info struct {
+		Version int
+		Models  []modelInfo
+	}
+	if err := json.Unmarshal(get(t, ts.URL+"/api/models"), &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Models) != 3 || info.Models[0].Error != "bad model" || info.Models[1].Dim != 2 || info.Models[2].Dim != 3 {
+		t.Fatalf("bad models %v", info.Models)
+	}
+
+	// mesh: triangle count and 3 vertices of 6 float32s per triangle
+	b := get(t, ts.URL+"/api/mesh?name=sphere&cells=20")
+	n := binary.LittleEndian.Uint32(b)
+	if n == 0 || len(b) != 4+int(n)*3*6*4 {
+		t.Fatalf("bad mesh size %d for %d triangles", len(b), n)
+	}
+
+	// image
+	img, err := png.Decode(bytes.NewReader(get(t, ts.URL+"/api/image?name=circle&cells=50")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() < 40 {
+		t.Fatalf("bad image size %v", img.Bounds())
+	}
+
+	// rebuild
+	v := s.version
+	s.Rebuild()
+	if s.version != v+1 || len(s.cache) != 0 {
+		t.Fatal("rebuild didn't update the version")
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,86 @@
+//-----------------------------------------------------------------------------
+/*
+
+File Watching
+
+Poll the modification times of a set of files and directories.
+Polling needs no platform support and is fast enough for a few source files.
+
+*/
+//-----------------------------------------------------------------------------
+
+package preview
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+// fileTimes returns the modification times of the files (directories are walked).
+func fileTimes(paths []string) map[string]time.Time {
+	t := map[string]time.Time{}
+	for _, path := range paths {
+		filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				// skip hidden directories (E.g. .git)
+				if p != path && len(d.Name()) > 1 && d.Name()[0] == '.' {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				t[p] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return t
+}
+
+// changed returns true if the modification times are different.
+func changed(t0, t1 map[string]time.Time) bool {
+	if len(t0) != len(t1) {
+		return true
+	}
+	for k, v := range t0 {
+		if !v.Equal(t1[k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch calls fn when any of the files change.
+func watch(paths []string, poll time.Duration, fn func()) {
+	t0 := fileTimes(paths)
+	for {
+		time.Sleep(poll)
+		t1 := fileTimes(paths)
+		if changed(t0, t1) {
+			fn()
+		}
+		t0 = t1
+	}
+}
+
+// WatchFile returns a model function that loads a saved model (.json, .yaml).
+// Add the file to the watch list to rebuild the model when it changes.
+func WatchFile(path string) ModelFunc {
+	return func() (any, error) {
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		return sdf.LoadModel(path)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,316 @@
+//-----------------------------------------------------------------------------
+/*
+
+Kinematic Animation
+
+A mechanism is a function of time returning the posed model. The model can be
+an SDF2, an SDF3, a list of SDF2/SDF3 parts or an assembly (*sdf.Assembly).
+
+The animation can be saved as:
+
+* a PNG frame sequence (2d models, slices of 3d models or shaded 3d views)
+* a glTF binary (.glb) with a mesh per part and animated part transforms
+
+The parts can be checked for collisions at each frame, and between frames.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/deadsy/sdfx/sdf"
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	"github.com/deadsy/sdfx/vec/v2i"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// AnimationParms defines the parameters for an animation.
+type AnimationParms struct {
+	Frames int     // number of frames
+	Start  float64 // start time
+	End    float64 // end time, excluded (E.g. the period of a cyclic mechanism)
+	FPS    float64 // frames per second for playback (glTF), default 24
+}
+
+// posedPart is a part of a posed model.
+type posedPart struct {
+	name string
+	s2   sdf.SDF2 // 2d part
+	s3   sdf.SDF3 // 3d part
+	m    sdf.M44  // part to model transform (3d)
+}
+
+// placed returns a 3d part in model coordinates.
+func (p *posedPart) placed() sdf.SDF3 {
+	if p.m == sdf.Identity3d() {
+		return p.s3
+	}
+	return sdf.Transform3D(p.s3, p.m)
+}
+
+// pose returns the parts of a model.
+func pose(x any) ([]posedPart, error) {
+	switch s := x.(type) {
+	case *sdf.Assembly:
+		var parts []posedPart
+		for _, p := range s.Parts() {
+			parts = append(parts, posedPart{name: p.Name, s3: p.SDF, m: p.Matrix()})
+		}
+		return parts, nil
+	case sdf.SDF3:
+		return []posedPart{{name: "model", s3: s, m: sdf.Identity3d()}}, nil
+	case sdf.SDF2:
+		return []posedPart{{name: "model", s2: s}}, nil
+	case []sdf.SDF3:
+		parts := make([]posedPart, len(s))
+		for i := range s {
+			parts[i] = posedPart{name: fmt.Sprintf("part%d", i), s3: s[i], m: sdf.Identity3d()}
+		}
+		return parts, nil
+	case []sdf.SDF2:
+		parts := make([]posedPart, len(s))
+		for i := range s {
+			parts[i] = posedPart{name: fmt.Sprintf("part%d", i), s2: s[i]}
+		}
+		return parts, nil
+	}
+	return nil, fmt.Errorf("can't animate %T", x)
+}
+
+// Animation is a sequence of posed models.
+type Animation struct {
+	Times  []float64 // frame times
+	model  func(t float64) (any, error)
+	k      AnimationParms
+	frames [][]posedPart
+	is2d   bool
+}
+
+// NewAnimation evaluates a model function at the frame times.
+func NewAnimation(model func(t float64) (any, error), k *AnimationParms) (*Animation, error) {
+	if k.Frames <= 0 {
+		return nil, sdf.ErrMsg("frames <= 0")
+	}
+	if k.End <= k.Start {
+		return nil, sdf.ErrMsg("end <= start")
+	}
+	a := &Animation{
+		model: model,
+		k:     *k,
+	}
+	if a.k.FPS <= 0 {
+		a.k.FPS = 24
+	}
+	for i := 0; i < k.Frames; i++ {
+		t := a.time(float64(i))
+		parts, err := a.pose(t)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("t=%g: no parts", t)
+		}
+		is2d := parts[0].s2 != nil
+		if i == 0 {
+			a.is2d = is2d
+		} else if is2d != a.is2d {
+			return nil, fmt.Errorf("t=%g: mixed 2d and 3d models", t)
+		}
+		a.Times = append(a.Times, t)
+		a.frames = append(a.frames, parts)
+	}
+	return a, nil
+}
+
+// time returns the time for a (fractional) frame number.
+func (a *Animation) time(frame float64) float64 {
+	return a.k.Start + frame*(a.k.End-a.k.Start)/float64(a.k.Frames)
+}
+
+// pose evaluates and poses the model at time t.
+func (a *Animation) pose(t float64) ([]posedPart, error) {
+	x, err := a.model(t)
+	if err != nil {
+		return nil, fmt.Errorf("t=%g: %w", t, err)
+	}
+	parts, err := pose(x)
+	if err != nil {
+		return nil, fmt.Errorf("t=%g: %w", t, err)
+	}
+	return parts, nil
+}
+
+// Box3 returns the bounding box of a 3d animation over all frames.
+func (a *Animation) Box3() sdf.Box3 {
+	var bb sdf.Box3
+	for i, parts := range a.frames {
+		for j := range parts {
+			b := parts[j].placed().BoundingBox()
+			if i == 0 && j == 0 {
+				bb = b
+			} else {
+				bb = bb.Extend(b)
+			}
+		}
+	}
+	return bb
+}
+
+//-----------------------------------------------------------------------------
+// PNG Frames
+
+// FrameParms defines the images for PNG frames.
+type FrameParms struct {
+	Pixels v2i.Vec // image size
+	Camera *Camera // view for shaded 3d frames (nil for an isometric view of the animation)
+	SliceA v3.Vec  // point on the slice plane for 2d slices of 3d models
+	SliceN v3.Vec  // normal of the slice plane, zero for shaded 3d frames
+}
+
+// SavePNG saves the animation as a sequence of PNG files, <prefix>0000.png, <prefix>0001.png, ...
+// 2d models and slices of 3d models are rendered as gray scale distance fields.
+// 3d models are rendered as shaded views.
+func (a *Animation) SavePNG(prefix string, k *FrameParms) error {
+	if k.Pixels.X <= 0 || k.Pixels.Y <= 0 {
+		return sdf.ErrMsg("pixels <= 0")
+	}
+	if a.is2d || k.SliceN.Length() != 0 {
+		return a.savePNG2(prefix, k)
+	}
+	c := k.Camera
+	if c == nil {
+		c = &Camera{Box: a.Box3()}
+	}
+	for i, parts := range a.frames {
+		s := make([]sdf.SDF3, len(parts))
+		for j := range parts {
+			s[j] = parts[j].placed()
+		}
+		img := Shade(c, k.Pixels, s...)
+		if err := saveImage(a.framePath(prefix, i), img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// savePNG2 saves 2d frames.
+func (a *Animation) savePNG2(prefix string, k *FrameParms) error {
+	// the 2d model for each frame
+	models := make([]sdf.SDF2, len(a.frames))
+	var bb sdf.Box2
+	for i, parts := range a.frames {
+		s := make([]sdf.SDF2, len(parts))
+		for j := range parts {
+			if a.is2d {
+				s[j] = parts[j].s2
+			} else {
+				s[j] = sdf.Slice2D(parts[j].placed(), k.SliceA, k.SliceN)
+			}
+		}
+		models[i] = sdf.Union2D(s...)
+		if i == 0 {
+			bb = models[i].BoundingBox()
+		} else {
+			bb = bb.Extend(models[i].BoundingBox())
+		}
+	}
+	// fixed levels for all frames, a square pixel view of the whole animation
+	size := bb.Size().MaxComponent()
+	bb = fitBox2(bb.ScaleAboutCenter(1.05), k.Pixels)
+	for i, s := range models {
+		d, err := NewPNG(a.framePath(prefix, i), bb, k.Pixels)
+		if err != nil {
+			return err
+		}
+		d.RenderSDF2MinMax(s, -0.02*size, 0.25*size)
+		if err := d.Save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// framePath returns the filename of a frame.
+func (a *Animation) framePath(prefix string, i int) string {
+	return fmt.Sprintf("%s%04d.png", prefix, i)
+}
+
+// fitBox2 enlarges a box to the aspect ratio of an image.
+func fitBox2(bb sdf.Box2, pixels v2i.Vec) sdf.Box2 {
+	size := bb.Size()
+	k := math.Max(size.X/float64(pixels.X), size.Y/float64(pixels.Y))
+	return sdf.NewBox2(bb.Center(), v2.Vec{X: k * float64(pixels.X), Y: k * float64(pixels.Y)})
+}
+
+// saveImage writes an image to a PNG file.
+func saveImage(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//-----------------------------------------------------------------------------
+// Collisions
+
+// Collision is an interference between parts during an animation.
+type Collision struct {
+	Time float64
+	sdf.PartInterference
+}
+
+// Collisions checks the parts of a 3d animation for interference.
+// The model is checked at each frame and at steps-1 times between frames.
+func (a *Animation) Collisions(k *sdf.InterferenceParms, steps int) ([]Collision, error) {
+	if a.is2d {
+		return nil, sdf.ErrMsg("collision checks need 3d parts")
+	}
+	if steps < 1 {
+		return nil, sdf.ErrMsg("steps < 1")
+	}
+	var list []Collision
+	for i := range a.frames {
+		for j := 0; j < steps; j++ {
+			t := a.time(float64(i) + float64(j)/float64(steps))
+			parts := a.frames[i]
+			if j != 0 {
+				var err error
+				parts, err = a.pose(t)
+				if err != nil {
+					return nil, err
+				}
+			}
+			asm := sdf.NewAssembly()
+			for _, p := range parts {
+				if err := asm.Add(p.name, p.s3, p.m); err != nil {
+					return nil, fmt.Errorf("t=%g: %w", t, err)
+				}
+			}
+			x, err := asm.Interference(k)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range x {
+				list = append(list, Collision{t, c})
+			}
+		}
+	}
+	return list, nil
+}
+
+//-----------------------------------------------------------------------------
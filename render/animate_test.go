@@ -0,0 +1,156 @@
+//-----------------------------------------------------------------------------
+/*
+
+Animation Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+	"github.com/deadsy/sdfx/vec/v2i"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// testArm returns an arm swinging over a post. It hits the post at t = 0.5.
+func testArm(t *testing.T) func(x float64) (any, error) {
+	post, _ := sdf.Cylinder3D(20, 2, 0)
+	post = sdf.Transform3D(post, sdf.Translate3d(v3.Vec{10, 0, 0}))
+	box, _ := sdf.Box3D(v3.Vec{15, 2, 2}, 0)
+	arm, err := sdf.AddConnector(sdf.Transform3D(box, sdf.Translate3d(v3.Vec{7.5, 0, 0})),
+		sdf.Connector3{Name: "pivot", Vector: v3.Vec{0, 0, -1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := sdf.AddConnector(post, sdf.Connector3{Name: "pivot", Vector: v3.Vec{0, 0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := sdf.NewAssembly()
+	a.Add("post", base, sdf.Identity3d())
+	a.Mate("arm", arm, "pivot", "post", "pivot", 0)
+	return func(x float64) (any, error) {
+		// swing from -180 to +180 degrees
+		if err := a.SetAngle("arm", sdf.Tau*(x-0.5)); err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+}
+
+func Test_Animation(t *testing.T) {
+	k := &AnimationParms{Frames: 8, End: 1}
+	a, err := NewAnimation(testArm(t), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Times) != 8 || a.Times[4] != 0.5 {
+		t.Fatalf("bad frame times %v", a.Times)
+	}
+
+	// the arm only hits the post at frame 4
+	c, err := a.Collisions(&sdf.InterferenceParms{Cells: 20}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c) != 1 || c[0].Time != 0.5 {
+		t.Fatalf("expected a collision at t = 0.5, got %v", c)
+	}
+	// with steps between frames there are collisions either side of frame 4
+	c, err = a.Collisions(&sdf.InterferenceParms{Cells: 20}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c) < 3 {
+		t.Fatalf("expected more collisions, got %v", c)
+	}
+
+	dir := t.TempDir()
+	// shaded frames
+	if err := a.SavePNG(filepath.Join(dir, "shade"), &FrameParms{Pixels: v2i.Vec{64, 48}}); err != nil {
+		t.Fatal(err)
+	}
+	// sliced frames
+	if err := a.SavePNG(filepath.Join(dir, "slice"), &FrameParms{Pixels: v2i.Vec{64, 64}, SliceN: v3.Vec{0, 0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"shade0007.png", "slice0000.png"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := png.Decode(f); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		f.Close()
+	}
+
+	// glTF binary
+	path := filepath.Join(dir, "arm.glb")
+	if err := a.SaveGLB(path, NewMarchingCubesUniform(20)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary.LittleEndian.Uint32(b) != glbMagic || int(binary.LittleEndian.Uint32(b[8:])) != len(b) {
+		t.Fatal("bad glb header")
+	}
+	var doc gltfDoc
+	n := binary.LittleEndian.Uint32(b[12:])
+	if err := json.Unmarshal(b[20:20+n], &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Meshes) != 2 || len(doc.Animations) != 1 || len(doc.Animations[0].Channels) != 6 {
+		t.Errorf("expected 2 animated meshes, got %d meshes %d animations", len(doc.Meshes), len(doc.Animations))
+	}
+	// the arm is at the final rotation in the last frame
+	ch := doc.Animations[0].Channels[4]
+	out := doc.Accessors[doc.Animations[0].Samplers[ch.Sampler].Output]
+	view := doc.BufferViews[out.BufferView]
+	bin := b[20+n+8:]
+	q := make([]float32, 4)
+	binary.Read(bytes.NewReader(bin[view.ByteOffset+16*7:]), binary.LittleEndian, q)
+	angle := sdf.Tau * (7.0/8 - 0.5)
+	if math.Abs(math.Abs(float64(q[3]))-math.Abs(math.Cos(angle/2))) > 1e-4 {
+		t.Errorf("unexpected rotation %v", q)
+	}
+
+	// shapes that change between frames
+	k.Frames = 4
+	a, err = NewAnimation(func(x float64) (any, error) { return sdf.Sphere3D(1 + x) }, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SaveGLB(path, NewMarchingCubesUniform(10)); err != nil {
+		t.Fatal(err)
+	}
+
+	// bad models
+	if _, err := NewAnimation(func(x float64) (any, error) { return 1, nil }, k); err == nil {
+		t.Error("expected an error for a bad model")
+	}
+	a, err = NewAnimation(func(x float64) (any, error) { return sdf.Circle2D(1 + x) }, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SaveGLB(path, NewMarchingCubesUniform(10)); err == nil {
+		t.Error("expected an error for a 2d glb")
+	}
+}
+
+//-----------------------------------------------------------------------------
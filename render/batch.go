@@ -0,0 +1,407 @@
+//-----------------------------------------------------------------------------
+/*
+
+Batch Builds
+
+Build many variants of a model in parallel.
+
+Each variant is hashed from its parameters, the SDF tree it builds and
+the rendering method. The hashes are kept in a manifest in the output
+directory, and a variant is only rendered again when its hash changes or
+its output file is missing or modified.
+
+Opaque SDF nodes (E.g. blend functions) can't be hashed from the tree, so
+changes to them are only detected through the variant parameters.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+// ManifestName is the filename of the batch manifest in the output directory.
+const ManifestName = "manifest.json"
+
+// Variant is a named parameter set for a model.
+type Variant[T any] struct {
+	Name  string // output filename (without extension)
+	Parms T      // model parameters
+}
+
+// BatchParms defines the rendering and output of a batch build.
+type BatchParms struct {
+	Dir     string  // output directory
+	Ext     string  // output file extension (.stl, .3mf, .dxf, .svg)
+	Render3 Render3 // rendering method for SDF3 models
+	Render2 Render2 // rendering method for SDF2 models
+	Workers int     // number of variants built in parallel (0 = number of CPUs)
+	Force   bool    // render all variants, ignoring the manifest
+	Quiet   bool    // no progress output
+}
+
+// ManifestEntry is the manifest record for a variant.
+type ManifestEntry struct {
+	Name   string          `json:"name"`
+	File   string          `json:"file"`
+	Parms  json.RawMessage `json:"parms,omitempty"`
+	Hash   string          `json:"hash"`             // sha256 of the parameters, SDF tree and rendering method
+	Sha1   string          `json:"sha1"`             // sha1 of the output file
+	Opaque bool            `json:"opaque,omitempty"` // the SDF tree has opaque nodes
+	Built  bool            `json:"-"`                // rendered by this build
+	Err    error           `json:"-"`                // build error
+}
+
+// Manifest records the outputs of a batch build.
+type Manifest struct {
+	Entries []*ManifestEntry `json:"entries"`
+}
+
+//-----------------------------------------------------------------------------
+
+// LoadManifest loads a batch manifest.
+func LoadManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return m, nil
+}
+
+// Save writes the manifest to a file.
+func (m *Manifest) Save(path string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Lookup returns the manifest entry for a variant name (nil if not found).
+func (m *Manifest) Lookup(name string) *ManifestEntry {
+	for _, e := range m.Entries {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// Batch builds and renders variants of a model in parallel.
+// The model function returns an SDF2 or SDF3 for a parameter set.
+// The manifest is written for the variants that were built without error.
+func Batch[T any](k *BatchParms, model func(k *T) (any, error), variants []Variant[T]) (*Manifest, error) {
+	if k.Ext == "" {
+		return nil, sdf.ErrMsg("no output file extension")
+	}
+	names := map[string]bool{}
+	for _, v := range variants {
+		if v.Name == "" || names[v.Name] {
+			return nil, sdf.ErrMsg(fmt.Sprintf("bad variant name \"%s\"", v.Name))
+		}
+		names[v.Name] = true
+	}
+	if err := os.MkdirAll(k.Dir, 0755); err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(k.Dir, ManifestName)
+	old, err := LoadManifest(manifestPath)
+	if err != nil {
+		old = &Manifest{}
+	}
+
+	workers := k.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	m := &Manifest{Entries: make([]*ManifestEntry, len(variants))}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				m.Entries[j] = buildVariant(k, model, &variants[j], old.Lookup(variants[j].Name))
+			}
+		}()
+	}
+	for i := range variants {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// write the manifest and report the errors
+	var errs []error
+	ok := &Manifest{}
+	for _, e := range m.Entries {
+		if e.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name, e.Err))
+			continue
+		}
+		ok.Entries = append(ok.Entries, e)
+	}
+	if err := ok.Save(manifestPath); err != nil {
+		errs = append(errs, err)
+	}
+	return m, errors.Join(errs...)
+}
+
+// buildVariant builds a variant, skipping the render if the output is up to date.
+func buildVariant[T any](k *BatchParms, model func(k *T) (any, error), v *Variant[T], old *ManifestEntry) *ManifestEntry {
+	e := &ManifestEntry{
+		Name: v.Name,
+		File: v.Name + k.Ext,
+	}
+	e.Parms, e.Err = json.Marshal(v.Parms)
+	if e.Err != nil {
+		return e
+	}
+	parms := v.Parms
+	s, err := model(&parms)
+	if err != nil {
+		e.Err = err
+		return e
+	}
+	var info string
+	switch x := s.(type) {
+	case sdf.SDF3:
+		if k.Render3 == nil {
+			e.Err = sdf.ErrMsg("no SDF3 renderer")
+			return e
+		}
+		info = k.Render3.Info(x)
+	case sdf.SDF2:
+		if k.Render2 == nil {
+			e.Err = sdf.ErrMsg("no SDF2 renderer")
+			return e
+		}
+		info = k.Render2.Info(x)
+	default:
+		e.Err = fmt.Errorf("model returned %T", s)
+		return e
+	}
+	e.Hash, e.Opaque = variantHash(e.Parms, s, k.Ext, info)
+
+	path := filepath.Join(k.Dir, e.File)
+	if !k.Force && old != nil && old.Hash == e.Hash {
+		if sum, err := fileSha1(path); err == nil && sum == old.Sha1 {
+			e.Sha1 = sum
+			return e
+		}
+	}
+
+	if !k.Quiet {
+		fmt.Printf("rendering %s (%s)\n", path, info)
+	}
+	switch x := s.(type) {
+	case sdf.SDF3:
+		e.Err = saveSDF3(path, x, k.Render3)
+	case sdf.SDF2:
+		e.Err = saveSDF2(path, x, k.Render2)
+	}
+	if e.Err != nil {
+		return e
+	}
+	e.Built = true
+	e.Sha1, e.Err = fileSha1(path)
+	return e
+}
+
+//-----------------------------------------------------------------------------
+
+// saveSDF3 renders an SDF3 to a file, the format is given by the file extension.
+func saveSDF3(path string, s sdf.SDF3, r Render3) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".stl":
+		return SaveSTL(path, ToTriangles(s, r))
+	case ".3mf":
+		var wg sync.WaitGroup
+		output, err := write3MF(&wg, path)
+		if err != nil {
+			return err
+		}
+		r.Render(s, sdf.NewTriangle3Buffer(output))
+		close(output)
+		wg.Wait()
+		return nil
+	}
+	return fmt.Errorf("%s: unsupported SDF3 output format", path)
+}
+
+// saveSDF2 renders an SDF2 to a file, the format is given by the file extension.
+func saveSDF2(path string, s sdf.SDF2, r Render2) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dxf":
+		return SaveDXF(path, ToLines(s, r))
+	case ".svg":
+		return SaveSVG(path, svgLineStyle, ToLines(s, r))
+	}
+	return fmt.Errorf("%s: unsupported SDF2 output format", path)
+}
+
+// fileSha1 returns the sha1 hash of a file.
+func fileSha1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// variantHash returns the hash of a variant.
+// It also reports if the SDF tree has opaque nodes.
+func variantHash(parms []byte, s any, ext, info string) (string, bool) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", parms, ext, info)
+	opaque := false
+	sdf.Walk(s, func(s any, n *sdf.Node, depth int) bool {
+		if n.Opaque != "" {
+			opaque = true
+		}
+		b, err := json.Marshal(struct {
+			Depth  int
+			Kind   string
+			Parms  map[string]any
+			Opaque string
+		}{depth, n.Kind, n.Parms, n.Opaque})
+		if err != nil {
+			b = []byte(fmt.Sprintf("%d %s", depth, n))
+		}
+		h.Write(append(b, '\n'))
+		return true
+	})
+	return hex.EncodeToString(h.Sum(nil)), opaque
+}
+
+//-----------------------------------------------------------------------------
+
+// GridAxis is a set of values for a field of a parameter struct.
+type GridAxis struct {
+	Field  string // field name, nested fields are dotted (E.g. "Size.X")
+	Values []any  // field values
+}
+
+// Grid returns the variants for all combinations of the axis values.
+// Each variant is a copy of the base parameters with the axis fields set.
+// The variant names are the name prefix and the axis values joined with "_".
+func Grid[T any](name string, base T, axes ...GridAxis) ([]Variant[T], error) {
+	n := 1
+	for _, a := range axes {
+		if len(a.Values) == 0 {
+			return nil, sdf.ErrMsg(fmt.Sprintf("no values for field \"%s\"", a.Field))
+		}
+		n *= len(a.Values)
+	}
+	variants := make([]Variant[T], 0, n)
+	idx := make([]int, len(axes))
+	for i := 0; i < n; i++ {
+		v := Variant[T]{Name: name, Parms: base}
+		for j, a := range axes {
+			x := a.Values[idx[j]]
+			if err := setField(&v.Parms, a.Field, x); err != nil {
+				return nil, err
+			}
+			v.Name += "_" + gridLabel(reflect.ValueOf(x))
+		}
+		variants = append(variants, v)
+		// next combination, the last axis varies fastest
+		for j := len(axes) - 1; j >= 0; j-- {
+			idx[j]++
+			if idx[j] < len(axes[j].Values) {
+				break
+			}
+			idx[j] = 0
+		}
+	}
+	return variants, nil
+}
+
+// setField sets a (dotted) field of a struct.
+// Pointers on the field path are copied so the base parameters are unchanged.
+func setField(k any, field string, x any) error {
+	v := reflect.ValueOf(k).Elem()
+	for _, name := range strings.Split(field, ".") {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			} else {
+				p := reflect.New(v.Type().Elem())
+				p.Elem().Set(v.Elem())
+				v.Set(p)
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return sdf.ErrMsg(fmt.Sprintf("\"%s\" is not a struct field", field))
+		}
+		v = v.FieldByName(name)
+		if !v.IsValid() || !v.CanSet() {
+			return sdf.ErrMsg(fmt.Sprintf("no field \"%s\"", field))
+		}
+	}
+	xv := reflect.ValueOf(x)
+	if !xv.IsValid() || !xv.Type().ConvertibleTo(v.Type()) {
+		return sdf.ErrMsg(fmt.Sprintf("can't set field \"%s\" (%s) to %T", field, v.Type(), x))
+	}
+	v.Set(xv.Convert(v.Type()))
+	return nil
+}
+
+// gridLabel returns the variant name label for a value.
+func gridLabel(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Array, reflect.Slice:
+		s := make([]string, v.Len())
+		for i := range s {
+			s[i] = gridLabel(v.Index(i))
+		}
+		return strings.Join(s, "x")
+	case reflect.Struct:
+		s := make([]string, v.NumField())
+		for i := range s {
+			s[i] = gridLabel(v.Field(i))
+		}
+		return strings.Join(s, "x")
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return gridLabel(v.Elem())
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,107 @@
+//-----------------------------------------------------------------------------
+/*
+
+Batch Build Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+	"github.com/deadsy/sdfx/vec/v3i"
+)
+
+//-----------------------------------------------------------------------------
+
+type testBoxParms struct {
+	Size  v3i.Vec
+	Round float64
+}
+
+func testBox(k *testBoxParms) (any, error) {
+	return sdf.Box3D(v3.Vec{float64(k.Size.X), float64(k.Size.Y), float64(k.Size.Z)}.MulScalar(10), k.Round)
+}
+
+func Test_Batch(t *testing.T) {
+	variants, err := Grid("box", testBoxParms{Size: v3i.Vec{1, 1, 1}},
+		GridAxis{"Size.X", []any{1, 2}},
+		GridAxis{"Size.Z", []any{1, 2, 3}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 6 || variants[5].Name != "box_2_3" || variants[5].Parms.Size != (v3i.Vec{2, 1, 3}) {
+		t.Fatalf("bad grid %v", variants)
+	}
+
+	k := &BatchParms{
+		Dir:     t.TempDir(),
+		Ext:     ".stl",
+		Render3: NewMarchingCubesUniform(10),
+		Quiet:   true,
+	}
+	built := func(m *Manifest) int {
+		n := 0
+		for _, e := range m.Entries {
+			if e.Built {
+				n++
+			}
+		}
+		return n
+	}
+
+	// first build renders everything
+	m, err := Batch(k, testBox, variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if built(m) != 6 {
+		t.Fatalf("expected 6 builds, got %d", built(m))
+	}
+
+	// nothing has changed
+	m, err = Batch(k, testBox, variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if built(m) != 0 {
+		t.Fatalf("expected 0 builds, got %d", built(m))
+	}
+
+	// changed parameters, deleted output, changed renderer
+	variants[0].Parms.Round = 1
+	os.Remove(filepath.Join(k.Dir, "box_1_2.stl"))
+	m, err = Batch(k, testBox, variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if built(m) != 2 || !m.Entries[0].Built || !m.Entries[1].Built {
+		t.Fatalf("expected 2 builds, got %d", built(m))
+	}
+	k.Render3 = NewMarchingCubesUniform(12)
+	m, err = Batch(k, testBox, variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if built(m) != 6 {
+		t.Fatalf("expected 6 builds, got %d", built(m))
+	}
+
+	// the manifest has all of the outputs
+	m, err = LoadManifest(filepath.Join(k.Dir, ManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 6 || m.Entries[5].File != "box_2_3.stl" || m.Entries[5].Sha1 == "" {
+		t.Fatalf("bad manifest %v", m.Entries)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,46 @@
+//-----------------------------------------------------------------------------
+/*
+
+CSV Output
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+// SaveCamCSV writes cam follower samples to a CSV file.
+// Angles are in degrees, velocity and acceleration are per degree of cam rotation.
+func SaveCamCSV(path string, samples []sdf.CamSample) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"angle", "displacement", "velocity", "acceleration", "pressure_angle"})
+	k := sdf.DtoR(1)
+	for _, s := range samples {
+		w.Write([]string{
+			strconv.FormatFloat(sdf.RtoD(s.Angle), 'f', -1, 64),
+			strconv.FormatFloat(s.Displacement, 'g', -1, 64),
+			strconv.FormatFloat(s.Velocity*k, 'g', -1, 64),
+			strconv.FormatFloat(s.Acceleration*k*k, 'g', -1, 64),
+			strconv.FormatFloat(sdf.RtoD(s.PressureAngle), 'g', -1, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+//-----------------------------------------------------------------------------
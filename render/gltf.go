@@ -0,0 +1,388 @@
+//-----------------------------------------------------------------------------
+/*
+
+glTF Binary (.glb) Animation Output
+
+Each part is meshed once and animated with its transform at each frame. A part
+whose shape changes between frames gets a mesh per shape, the meshes are
+shown/hidden with a stepped scale.
+
+The sdfx z-up coordinates are rotated to the glTF y-up coordinates by the
+root node.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"reflect"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// glTF document, the subset of the schema needed here.
+
+type gltfDoc struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes,omitempty"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Animations  []gltfAnimation  `json:"animations,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors,omitempty"`
+	BufferViews []gltfBufferView `json:"bufferViews,omitempty"`
+	Buffers     []gltfBuffer     `json:"buffers,omitempty"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name     string    `json:"name,omitempty"`
+	Mesh     *int      `json:"mesh,omitempty"`
+	Children []int     `json:"children,omitempty"`
+	Rotation []float64 `json:"rotation,omitempty"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Material   int            `json:"material"`
+}
+
+type gltfMaterial struct {
+	Name string  `json:"name"`
+	PBR  gltfPBR `json:"pbrMetallicRoughness"`
+}
+
+type gltfPBR struct {
+	BaseColor []float64 `json:"baseColorFactor"`
+	Metallic  float64   `json:"metallicFactor"`
+	Roughness float64   `json:"roughnessFactor"`
+}
+
+type gltfAnimation struct {
+	Channels []gltfChannel `json:"channels"`
+	Samplers []gltfSampler `json:"samplers"`
+}
+
+type gltfChannel struct {
+	Sampler int        `json:"sampler"`
+	Target  gltfTarget `json:"target"`
+}
+
+type gltfTarget struct {
+	Node int    `json:"node"`
+	Path string `json:"path"`
+}
+
+type gltfSampler struct {
+	Input         int    `json:"input"`
+	Output        int    `json:"output"`
+	Interpolation string `json:"interpolation"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+const (
+	gltfFloat        = 5126
+	gltfArrayBuffer  = 34962
+	glbMagic         = 0x46546c67 // "glTF"
+	glbChunkJSON     = 0x4e4f534a // "JSON"
+	glbChunkBIN      = 0x004e4942 // "BIN\0"
+	gltfTypeScalar   = "SCALAR"
+	gltfTypeVec3     = "VEC3"
+	gltfTypeVec4     = "VEC4"
+	gltfInterpLinear = "LINEAR"
+	gltfInterpStep   = "STEP"
+)
+
+// gltfWriter builds a glTF document and its binary buffer.
+type gltfWriter struct {
+	doc gltfDoc
+	bin bytes.Buffer
+}
+
+// addFloats adds float data to the buffer and returns its accessor.
+func (w *gltfWriter) addFloats(x []float32, typ string, target int, bounds bool) int {
+	n := map[string]int{gltfTypeScalar: 1, gltfTypeVec3: 3, gltfTypeVec4: 4}[typ]
+	offset := w.bin.Len()
+	binary.Write(&w.bin, binary.LittleEndian, x)
+	w.doc.BufferViews = append(w.doc.BufferViews, gltfBufferView{
+		ByteOffset: offset,
+		ByteLength: 4 * len(x),
+		Target:     target,
+	})
+	a := gltfAccessor{
+		BufferView:    len(w.doc.BufferViews) - 1,
+		ComponentType: gltfFloat,
+		Count:         len(x) / n,
+		Type:          typ,
+	}
+	if bounds {
+		a.Min = make([]float32, n)
+		a.Max = make([]float32, n)
+		for i := 0; i < n; i++ {
+			a.Min[i] = float32(math.Inf(1))
+			a.Max[i] = float32(math.Inf(-1))
+		}
+		for i, v := range x {
+			a.Min[i%n] = min(a.Min[i%n], v)
+			a.Max[i%n] = max(a.Max[i%n], v)
+		}
+	}
+	w.doc.Accessors = append(w.doc.Accessors, a)
+	return len(w.doc.Accessors) - 1
+}
+
+// addMesh adds a triangle mesh and returns its mesh index.
+func (w *gltfWriter) addMesh(mesh []*sdf.Triangle3, material int) int {
+	pos := make([]float32, 0, 9*len(mesh))
+	nrm := make([]float32, 0, 9*len(mesh))
+	for _, t := range mesh {
+		n := t.Normal()
+		for _, v := range t {
+			pos = append(pos, float32(v.X), float32(v.Y), float32(v.Z))
+			nrm = append(nrm, float32(n.X), float32(n.Y), float32(n.Z))
+		}
+	}
+	p := w.addFloats(pos, gltfTypeVec3, gltfArrayBuffer, true)
+	n := w.addFloats(nrm, gltfTypeVec3, gltfArrayBuffer, false)
+	w.doc.Meshes = append(w.doc.Meshes, gltfMesh{Primitives: []gltfPrimitive{{
+		Attributes: map[string]int{"POSITION": p, "NORMAL": n},
+		Material:   material,
+	}}})
+	return len(w.doc.Meshes) - 1
+}
+
+// write writes the glb file.
+func (w *gltfWriter) write(path string) error {
+	w.doc.Buffers = []gltfBuffer{{ByteLength: w.bin.Len()}}
+	js, err := json.Marshal(&w.doc)
+	if err != nil {
+		return err
+	}
+	// chunks are 4 byte aligned
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	for w.bin.Len()%4 != 0 {
+		w.bin.WriteByte(0)
+	}
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, []uint32{glbMagic, 2, uint32(12 + 8 + len(js) + 8 + w.bin.Len())})
+	binary.Write(&b, binary.LittleEndian, []uint32{uint32(len(js)), glbChunkJSON})
+	b.Write(js)
+	binary.Write(&b, binary.LittleEndian, []uint32{uint32(w.bin.Len()), glbChunkBIN})
+	b.Write(w.bin.Bytes())
+	return os.WriteFile(path, b.Bytes(), 0644)
+}
+
+//-----------------------------------------------------------------------------
+
+// decompose returns the translation, rotation (quaternion x, y, z, w) and scale of a transform.
+func decompose(m sdf.M44) (v3.Vec, [4]float64, v3.Vec) {
+	t := v3.Vec{X: m[3], Y: m[7], Z: m[11]}
+	c := [3]v3.Vec{
+		{X: m[0], Y: m[4], Z: m[8]},
+		{X: m[1], Y: m[5], Z: m[9]},
+		{X: m[2], Y: m[6], Z: m[10]},
+	}
+	s := v3.Vec{X: c[0].Length(), Y: c[1].Length(), Z: c[2].Length()}
+	if m.Determinant() < 0 {
+		s.X = -s.X
+	}
+	for i, k := range []float64{s.X, s.Y, s.Z} {
+		if k != 0 {
+			c[i] = c[i].DivScalar(k)
+		}
+	}
+	// rotation matrix to quaternion
+	r00, r11, r22 := c[0].X, c[1].Y, c[2].Z
+	var q [4]float64
+	if tr := r00 + r11 + r22; tr > 0 {
+		k := 0.5 / math.Sqrt(tr+1)
+		q = [4]float64{(c[1].Z - c[2].Y) * k, (c[2].X - c[0].Z) * k, (c[0].Y - c[1].X) * k, 0.25 / k}
+	} else if r00 > r11 && r00 > r22 {
+		k := 2 * math.Sqrt(1+r00-r11-r22)
+		q = [4]float64{0.25 * k, (c[1].X + c[0].Y) / k, (c[2].X + c[0].Z) / k, (c[1].Z - c[2].Y) / k}
+	} else if r11 > r22 {
+		k := 2 * math.Sqrt(1+r11-r00-r22)
+		q = [4]float64{(c[1].X + c[0].Y) / k, 0.25 * k, (c[2].Y + c[1].Z) / k, (c[2].X - c[0].Z) / k}
+	} else {
+		k := 2 * math.Sqrt(1+r22-r00-r11)
+		q = [4]float64{(c[2].X + c[0].Z) / k, (c[2].Y + c[1].Z) / k, 0.25 * k, (c[0].Y - c[1].X) / k}
+	}
+	return t, q, s
+}
+
+// gltfTrack is an animated glTF node for a part shape.
+type gltfTrack struct {
+	part    string
+	s       sdf.SDF3
+	visible []bool
+	m       []sdf.M44
+}
+
+// sameSDF3 returns true if two SDF3s are the same object.
+func sameSDF3(a, b sdf.SDF3) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.TypeOf(a).Comparable() && a == b
+}
+
+// SaveGLB saves a 3d animation as a glTF binary file.
+// Each part shape is rendered once with the 3d renderer.
+func (a *Animation) SaveGLB(path string, r Render3) error {
+	if a.is2d {
+		return sdf.ErrMsg("glTF output needs 3d parts")
+	}
+	// a track for each part shape
+	var tracks []*gltfTrack
+	parts := map[string]int{} // part colors
+	for i, frame := range a.frames {
+		for _, p := range frame {
+			var tr *gltfTrack
+			for _, x := range tracks {
+				if x.part == p.name && sameSDF3(x.s, p.s3) {
+					tr = x
+					break
+				}
+			}
+			if tr == nil {
+				tr = &gltfTrack{
+					part:    p.name,
+					s:       p.s3,
+					visible: make([]bool, len(a.frames)),
+					m:       make([]sdf.M44, len(a.frames)),
+				}
+				tracks = append(tracks, tr)
+			}
+			if _, ok := parts[p.name]; !ok {
+				parts[p.name] = len(parts)
+			}
+			tr.visible[i] = true
+			tr.m[i] = p.m
+		}
+	}
+
+	w := &gltfWriter{}
+	w.doc.Asset = gltfAsset{Version: "2.0", Generator: "sdfx"}
+	// root node: z-up to y-up
+	root := gltfNode{Name: "root", Rotation: []float64{-math.Sqrt2 / 2, 0, 0, math.Sqrt2 / 2}}
+	w.doc.Nodes = append(w.doc.Nodes, root)
+	w.doc.Scenes = []gltfScene{{Nodes: []int{0}}}
+	// part materials
+	w.doc.Materials = make([]gltfMaterial, len(parts))
+	for name, i := range parts {
+		c := partColor(i)
+		w.doc.Materials[i] = gltfMaterial{
+			Name: name,
+			PBR: gltfPBR{
+				BaseColor: []float64{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255, 1},
+				Roughness: 0.8,
+			},
+		}
+	}
+	// frame times
+	times := make([]float32, len(a.frames))
+	for i := range times {
+		times[i] = float32(float64(i) / a.k.FPS)
+	}
+	input := w.addFloats(times, gltfTypeScalar, 0, true)
+
+	anim := gltfAnimation{}
+	for _, tr := range tracks {
+		mesh := ToTriangles(tr.s, r)
+		if len(mesh) == 0 {
+			continue
+		}
+		m := w.addMesh(mesh, parts[tr.part])
+		w.doc.Nodes = append(w.doc.Nodes, gltfNode{Name: tr.part, Mesh: &m})
+		node := len(w.doc.Nodes) - 1
+		w.doc.Nodes[0].Children = append(w.doc.Nodes[0].Children, node)
+
+		// hidden frames keep the nearest visible transform
+		last := -1
+		for i := range tr.visible {
+			if tr.visible[i] {
+				last = i
+				break
+			}
+		}
+		var tv, rv, sv []float32
+		var prev [4]float64
+		for i := range tr.visible {
+			if tr.visible[i] {
+				last = i
+			}
+			t, q, s := decompose(tr.m[last])
+			// keep the quaternions on one hemisphere for interpolation
+			if i != 0 && q[0]*prev[0]+q[1]*prev[1]+q[2]*prev[2]+q[3]*prev[3] < 0 {
+				q = [4]float64{-q[0], -q[1], -q[2], -q[3]}
+			}
+			prev = q
+			if !tr.visible[i] {
+				s = v3.Vec{}
+			}
+			tv = append(tv, float32(t.X), float32(t.Y), float32(t.Z))
+			rv = append(rv, float32(q[0]), float32(q[1]), float32(q[2]), float32(q[3]))
+			sv = append(sv, float32(s.X), float32(s.Y), float32(s.Z))
+		}
+		for _, x := range []struct {
+			path   string
+			data   []float32
+			typ    string
+			interp string
+		}{
+			{"translation", tv, gltfTypeVec3, gltfInterpLinear},
+			{"rotation", rv, gltfTypeVec4, gltfInterpLinear},
+			{"scale", sv, gltfTypeVec3, gltfInterpStep},
+		} {
+			output := w.addFloats(x.data, x.typ, 0, false)
+			anim.Samplers = append(anim.Samplers, gltfSampler{Input: input, Output: output, Interpolation: x.interp})
+			anim.Channels = append(anim.Channels, gltfChannel{Sampler: len(anim.Samplers) - 1, Target: gltfTarget{Node: node, Path: x.path}})
+		}
+	}
+	if len(anim.Channels) != 0 {
+		w.doc.Animations = []gltfAnimation{anim}
+	}
+	return w.write(path)
+}
+
+//-----------------------------------------------------------------------------
@@ -50,6 +50,25 @@ func ToTriangles(
 	return triangles
 }
 
+// ToLines renders an SDF2 to a set of line segments.
+func ToLines(
+	s sdf.SDF2, // sdf2 to render
+	r Render2, // rendering method
+) []*sdf.Line2 {
+	lines := make([]*sdf.Line2, 0)
+	var wg sync.WaitGroup
+	// To write the lines.
+	output := sdf.WriteLines(&wg, &lines)
+	// Run the renderer.
+	r.Render(s, sdf.NewLine2Buffer(output))
+	// Stop the writer reading on the channel.
+	close(output)
+	// Wait for the write to complete.
+	wg.Wait()
+	// return all the lines
+	return lines
+}
+
 //-----------------------------------------------------------------------------
 
 // ToSTL renders an SDF3 to an STL file.
@@ -100,6 +119,30 @@ func To3MF(
 
 //-----------------------------------------------------------------------------
 
+// AssemblyToSTL renders each part of an assembly to its own STL file, <prefix><part name>.stl.
+// Parts are rendered in part coordinates (E.g. for printing) or placed as in the assembly.
+func AssemblyToSTL(
+	a *sdf.Assembly, // assembly to render
+	prefix string, // filename prefix (E.g. a directory)
+	r Render3, // rendering method
+	placed bool, // render the parts in assembly coordinates
+) error {
+	for _, p := range a.Parts() {
+		s := p.SDF
+		if placed {
+			s = p.Placed()
+		}
+		path := prefix + p.Name + ".stl"
+		fmt.Printf("rendering %s (%s)\n", path, r.Info(s))
+		if err := SaveSTL(path, ToTriangles(s, r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
 // ToDXF renders an SDF2 to a DXF file.
 func ToDXF(
 	s sdf.SDF2, // sdf2 to render
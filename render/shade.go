@@ -0,0 +1,147 @@
+//-----------------------------------------------------------------------------
+/*
+
+Shaded 3D Previews
+
+Render a shaded image of SDF3 parts by ray marching an orthographic view.
+It's intended for quick previews (E.g. animation frames) rather than
+presentation quality images.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/deadsy/sdfx/sdf"
+	"github.com/deadsy/sdfx/vec/v2i"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// partColors are the colors of the parts in shaded images and glTF files.
+var partColors = []color.RGBA{
+	{0x4e, 0x79, 0xa7, 0xff},
+	{0xf2, 0x8e, 0x2b, 0xff},
+	{0x59, 0xa1, 0x4f, 0xff},
+	{0xe1, 0x57, 0x59, 0xff},
+	{0x76, 0xb7, 0xb2, 0xff},
+	{0xed, 0xc9, 0x48, 0xff},
+	{0xb0, 0x7a, 0xa1, 0xff},
+	{0x9c, 0x75, 0x5f, 0xff},
+}
+
+// partColor returns the color for the i-th part.
+func partColor(i int) color.RGBA {
+	return partColors[i%len(partColors)]
+}
+
+//-----------------------------------------------------------------------------
+
+// Camera is an orthographic view of a 3d region.
+type Camera struct {
+	Box       sdf.Box3 // region to view
+	Direction v3.Vec   // view direction, zero for an isometric view
+	Up        v3.Vec   // image up direction, zero for +z (+y when viewing along z)
+}
+
+// basis returns the right, up and forward directions of the camera.
+func (c *Camera) basis() (v3.Vec, v3.Vec, v3.Vec) {
+	f := c.Direction
+	if f.Length() == 0 {
+		f = v3.Vec{X: -1, Y: 1, Z: -1}
+	}
+	f = f.Normalize()
+	up := c.Up
+	if up.Length() == 0 {
+		up = v3.Vec{Z: 1}
+		if math.Abs(f.Z) > 0.99 {
+			up = v3.Vec{Y: 1}
+		}
+	}
+	r := f.Cross(up).Normalize()
+	return r, r.Cross(f), f
+}
+
+// Shade renders a shaded image of SDF3 parts.
+// Each part has its own color.
+func Shade(c *Camera, pixels v2i.Vec, parts ...sdf.SDF3) *image.RGBA {
+	r, u, f := c.basis()
+	// view extents in camera coordinates
+	lo := v3.Vec{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1)}
+	hi := lo.Neg()
+	for _, p := range c.Box.Vertices() {
+		q := v3.Vec{X: p.Dot(r), Y: p.Dot(u), Z: p.Dot(f)}
+		lo = lo.Min(q)
+		hi = hi.Max(q)
+	}
+	// square pixels with the view centered in the image
+	pixel := math.Max((hi.X-lo.X)/float64(pixels.X), (hi.Y-lo.Y)/float64(pixels.Y))
+	if pixel == 0 {
+		pixel = 1
+	}
+	center := lo.Add(hi).MulScalar(0.5)
+	eps := 0.5 * pixel
+	light := f.Neg().Add(u.MulScalar(0.6)).Add(r.MulScalar(-0.4)).Normalize()
+	background := color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+
+	img := image.NewRGBA(image.Rect(0, 0, pixels.X, pixels.Y))
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range rows {
+				y := center.Y + (0.5*float64(pixels.Y)-float64(j)-0.5)*pixel
+				for i := 0; i < pixels.X; i++ {
+					x := center.X + (float64(i)+0.5-0.5*float64(pixels.X))*pixel
+					o := r.MulScalar(x).Add(u.MulScalar(y))
+					col := background
+					// march the ray through the view
+					for t, n := lo.Z, 0; t <= hi.Z && n < 1024; n++ {
+						p := o.Add(f.MulScalar(t))
+						d, k := math.Inf(1), 0
+						for idx, s := range parts {
+							if e := s.Evaluate(p); e < d {
+								d, k = e, idx
+							}
+						}
+						if d < eps {
+							l := math.Max(0, sdf.Normal3(parts[k], p, eps).Dot(light))
+							col = shadeColor(partColor(k), 0.3+0.7*l)
+							break
+						}
+						t += math.Max(d, eps)
+					}
+					img.SetRGBA(i, j, col)
+				}
+			}
+		}()
+	}
+	for j := 0; j < pixels.Y; j++ {
+		rows <- j
+	}
+	close(rows)
+	wg.Wait()
+	return img
+}
+
+// shadeColor scales a color by a brightness.
+func shadeColor(c color.RGBA, k float64) color.RGBA {
+	return color.RGBA{
+		uint8(float64(c.R) * k),
+		uint8(float64(c.G) * k),
+		uint8(float64(c.B) * k),
+		c.A,
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -9,7 +9,11 @@ STL File Load/Save Testing
 package render
 
 import (
+	"path/filepath"
 	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+	v3 "github.com/deadsy/sdfx/vec/v3"
 )
 
 //-----------------------------------------------------------------------------
@@ -35,3 +39,26 @@ func Test_LoadSTL(t *testing.T) {
 }
 
 //-----------------------------------------------------------------------------
+
+func Test_AssemblyToSTL(t *testing.T) {
+	box, _ := sdf.Box3D(v3.Vec{10, 10, 10}, 0)
+	a := sdf.NewAssembly()
+	a.Add("a", box, sdf.Identity3d())
+	a.Add("b", box, sdf.Translate3d(v3.Vec{100, 0, 0}))
+	prefix := t.TempDir() + "/"
+	for _, placed := range []bool{false, true} {
+		if err := AssemblyToSTL(a, prefix, NewMarchingCubesUniform(20), placed); err != nil {
+			t.Fatal(err)
+		}
+		mesh, err := LoadSTL(filepath.Join(prefix, "b.stl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		x := mesh[0][0].X
+		if placed != (x > 50) {
+			t.Errorf("placed %v: unexpected vertex %v", placed, mesh[0][0])
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,176 @@
+//-----------------------------------------------------------------------------
+/*
+
+Evaluation Cache
+
+A concurrent-safe, bounded memoizing cache for SDF evaluations.
+
+The cache is split into shards (each with it's own lock) to reduce contention
+when used from the parallel renderers. Each shard keeps two generations of
+entries. When the current generation is full it becomes the old generation and
+the previous old generation is dropped. Hits in the old generation are promoted.
+This approximates an LRU with bounded memory and no per-entry bookkeeping.
+
+Keys may be quantized so that nearby points share an evaluation. This trades
+accuracy (an error of up to about Quantum * sqrt(dimensions)) for hit rate.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+//-----------------------------------------------------------------------------
+
+// CacheParms defines the parameters for an evaluation cache.
+type CacheParms struct {
+	Quantum  float64 // key quantization step (0 = exact keys)
+	Capacity int     // maximum number of cached evaluations (0 = default)
+}
+
+// defaultCacheCapacity is the default maximum number of cached evaluations.
+const defaultCacheCapacity = 1 << 20
+
+// cacheShards is the number of cache shards (a power of 2).
+const cacheShards = 64
+
+// CacheStats are the statistics for an evaluation cache.
+type CacheStats struct {
+	Reads   uint64 // number of evaluations
+	Hits    uint64 // number of evaluations returned from the cache
+	Entries int    // number of cached evaluations
+}
+
+func (s CacheStats) String() string {
+	r := float64(s.Hits) / float64(s.Reads)
+	return fmt.Sprintf("reads %d hits %d (%.2f) entries %d", s.Reads, s.Hits, r, s.Entries)
+}
+
+type cacheKey [3]uint64
+
+type cacheShard struct {
+	sync.Mutex
+	cur, old map[cacheKey]float64
+}
+
+// evalCache is the cache shared by the 2d and 3d cache SDFs.
+type evalCache struct {
+	quantum    float64
+	invQuantum float64
+	capacity   int
+	generation int // maximum entries per generation per shard
+	shard      [cacheShards]cacheShard
+	reads      atomic.Uint64
+	hits       atomic.Uint64
+}
+
+func newEvalCache(k *CacheParms) *evalCache {
+	var quantum float64
+	capacity := defaultCacheCapacity
+	if k != nil {
+		quantum = k.Quantum
+		if k.Capacity > 0 {
+			capacity = k.Capacity
+		}
+	}
+	c := &evalCache{
+		quantum:    quantum,
+		capacity:   capacity,
+		generation: capacity / (2 * cacheShards),
+	}
+	if c.generation < 1 {
+		c.generation = 1
+	}
+	if quantum > 0 {
+		c.invQuantum = 1.0 / quantum
+	}
+	for i := range c.shard {
+		c.shard[i].cur = make(map[cacheKey]float64)
+	}
+	return c
+}
+
+// parms returns the parameters of the cache.
+func (c *evalCache) parms() *CacheParms {
+	return &CacheParms{Quantum: c.quantum, Capacity: c.capacity}
+}
+
+// keyComponent returns the key for a point component.
+func (c *evalCache) keyComponent(x float64) uint64 {
+	if c.quantum > 0 {
+		return uint64(int64(math.Round(x * c.invQuantum)))
+	}
+	// +0 for -0
+	return math.Float64bits(x + 0)
+}
+
+// key returns the cache key for a point.
+func (c *evalCache) key(x, y, z float64) cacheKey {
+	return cacheKey{c.keyComponent(x), c.keyComponent(y), c.keyComponent(z)}
+}
+
+// shardOf returns the shard for a key.
+func (c *evalCache) shardOf(k cacheKey) *cacheShard {
+	h := k[0]*0x9e3779b97f4a7c15 ^ k[1]*0xc2b2ae3d27d4eb4f ^ k[2]*0x165667b19e3779f9
+	return &c.shard[(h>>32)&(cacheShards-1)]
+}
+
+// get returns a cached evaluation.
+func (c *evalCache) get(k cacheKey) (float64, bool) {
+	c.reads.Add(1)
+	s := c.shardOf(k)
+	s.Lock()
+	defer s.Unlock()
+	if d, ok := s.cur[k]; ok {
+		c.hits.Add(1)
+		return d, true
+	}
+	if d, ok := s.old[k]; ok {
+		c.hits.Add(1)
+		// promote to the current generation
+		delete(s.old, k)
+		s.put(k, d, c.generation)
+		return d, true
+	}
+	return 0, false
+}
+
+// set adds an evaluation to the cache.
+func (c *evalCache) set(k cacheKey, d float64) {
+	s := c.shardOf(k)
+	s.Lock()
+	s.put(k, d, c.generation)
+	s.Unlock()
+}
+
+// put adds an entry to the current generation (the shard must be locked).
+func (s *cacheShard) put(k cacheKey, d float64, generation int) {
+	if len(s.cur) >= generation {
+		s.old = s.cur
+		s.cur = make(map[cacheKey]float64, generation)
+	}
+	s.cur[k] = d
+}
+
+// stats returns the cache statistics.
+func (c *evalCache) stats() CacheStats {
+	st := CacheStats{
+		Reads: c.reads.Load(),
+		Hits:  c.hits.Load(),
+	}
+	for i := range c.shard {
+		s := &c.shard[i]
+		s.Lock()
+		st.Entries += len(s.cur) + len(s.old)
+		s.Unlock()
+	}
+	return st
+}
+
+//-----------------------------------------------------------------------------
@@ -14,8 +14,6 @@ evaluations.
 package sdf
 
 import (
-	"fmt"
-
 	v2 "github.com/deadsy/sdfx/vec/v2"
 )
 
@@ -23,33 +21,41 @@ import (
 
 // CacheSDF2 is an SDF2 cache.
 type CacheSDF2 struct {
-	sdf         SDF2
-	cache       map[v2.Vec]float64
-	reads, hits uint
+	sdf   SDF2
+	cache *evalCache
 }
 
 // Cache2D wraps the passed SDF2 with an evaluation cache.
 func Cache2D(sdf SDF2) SDF2 {
+	return BoundedCache2D(sdf, nil)
+}
+
+// BoundedCache2D wraps the passed SDF2 with an evaluation cache.
+// The cache parameters set the key quantization and the maximum size of the cache.
+func BoundedCache2D(sdf SDF2, k *CacheParms) SDF2 {
 	return &CacheSDF2{
 		sdf:   sdf,
-		cache: make(map[v2.Vec]float64),
+		cache: newEvalCache(k),
 	}
 }
 
 func (s *CacheSDF2) String() string {
-	r := float64(s.hits) / float64(s.reads)
-	return fmt.Sprintf("reads %d hits %d (%.2f)", s.reads, s.hits, r)
+	return s.Stats().String()
+}
+
+// Stats returns the cache statistics.
+func (s *CacheSDF2) Stats() CacheStats {
+	return s.cache.stats()
 }
 
 // Evaluate returns the minimum distance to a cached 2d sdf.
 func (s *CacheSDF2) Evaluate(p v2.Vec) float64 {
-	s.reads++
-	if d, ok := s.cache[p]; ok {
-		s.hits++
+	k := s.cache.key(p.X, p.Y, 0)
+	if d, ok := s.cache.get(k); ok {
 		return d
 	}
 	d := s.sdf.Evaluate(p)
-	s.cache[p] = d
+	s.cache.set(k, d)
 	return d
 }
 
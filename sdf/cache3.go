@@ -0,0 +1,66 @@
+//-----------------------------------------------------------------------------
+/*
+
+3D Evaluation Cache
+
+Expensive 3d SDFs (E.g. imported meshes or text) may be evaluated repeatedly
+at the same points, particularly when they are used as a subtree of a larger
+model. This SDF3 wraps an underlying SDF3 and caches the evaluations.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// CacheSDF3 is an SDF3 cache.
+type CacheSDF3 struct {
+	sdf   SDF3
+	cache *evalCache
+}
+
+// Cache3D wraps the passed SDF3 with an evaluation cache.
+func Cache3D(sdf SDF3) SDF3 {
+	return BoundedCache3D(sdf, nil)
+}
+
+// BoundedCache3D wraps the passed SDF3 with an evaluation cache.
+// The cache parameters set the key quantization and the maximum size of the cache.
+func BoundedCache3D(sdf SDF3, k *CacheParms) SDF3 {
+	return &CacheSDF3{
+		sdf:   sdf,
+		cache: newEvalCache(k),
+	}
+}
+
+func (s *CacheSDF3) String() string {
+	return s.Stats().String()
+}
+
+// Stats returns the cache statistics.
+func (s *CacheSDF3) Stats() CacheStats {
+	return s.cache.stats()
+}
+
+// Evaluate returns the minimum distance to a cached 3d sdf.
+func (s *CacheSDF3) Evaluate(p v3.Vec) float64 {
+	k := s.cache.key(p.X, p.Y, p.Z)
+	if d, ok := s.cache.get(k); ok {
+		return d
+	}
+	d := s.sdf.Evaluate(p)
+	s.cache.set(k, d)
+	return d
+}
+
+// BoundingBox returns the bounding box of a cached 3d sdf.
+func (s *CacheSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+//-----------------------------------------------------------------------------
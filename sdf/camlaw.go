@@ -0,0 +1,330 @@
+//-----------------------------------------------------------------------------
+/*
+
+Cam Synthesis and Analysis
+
+Synthesis: build a cam profile from a follower motion program.
+The motion program is a sequence of dwell, rise and return segments, each
+using a standard motion law (cycloidal, harmonic, 3-4-5 polynomial).
+
+Analysis: sample the follower displacement, velocity, acceleration and
+pressure angle for an existing cam profile.
+
+Conventions: The cam rotates about the origin. The follower moves along the
+positive y-axis (as with the other cam profiles) and the cam angle is the
+rotation of the follower about the cam (counter clockwise). Velocity and
+acceleration are per radian and per radian squared of cam rotation.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	v2 "github.com/deadsy/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+// Motion Laws
+
+// CamLaw is a follower motion law.
+type CamLaw int
+
+// Follower motion laws.
+const (
+	CamDwell      CamLaw = iota // no follower motion
+	CamCycloidal                // cycloidal motion (zero acceleration at the ends)
+	CamHarmonic                 // simple harmonic motion
+	CamPolynomial               // 3-4-5 polynomial motion
+)
+
+// CamSegment is a segment of a cam motion program.
+type CamSegment struct {
+	Law      CamLaw  // motion law
+	Duration float64 // cam angle over which the segment acts (radians)
+	Lift     float64 // follower displacement, > 0 for a rise, < 0 for a return
+}
+
+// camLaw returns the normalised displacement and its first and second derivatives for x in [0, 1].
+func camLaw(law CamLaw, x float64) (s, v, a float64) {
+	switch law {
+	case CamCycloidal:
+		s = x - math.Sin(Tau*x)/Tau
+		v = 1 - math.Cos(Tau*x)
+		a = Tau * math.Sin(Tau*x)
+	case CamHarmonic:
+		s = 0.5 * (1 - math.Cos(Pi*x))
+		v = 0.5 * Pi * math.Sin(Pi*x)
+		a = 0.5 * Pi * Pi * math.Cos(Pi*x)
+	case CamPolynomial:
+		x2 := x * x
+		x3 := x2 * x
+		s = x3 * (10 - 15*x + 6*x2)
+		v = x2 * (30 - 60*x + 30*x2)
+		a = x * (60 - 180*x + 120*x2)
+	}
+	return s, v, a
+}
+
+// CamProgram is a complete cam motion program (one revolution).
+type CamProgram []CamSegment
+
+// Validate checks that the motion program covers one revolution and returns to the start.
+func (cp CamProgram) Validate() error {
+	if len(cp) == 0 {
+		return errors.New("empty cam program")
+	}
+	var angle, lift float64
+	for i, seg := range cp {
+		if seg.Duration <= 0 {
+			return fmt.Errorf("segment %d: duration <= 0", i)
+		}
+		if seg.Law < CamDwell || seg.Law > CamPolynomial {
+			return fmt.Errorf("segment %d: unknown motion law", i)
+		}
+		if seg.Law == CamDwell && seg.Lift != 0 {
+			return fmt.Errorf("segment %d: dwell with lift != 0", i)
+		}
+		angle += seg.Duration
+		lift += seg.Lift
+		if lift < -tolerance {
+			return fmt.Errorf("segment %d: follower below the base circle", i)
+		}
+	}
+	if math.Abs(angle-Tau) > 1e-6 {
+		return errors.New("cam program durations must sum to 2*Pi")
+	}
+	if math.Abs(lift) > 1e-6 {
+		return errors.New("cam program lifts must sum to zero")
+	}
+	return nil
+}
+
+// Displacement returns the follower displacement and its first and second derivatives at a cam angle.
+func (cp CamProgram) Displacement(theta float64) (s, v, a float64) {
+	theta = math.Mod(theta, Tau)
+	if theta < 0 {
+		theta += Tau
+	}
+	var base float64
+	for _, seg := range cp {
+		if theta <= seg.Duration {
+			x := theta / seg.Duration
+			s, v, a = camLaw(seg.Law, x)
+			k := seg.Lift
+			return base + k*s, k * v / seg.Duration, k * a / (seg.Duration * seg.Duration)
+		}
+		theta -= seg.Duration
+		base += seg.Lift
+	}
+	return base, 0, 0
+}
+
+//-----------------------------------------------------------------------------
+// Cam Synthesis
+
+// CamFollower is the type of cam follower.
+type CamFollower int
+
+// Cam follower types.
+const (
+	KnifeEdgeFollower CamFollower = iota // point contact
+	RollerFollower                       // roller (radius > 0)
+	FlatFaceFollower                     // flat face perpendicular to the follower axis
+)
+
+// CamParms defines the parameters for cam synthesis.
+type CamParms struct {
+	Program      CamProgram  // follower motion program
+	Follower     CamFollower // follower type
+	BaseRadius   float64     // base circle radius of the cam
+	RollerRadius float64     // roller radius (roller followers only)
+	Steps        int         // number of profile steps per revolution
+}
+
+// followerDir returns the follower direction (in cam coordinates) for a cam angle.
+func followerDir(theta float64) v2.Vec {
+	return v2.Vec{-math.Sin(theta), math.Cos(theta)}
+}
+
+// CamProfile2D returns a cam profile synthesised from a follower motion program.
+func CamProfile2D(k *CamParms) (SDF2, error) {
+	err := k.Program.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if k.BaseRadius <= 0 {
+		return nil, errors.New("BaseRadius <= 0")
+	}
+	if k.Steps < 16 {
+		return nil, errors.New("Steps < 16")
+	}
+	if k.Follower == RollerFollower && k.RollerRadius <= 0 {
+		return nil, errors.New("RollerRadius <= 0")
+	}
+
+	vertex := make([]v2.Vec, k.Steps)
+	dtheta := Tau / float64(k.Steps)
+	for i := range vertex {
+		theta := float64(i) * dtheta
+		s, v, a := k.Program.Displacement(theta)
+		u := followerDir(theta)
+		switch k.Follower {
+		case KnifeEdgeFollower:
+			vertex[i] = u.MulScalar(k.BaseRadius + s)
+		case RollerFollower:
+			// pitch curve for the roller center
+			vertex[i] = u.MulScalar(k.BaseRadius + k.RollerRadius + s)
+		case FlatFaceFollower:
+			// the contact point on the envelope of the follower face
+			r := k.BaseRadius + s
+			if r+a <= 0 {
+				return nil, fmt.Errorf("cam profile is not convex at %.1f degrees (increase BaseRadius)", RtoD(theta))
+			}
+			du := v2.Vec{-math.Cos(theta), -math.Sin(theta)}
+			vertex[i] = u.MulScalar(r).Add(du.MulScalar(v))
+		default:
+			return nil, errors.New("unknown follower type")
+		}
+	}
+
+	s, err := Polygon2D(vertex)
+	if err != nil {
+		return nil, err
+	}
+	if k.Follower == RollerFollower {
+		// the cam profile is the inner envelope of the roller
+		s = Offset2D(s, -k.RollerRadius)
+	}
+	return s, nil
+}
+
+// CamPressureAngle returns the maximum pressure angle (radians) for a cam synthesised from the parameters.
+func CamPressureAngle(k *CamParms) float64 {
+	if k.Follower == FlatFaceFollower {
+		return 0
+	}
+	r0 := k.BaseRadius
+	if k.Follower == RollerFollower {
+		r0 += k.RollerRadius
+	}
+	var pMax float64
+	dtheta := Tau / float64(k.Steps)
+	for i := 0; i < k.Steps; i++ {
+		s, v, _ := k.Program.Displacement(float64(i) * dtheta)
+		p := math.Atan2(math.Abs(v), r0+s)
+		pMax = math.Max(pMax, p)
+	}
+	return pMax
+}
+
+//-----------------------------------------------------------------------------
+// Cam Analysis
+
+// CamSample is the follower state at a cam angle.
+type CamSample struct {
+	Angle         float64 // cam angle (radians)
+	Displacement  float64 // follower displacement from the minimum
+	Velocity      float64 // follower velocity (per radian)
+	Acceleration  float64 // follower acceleration (per radian^2)
+	PressureAngle float64 // pressure angle (radians)
+}
+
+// camRadius returns the distance along a ray from the origin to the cam surface offset by d.
+func camRadius(s SDF2, u v2.Vec, d float64) float64 {
+	bb := s.BoundingBox()
+	hi := bb.Max.Sub(bb.Min).Length() + d
+	lo := 0.0
+	for i := 0; i < 60; i++ {
+		mid := 0.5 * (lo + hi)
+		if s.Evaluate(u.MulScalar(mid)) < d {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return 0.5 * (lo + hi)
+}
+
+// CamFollowerSamples samples the follower motion for a cam profile.
+// The cam must be star shaped about the origin and have an exact distance field
+// (for roller followers) or a well defined boundary (knife-edge and flat-faced followers).
+func CamFollowerSamples(
+	s SDF2, // cam profile
+	follower CamFollower, // follower type
+	rollerRadius float64, // roller radius (roller followers only)
+	steps int, // number of samples per revolution
+) ([]CamSample, error) {
+	if s == nil {
+		return nil, errors.New("s == nil")
+	}
+	if steps < 16 {
+		return nil, errors.New("steps < 16")
+	}
+	if follower == RollerFollower && rollerRadius <= 0 {
+		return nil, errors.New("rollerRadius <= 0")
+	}
+	dtheta := Tau / float64(steps)
+
+	// follower position along its axis
+	pos := make([]float64, steps)
+	switch follower {
+	case KnifeEdgeFollower:
+		for i := range pos {
+			pos[i] = camRadius(s, followerDir(float64(i)*dtheta), 0)
+		}
+	case RollerFollower:
+		for i := range pos {
+			pos[i] = camRadius(s, followerDir(float64(i)*dtheta), rollerRadius)
+		}
+	case FlatFaceFollower:
+		// sample the cam boundary and take the support function
+		n := 4 * steps
+		boundary := make([]v2.Vec, n)
+		for i := range boundary {
+			u := followerDir(Tau * float64(i) / float64(n))
+			boundary[i] = u.MulScalar(camRadius(s, u, 0))
+		}
+		for i := range pos {
+			u := followerDir(float64(i) * dtheta)
+			pos[i] = math.Inf(-1)
+			for _, p := range boundary {
+				pos[i] = math.Max(pos[i], p.Dot(u))
+			}
+		}
+	default:
+		return nil, errors.New("unknown follower type")
+	}
+
+	// displacement from the minimum position
+	pMin := math.Inf(1)
+	for _, p := range pos {
+		pMin = math.Min(pMin, p)
+	}
+
+	samples := make([]CamSample, steps)
+	for i := range samples {
+		prev := pos[(i+steps-1)%steps]
+		next := pos[(i+1)%steps]
+		v := (next - prev) / (2 * dtheta)
+		a := (next - 2*pos[i] + prev) / (dtheta * dtheta)
+		var pa float64
+		if follower != FlatFaceFollower {
+			pa = math.Atan2(v, pos[i])
+		}
+		samples[i] = CamSample{
+			Angle:         float64(i) * dtheta,
+			Displacement:  pos[i] - pMin,
+			Velocity:      v,
+			Acceleration:  a,
+			PressureAngle: pa,
+		}
+	}
+	return samples, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,320 @@
+//-----------------------------------------------------------------------------
+/*
+
+Connectors and Assemblies
+
+A part declares named connectors. A connector is a point on the part with an
+axis pointing out of the part and an angle that rotates the connector about
+its axis. Mating two connectors puts the connection points together with
+opposed axes and aligned angles.
+
+An assembly is a set of parts with transforms. Parts are either placed with
+a transform or mated to a connector of a part already in the assembly. Mated
+parts form a tree, changing the joint angle of a part moves it and all the
+parts mated to it.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+// Connectors
+
+// Connector3 defines a 3d connection point.
+type Connector3 struct {
+	Name     string
+	Position v3.Vec  // connection point
+	Vector   v3.Vec  // connection axis, pointing out of the part
+	Angle    float64 // rotation about the axis from the reference direction (radians)
+}
+
+// connectorReference returns the reference direction (angle 0) for a connector axis.
+// It's the x-axis (or the y-axis for axes close to x) made normal to the axis.
+func connectorReference(axis v3.Vec) v3.Vec {
+	r := v3.Vec{X: 1}
+	if math.Abs(axis.X) > 0.9 {
+		r = v3.Vec{Y: 1}
+	}
+	return r.Sub(axis.MulScalar(r.Dot(axis))).Normalize()
+}
+
+// Frame returns the matrix that maps the connector frame to part coordinates.
+// The frame has its origin at the connection point, the z-axis along the
+// connector axis and the x-axis in the direction given by the connector angle.
+func (c *Connector3) Frame() M44 {
+	z := c.Vector.Normalize()
+	r := connectorReference(z)
+	s, k := math.Sincos(c.Angle)
+	x := r.MulScalar(k).Add(z.Cross(r).MulScalar(s))
+	y := z.Cross(x)
+	p := c.Position
+	return M44{
+		x.X, y.X, z.X, p.X,
+		x.Y, y.Y, z.Y, p.Y,
+		x.Z, y.Z, z.Z, p.Z,
+		0, 0, 0, 1,
+	}
+}
+
+// connectorFromFrame returns the connector for a connector frame.
+func connectorFromFrame(name string, m M44) Connector3 {
+	x := v3.Vec{X: m[0], Y: m[4], Z: m[8]}
+	z := v3.Vec{X: m[2], Y: m[6], Z: m[10]}
+	r := connectorReference(z)
+	return Connector3{
+		Name:     name,
+		Position: v3.Vec{X: m[3], Y: m[7], Z: m[11]},
+		Vector:   z,
+		Angle:    math.Atan2(x.Dot(z.Cross(r)), x.Dot(r)),
+	}
+}
+
+// Transform returns the connector moved by a rotate/translate matrix.
+func (c *Connector3) Transform(m M44) Connector3 {
+	return connectorFromFrame(c.Name, m.Mul(c.Frame()))
+}
+
+// Mate returns the transform that moves a part so its connector b mates with connector a.
+// The connection points coincide, the axes are opposed and the angles are aligned.
+// The part is then rotated by angle about the axis of connector a (E.g. a joint angle).
+func Mate(a, b *Connector3, angle float64) M44 {
+	// flip about the x-axis to oppose the axes
+	flip := M44{
+		1, 0, 0, 0,
+		0, -1, 0, 0,
+		0, 0, -1, 0,
+		0, 0, 0, 1,
+	}
+	return a.Frame().Mul(RotateZ(angle)).Mul(flip).Mul(b.Frame().Inverse())
+}
+
+//-----------------------------------------------------------------------------
+
+// ConnectedSDF3 is an SDF3 with connection points defined.
+type ConnectedSDF3 struct {
+	sdf        SDF3
+	connectors []Connector3
+}
+
+// AddConnector adds connection points to an SDF3.
+func AddConnector(sdf SDF3, connectors ...Connector3) (SDF3, error) {
+	var list []Connector3
+	// is the sdf already connected?
+	if s, ok := sdf.(*ConnectedSDF3); ok {
+		sdf = s.sdf
+		list = append(list, s.connectors...)
+	}
+	for _, c := range connectors {
+		if c.Name == "" {
+			return nil, ErrMsg("connector has no name")
+		}
+		if c.Vector.Length() == 0 {
+			return nil, fmt.Errorf("connector \"%s\" vector has zero length", c.Name)
+		}
+		for i := range list {
+			if list[i].Name == c.Name {
+				return nil, fmt.Errorf("connector \"%s\" is already defined", c.Name)
+			}
+		}
+		c.Vector = c.Vector.Normalize()
+		list = append(list, c)
+	}
+	return &ConnectedSDF3{
+		sdf:        sdf,
+		connectors: list,
+	}, nil
+}
+
+// Evaluate returns the minimum distance to a connected SDF3.
+func (s *ConnectedSDF3) Evaluate(p v3.Vec) float64 {
+	return s.sdf.Evaluate(p)
+}
+
+// BoundingBox returns the bounding box of a connected SDF3.
+func (s *ConnectedSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+// Connectors returns the connection points of a connected SDF3.
+func (s *ConnectedSDF3) Connectors() []Connector3 {
+	return append([]Connector3(nil), s.connectors...)
+}
+
+// Connector returns a named connection point of an SDF3.
+func Connector(s SDF3, name string) (Connector3, error) {
+	cs, ok := s.(*ConnectedSDF3)
+	if !ok {
+		return Connector3{}, fmt.Errorf("no connector \"%s\" (the sdf has no connectors)", name)
+	}
+	for _, c := range cs.connectors {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return Connector3{}, fmt.Errorf("no connector \"%s\"", name)
+}
+
+//-----------------------------------------------------------------------------
+// Assemblies
+
+// assemblyMate is the mating of a part to a connector of another part.
+type assemblyMate struct {
+	to    *AssemblyPart // the part mated to
+	a, b  Connector3    // connectors on the mated to part and this part
+	angle float64       // joint angle about the connector axis
+}
+
+// AssemblyPart is a part in an assembly.
+type AssemblyPart struct {
+	Name   string
+	SDF    SDF3
+	matrix M44           // part to assembly transform
+	base   M44           // transform for placed parts
+	mate   *assemblyMate // mating for mated parts
+}
+
+// Matrix returns the part to assembly transform.
+func (p *AssemblyPart) Matrix() M44 {
+	return p.matrix
+}
+
+// Placed returns the part SDF3 in assembly coordinates.
+func (p *AssemblyPart) Placed() SDF3 {
+	return Transform3D(p.SDF, p.matrix)
+}
+
+// Connector returns a named connector of the part in assembly coordinates.
+func (p *AssemblyPart) Connector(name string) (Connector3, error) {
+	c, err := Connector(p.SDF, name)
+	if err != nil {
+		return Connector3{}, fmt.Errorf("part \"%s\": %w", p.Name, err)
+	}
+	return c.Transform(p.matrix), nil
+}
+
+// update sets the part transform from its placement or mating.
+func (p *AssemblyPart) update() {
+	if p.mate == nil {
+		p.matrix = p.base
+		return
+	}
+	m := p.mate
+	p.matrix = m.to.matrix.Mul(Mate(&m.a, &m.b, m.angle))
+}
+
+// Assembly is a set of parts with transforms.
+type Assembly struct {
+	parts []*AssemblyPart // in the order they were added, mated parts follow their parents
+}
+
+// NewAssembly returns an empty assembly.
+func NewAssembly() *Assembly {
+	return &Assembly{}
+}
+
+// addPart adds a part to the assembly.
+func (a *Assembly) addPart(p *AssemblyPart) error {
+	if p.Name == "" {
+		return ErrMsg("part has no name")
+	}
+	if p.SDF == nil {
+		return fmt.Errorf("part \"%s\" sdf is nil", p.Name)
+	}
+	if _, err := a.Part(p.Name); err == nil {
+		return fmt.Errorf("part \"%s\" is already in the assembly", p.Name)
+	}
+	p.update()
+	a.parts = append(a.parts, p)
+	return nil
+}
+
+// Add places a part in the assembly with a transform.
+func (a *Assembly) Add(name string, s SDF3, m M44) error {
+	return a.addPart(&AssemblyPart{Name: name, SDF: s, base: m})
+}
+
+// Mate adds a part to the assembly with its connector mated to a connector of another part.
+// The part is rotated by angle about the axis of the other connector (E.g. a joint angle).
+func (a *Assembly) Mate(name string, s SDF3, connector, to, toConnector string, angle float64) error {
+	parent, err := a.Part(to)
+	if err != nil {
+		return err
+	}
+	ca, err := Connector(parent.SDF, toConnector)
+	if err != nil {
+		return fmt.Errorf("part \"%s\": %w", to, err)
+	}
+	cb, err := Connector(s, connector)
+	if err != nil {
+		return fmt.Errorf("part \"%s\": %w", name, err)
+	}
+	return a.addPart(&AssemblyPart{
+		Name: name,
+		SDF:  s,
+		mate: &assemblyMate{to: parent, a: ca, b: cb, angle: angle},
+	})
+}
+
+// SetAngle sets the joint angle of a mated part.
+// The part and all the parts mated to it are moved.
+func (a *Assembly) SetAngle(name string, angle float64) error {
+	p, err := a.Part(name)
+	if err != nil {
+		return err
+	}
+	if p.mate == nil {
+		return fmt.Errorf("part \"%s\" is not mated", name)
+	}
+	p.mate.angle = angle
+	// parents precede their children
+	for _, p := range a.parts {
+		p.update()
+	}
+	return nil
+}
+
+// Angle returns the joint angle of a mated part.
+func (a *Assembly) Angle(name string) (float64, error) {
+	p, err := a.Part(name)
+	if err != nil {
+		return 0, err
+	}
+	if p.mate == nil {
+		return 0, fmt.Errorf("part \"%s\" is not mated", name)
+	}
+	return p.mate.angle, nil
+}
+
+// Part returns a named part of the assembly.
+func (a *Assembly) Part(name string) (*AssemblyPart, error) {
+	for _, p := range a.parts {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no part \"%s\" in the assembly", name)
+}
+
+// Parts returns the parts of the assembly in the order they were added.
+func (a *Assembly) Parts() []*AssemblyPart {
+	return append([]*AssemblyPart(nil), a.parts...)
+}
+
+// Union returns the union of the placed parts (E.g. for a preview).
+func (a *Assembly) Union() SDF3 {
+	s := make([]SDF3, len(a.parts))
+	for i, p := range a.parts {
+		s[i] = p.Placed()
+	}
+	return Union3D(s...)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,244 @@
+//-----------------------------------------------------------------------------
+/*
+
+Draft Angles
+
+Casting patterns need draft: the faces parallel to the pull direction must
+taper so the pattern can be withdrawn from the mold.
+
+Draft3D tapers all faces of an SDF3 by a uniform angle. The pattern is
+largest at the parting plane and faces taper inwards with distance from it,
+on both sides of the plane for a two part mold. Each cross-section normal
+to the pull direction is offset inwards, so faces normal to the pull
+direction (E.g. the top of a pattern) are not moved.
+
+DraftCheck samples the surface of an SDF3 and reports the regions that don't
+have the required draft (including undercuts).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"math"
+	"sort"
+
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// DraftSDF3 is an SDF3 with a draft angle applied.
+type DraftSDF3 struct {
+	sdf     SDF3
+	pull    v3.Vec  // pull direction (unit vector)
+	u, v    v3.Vec  // basis vectors for the plane normal to the pull direction
+	parting v3.Vec  // a point on the parting plane
+	angle   float64 // draft angle (radians)
+	tan     float64 // tan(angle)
+	k       float64 // distance scaling (the offset increases the gradient)
+	eps     float64 // offset tolerance
+}
+
+// Draft3D returns an SDF3 with a uniform draft angle applied to it.
+// The parting plane goes through the parting point and is normal to the pull direction.
+// Each cross-section normal to the pull direction is offset inwards by h * tan(angle),
+// where h is the distance from the parting plane.
+// Note: Each evaluation samples the underlying SDF3 on a circle about the
+// evaluation point (about 25 evaluations). Features that fit inside the
+// circle without touching it (E.g. holes narrower than the offset) are not
+// seen by the offset.
+func Draft3D(sdf SDF3, pull, parting v3.Vec, angle float64) (SDF3, error) {
+	if pull.Length() == 0 {
+		return nil, ErrMsg("pull direction has zero length")
+	}
+	if angle < 0 || angle >= DtoR(45) {
+		return nil, ErrMsg("angle must be >= 0 and < 45 degrees")
+	}
+	pull = pull.Normalize()
+	// any vector not parallel to the pull direction
+	x := v3.Vec{1, 0, 0}
+	if math.Abs(pull.X) > 0.5 {
+		x = v3.Vec{0, 1, 0}
+	}
+	u := pull.Cross(x).Normalize()
+	tan := math.Tan(angle)
+	return &DraftSDF3{
+		sdf:     sdf,
+		pull:    pull,
+		u:       u,
+		v:       pull.Cross(u),
+		parting: parting,
+		angle:   angle,
+		tan:     tan,
+		k:       1 / (1 + tan),
+		eps:     sdf.BoundingBox().Size().MaxComponent() * 1e-7,
+	}, nil
+}
+
+// draftSamples is the number of coarse samples on the offset circle.
+const draftSamples = 8
+
+// Evaluate returns the minimum distance to a drafted SDF3.
+func (s *DraftSDF3) Evaluate(p v3.Vec) float64 {
+	d := s.sdf.Evaluate(p)
+	r := math.Abs(p.Sub(s.parting).Dot(s.pull)) * s.tan
+	if r == 0 {
+		return d
+	}
+	// The point is in the offset cross-section if the circle of radius r
+	// (normal to the pull direction) about it is within the cross-section.
+	// Find the maximum distance on the circle.
+	f := func(theta float64) float64 {
+		sin, cos := math.Sincos(theta)
+		return s.sdf.Evaluate(p.Add(s.u.MulScalar(r * cos)).Add(s.v.MulScalar(r * sin)))
+	}
+	step := Tau / draftSamples
+	t0, d0 := 0.0, math.Inf(-1)
+	for i := 0; i < draftSamples; i++ {
+		t := float64(i) * step
+		if x := f(t); x > d0 {
+			t0, d0 = t, x
+		}
+	}
+	// golden section search about the best sample
+	const g = 0.6180339887498949
+	a, b := t0-step, t0+step
+	t1, t2 := b-g*(b-a), a+g*(b-a)
+	d1, d2 := f(t1), f(t2)
+	for r*(1-math.Cos(0.5*(b-a))) > s.eps {
+		if d1 > d2 {
+			b, t2, d2 = t2, t1, d1
+			t1 = b - g*(b-a)
+			d1 = f(t1)
+		} else {
+			a, t1, d1 = t1, t2, d2
+			t2 = a + g*(b-a)
+			d2 = f(t2)
+		}
+	}
+	return math.Max(d, math.Max(d0, math.Max(d1, d2))) * s.k
+}
+
+// BoundingBox returns the bounding box of a drafted SDF3.
+func (s *DraftSDF3) BoundingBox() Box3 {
+	// the draft only removes material
+	return s.sdf.BoundingBox()
+}
+
+//-----------------------------------------------------------------------------
+
+// DraftRegion is a connected surface region with insufficient draft.
+type DraftRegion struct {
+	Box      Box3    // bounding box of the region
+	Center   v3.Vec  // mean position of the samples
+	Area     float64 // approximate surface area
+	Samples  int     // number of surface samples
+	MinAngle float64 // minimum draft angle in the region (radians, negative for undercuts)
+}
+
+// draftSample is a surface sample with insufficient draft.
+type draftSample struct {
+	p     v3.Vec
+	angle float64
+}
+
+// DraftCheck samples the surface of an SDF3 and returns the regions with less than the required draft angle.
+// The parting plane goes through the parting point and is normal to the pull direction.
+// The surface is sampled on a grid with cells along the longest bounding box axis.
+// The regions are sorted by decreasing area.
+func DraftCheck(s SDF3, pull, parting v3.Vec, angle float64, cells int) ([]DraftRegion, error) {
+	if pull.Length() == 0 {
+		return nil, ErrMsg("pull direction has zero length")
+	}
+	if cells <= 0 {
+		return nil, ErrMsg("cells <= 0")
+	}
+	pull = pull.Normalize()
+
+	bb := s.BoundingBox()
+	step := bb.Size().MaxComponent() / float64(cells)
+	bb = NewBox3(bb.Center(), bb.Size().AddScalar(2*step))
+	n := [3]int{
+		int(math.Ceil(bb.Size().X/step)) + 1,
+		int(math.Ceil(bb.Size().Y/step)) + 1,
+		int(math.Ceil(bb.Size().Z/step)) + 1,
+	}
+	eps := step * 1e-3
+	// allow for the numerical normal error
+	limit := math.Sin(angle) - 1e-6
+
+	// sample the surface band of the sdf
+	bad := map[[3]int]draftSample{}
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			for k := 0; k < n[2]; k++ {
+				p := bb.Min.Add(v3.Vec{X: float64(i), Y: float64(j), Z: float64(k)}.MulScalar(step))
+				d := s.Evaluate(p)
+				if math.Abs(d) > 0.5*step {
+					continue
+				}
+				// project to the surface
+				q := p.Sub(Normal3(s, p, eps).MulScalar(d))
+				h := q.Sub(parting).Dot(pull)
+				if math.Abs(h) < 0.5*step {
+					// on the parting plane
+					continue
+				}
+				// the face must open towards the pull direction on each side of the parting plane
+				c := Normal3(s, q, eps).Dot(pull)
+				if h < 0 {
+					c = -c
+				}
+				if c < limit {
+					bad[[3]int{i, j, k}] = draftSample{q, math.Asin(Clamp(c, -1, 1))}
+				}
+			}
+		}
+	}
+
+	// group the samples into connected regions
+	var regions []DraftRegion
+	seen := map[[3]int]bool{}
+	for idx := range bad {
+		if seen[idx] {
+			continue
+		}
+		seen[idx] = true
+		stack := [][3]int{idx}
+		r := DraftRegion{
+			Box:      Box3{bad[idx].p, bad[idx].p},
+			MinAngle: bad[idx].angle,
+		}
+		var sum v3.Vec
+		for len(stack) != 0 {
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x := bad[c]
+			r.Samples++
+			sum = sum.Add(x.p)
+			r.Box = r.Box.Include(x.p)
+			r.MinAngle = math.Min(r.MinAngle, x.angle)
+			// 26-connected neighbours
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					for dz := -1; dz <= 1; dz++ {
+						nb := [3]int{c[0] + dx, c[1] + dy, c[2] + dz}
+						if _, ok := bad[nb]; ok && !seen[nb] {
+							seen[nb] = true
+							stack = append(stack, nb)
+						}
+					}
+				}
+			}
+		}
+		r.Center = sum.DivScalar(float64(r.Samples))
+		r.Area = float64(r.Samples) * step * step
+		regions = append(regions, r)
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Area > regions[j].Area })
+	return regions, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,453 @@
+//-----------------------------------------------------------------------------
+/*
+
+SDF Tree Introspection
+
+Each node of an SDF tree can describe itself as a kind (the name of the
+function that constructs it), a set of parameters (the arguments to that
+function) and a list of child nodes. This allows an SDF tree to be walked,
+printed, compared and serialized.
+
+	n := sdf.Inspect(s)
+	fmt.Println(n.Kind, n.Parms, len(n.Children))
+
+Notes:
+
+The parameters are those passed to the constructor, not the derived values
+stored in the node. Vectors are reported as []float64 (or []int), matrices as
+row-major []float64 and line segments as [][]float64{x0, y0, x1, y1}.
+
+Some nodes can't be rebuilt from their parameters (e.g. unions with custom
+blending functions, imported 3d meshes). They are still inspected, but the
+Opaque field describes what is missing. Nodes that don't implement Inspector
+are opaque leaf nodes, their subtrees are not walked.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// Node describes a node of an SDF tree.
+type Node struct {
+	Kind     string         // node kind (the name of the constructor)
+	Parms    map[string]any // constructor parameters
+	Children []any          // child SDF2/SDF3 nodes
+	Opaque   string         // why the node can't be rebuilt from its parameters ("" if it can)
+}
+
+// Inspector is an SDF node that can describe itself.
+type Inspector interface {
+	Inspect() *Node
+}
+
+// Inspect returns the description of an SDF node.
+// Nodes that don't implement Inspector are reported as opaque leaf nodes.
+func Inspect(s any) *Node {
+	if x, ok := s.(Inspector); ok {
+		return x.Inspect()
+	}
+	return &Node{
+		Kind:   nodeName(s),
+		Opaque: "no introspection",
+	}
+}
+
+// Walk calls fn for each node of an SDF tree (depth first, parents before children).
+// The walk of a subtree stops if fn returns false.
+func Walk(s any, fn func(s any, n *Node, depth int) bool) {
+	var walk func(s any, depth int)
+	walk = func(s any, depth int) {
+		n := Inspect(s)
+		if !fn(s, n, depth) {
+			return
+		}
+		for _, c := range n.Children {
+			walk(c, depth+1)
+		}
+	}
+	walk(s, 0)
+}
+
+// TreeString returns an indented text description of an SDF tree.
+func TreeString(s any) string {
+	var b strings.Builder
+	Walk(s, func(s any, n *Node, depth int) bool {
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString(n.String())
+		b.WriteString("\n")
+		return true
+	})
+	return b.String()
+}
+
+func (n *Node) String() string {
+	var b strings.Builder
+	b.WriteString(n.Kind)
+	if len(n.Parms) != 0 {
+		b.WriteString(" ")
+		b.WriteString(fmt.Sprint(n.Parms))
+	}
+	if n.Opaque != "" {
+		fmt.Fprintf(&b, " (opaque: %s)", n.Opaque)
+	}
+	return b.String()
+}
+
+//-----------------------------------------------------------------------------
+// Parameter Helpers
+
+func v2Parm(v v2.Vec) []float64 {
+	return []float64{v.X, v.Y}
+}
+
+func v3Parm(v v3.Vec) []float64 {
+	return []float64{v.X, v.Y, v.Z}
+}
+
+func linesParm(lines []*Line2) [][]float64 {
+	x := make([][]float64, len(lines))
+	for i, l := range lines {
+		x[i] = []float64{l[0].X, l[0].Y, l[1].X, l[1].Y}
+	}
+	return x
+}
+
+func connectorsParm(connectors []Connector3) []map[string]any {
+	x := make([]map[string]any, len(connectors))
+	for i, c := range connectors {
+		x[i] = map[string]any{"name": c.Name, "position": v3Parm(c.Position), "vector": v3Parm(c.Vector), "angle": c.Angle}
+	}
+	return x
+}
+
+// isFunc returns true if two functions are the same function.
+func isFunc(f0, f1 any) bool {
+	return reflect.ValueOf(f0).Pointer() == reflect.ValueOf(f1).Pointer()
+}
+
+// minOpaque reports a non-default minimum function.
+func minOpaque(f MinFunc) string {
+	if isFunc(f, math.Min) {
+		return ""
+	}
+	return "custom minimum function"
+}
+
+// maxOpaque reports a non-default maximum function.
+func maxOpaque(f MaxFunc) string {
+	if isFunc(f, math.Max) {
+		return ""
+	}
+	return "custom maximum function"
+}
+
+//-----------------------------------------------------------------------------
+// 2D Nodes
+
+// Inspect returns the description of a 2d circle.
+func (s *CircleSDF2) Inspect() *Node {
+	return &Node{Kind: "Circle2D", Parms: map[string]any{"radius": s.radius}}
+}
+
+// Inspect returns the description of a 2d box.
+func (s *BoxSDF2) Inspect() *Node {
+	size := s.size.AddScalar(s.round).MulScalar(2)
+	return &Node{Kind: "Box2D", Parms: map[string]any{"size": v2Parm(size), "round": s.round}}
+}
+
+// Inspect returns the description of a 2d line.
+func (s *LineSDF2) Inspect() *Node {
+	return &Node{Kind: "Line2D", Parms: map[string]any{"length": 2 * s.l, "round": s.round}}
+}
+
+// Inspect returns the description of a 2d mesh.
+func (s *MeshSDF2) Inspect() *Node {
+	return &Node{Kind: "Mesh2D", Parms: map[string]any{"lines": linesParm(s.mesh)}}
+}
+
+// Inspect returns the description of a set of strokes.
+func (s *StrokeSDF2) Inspect() *Node {
+	return &Node{Kind: "Stroke2D", Parms: map[string]any{"lines": linesParm(s.mesh), "width": 2 * s.radius}}
+}
+
+// Inspect returns the description of an offset SDF2.
+func (s *OffsetSDF2) Inspect() *Node {
+	return &Node{Kind: "Offset2D", Parms: map[string]any{"offset": s.offset}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of an SDF2 intersection.
+func (s *IntersectionSDF2) Inspect() *Node {
+	return &Node{Kind: "Intersect2D", Children: []any{s.s0, s.s1}, Opaque: maxOpaque(s.max)}
+}
+
+// Inspect returns the description of a cut SDF2.
+func (s *CutSDF2) Inspect() *Node {
+	v := v2.Vec{s.n.Y, -s.n.X}
+	return &Node{Kind: "Cut2D", Parms: map[string]any{"a": v2Parm(s.a), "v": v2Parm(v)}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a transformed SDF2.
+func (s *TransformSDF2) Inspect() *Node {
+	m := s.mInv.Inverse()
+	return &Node{Kind: "Transform2D", Parms: map[string]any{"matrix": m[:]}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a uniformly scaled SDF2.
+func (s *ScaleUniformSDF2) Inspect() *Node {
+	return &Node{Kind: "ScaleUniform2D", Parms: map[string]any{"k": s.k}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a grid array of SDF2s.
+func (s *ArraySDF2) Inspect() *Node {
+	return &Node{
+		Kind:     "Array2D",
+		Parms:    map[string]any{"num": []int{s.num.X, s.num.Y}, "step": v2Parm(s.step)},
+		Children: []any{s.sdf},
+		Opaque:   minOpaque(s.min),
+	}
+}
+
+// Inspect returns the description of a union of rotated SDF2s.
+func (s *RotateUnionSDF2) Inspect() *Node {
+	m := s.step.Inverse()
+	return &Node{
+		Kind:     "RotateUnion2D",
+		Parms:    map[string]any{"num": s.num, "step": m[:]},
+		Children: []any{s.sdf},
+		Opaque:   minOpaque(s.min),
+	}
+}
+
+// Inspect returns the description of a rotate/copy SDF2.
+func (s *RotateCopySDF2) Inspect() *Node {
+	n := int(math.Round(Tau / s.theta))
+	return &Node{Kind: "RotateCopy2D", Parms: map[string]any{"num": n}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a sliced SDF3.
+func (s *SliceSDF2) Inspect() *Node {
+	n := s.u.Cross(s.v)
+	return &Node{Kind: "Slice2D", Parms: map[string]any{"a": v3Parm(s.a), "n": v3Parm(n)}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of an SDF2 union.
+func (s *UnionSDF2) Inspect() *Node {
+	children := make([]any, len(s.sdf))
+	for i, x := range s.sdf {
+		children[i] = x
+	}
+	return &Node{Kind: "Union2D", Children: children, Opaque: minOpaque(s.min)}
+}
+
+// Inspect returns the description of the difference of two SDF2s.
+func (s *DifferenceSDF2) Inspect() *Node {
+	return &Node{Kind: "Difference2D", Children: []any{s.s0, s.s1}, Opaque: maxOpaque(s.max)}
+}
+
+// Inspect returns the description of an elongated SDF2.
+func (s *ElongateSDF2) Inspect() *Node {
+	return &Node{Kind: "Elongate2D", Parms: map[string]any{"h": v2Parm(s.hp.MulScalar(2))}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a cached SDF2.
+func (s *CacheSDF2) Inspect() *Node {
+	k := s.cache.parms()
+	return &Node{Kind: "Cache2D", Parms: map[string]any{"quantum": k.Quantum, "capacity": k.Capacity}, Children: []any{s.sdf}}
+}
+
+//-----------------------------------------------------------------------------
+// 3D Nodes
+
+// Inspect returns the description of a solid of revolution.
+func (s *SorSDF3) Inspect() *Node {
+	return &Node{Kind: "RevolveTheta3D", Parms: map[string]any{"theta": s.theta}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of an extrusion.
+func (s *ExtrudeSDF3) Inspect() *Node {
+	n := &Node{Children: []any{s.sdf}}
+	height := 2 * s.height
+	scaled := s.scale != v2.Vec{1, 1}
+	switch {
+	case s.twist != 0 && scaled:
+		n.Kind = "ScaleTwistExtrude3D"
+		n.Parms = map[string]any{"height": height, "twist": s.twist, "scale": v2Parm(s.scale)}
+	case s.twist != 0:
+		n.Kind = "TwistExtrude3D"
+		n.Parms = map[string]any{"height": height, "twist": s.twist}
+	case scaled:
+		n.Kind = "ScaleExtrude3D"
+		n.Parms = map[string]any{"height": height, "scale": v2Parm(s.scale)}
+	default:
+		n.Kind = "Extrude3D"
+		n.Parms = map[string]any{"height": height}
+	}
+	if s.custom {
+		n.Opaque = "custom extrusion function"
+	}
+	return n
+}
+
+// Inspect returns the description of a rounded extrusion.
+func (s *ExtrudeRoundedSDF3) Inspect() *Node {
+	height := 2 * (s.height + s.round)
+	return &Node{Kind: "ExtrudeRounded3D", Parms: map[string]any{"height": height, "round": s.round}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a loft extrusion.
+func (s *LoftSDF3) Inspect() *Node {
+	height := 2 * (s.height + s.round)
+	return &Node{Kind: "Loft3D", Parms: map[string]any{"height": height, "round": s.round}, Children: []any{s.sdf0, s.sdf1}}
+}
+
+// Inspect returns the description of a 3d box.
+func (s *BoxSDF3) Inspect() *Node {
+	size := s.size.AddScalar(s.round).MulScalar(2)
+	return &Node{Kind: "Box3D", Parms: map[string]any{"size": v3Parm(size), "round": s.round}}
+}
+
+// Inspect returns the description of a sphere.
+func (s *SphereSDF3) Inspect() *Node {
+	return &Node{Kind: "Sphere3D", Parms: map[string]any{"radius": s.radius}}
+}
+
+// Inspect returns the description of a cylinder.
+func (s *CylinderSDF3) Inspect() *Node {
+	return &Node{Kind: "Cylinder3D", Parms: map[string]any{
+		"height": 2 * (s.height + s.round),
+		"radius": s.radius + s.round,
+		"round":  s.round,
+	}}
+}
+
+// Inspect returns the description of a truncated cone.
+func (s *ConeSDF3) Inspect() *Node {
+	// undo the rounding inset of the radii
+	ofs := s.round / s.n.X
+	return &Node{Kind: "Cone3D", Parms: map[string]any{
+		"height": 2 * (s.height + s.round),
+		"r0":     s.r0 + (1+s.n.Y)*ofs,
+		"r1":     s.r1 + (1-s.n.Y)*ofs,
+		"round":  s.round,
+	}}
+}
+
+// Inspect returns the description of a transformed SDF3.
+func (s *TransformSDF3) Inspect() *Node {
+	return &Node{Kind: "Transform3D", Parms: map[string]any{"matrix": s.matrix[:]}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a uniformly scaled SDF3.
+func (s *ScaleUniformSDF3) Inspect() *Node {
+	return &Node{Kind: "ScaleUniform3D", Parms: map[string]any{"k": s.k}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of an SDF3 union.
+func (s *UnionSDF3) Inspect() *Node {
+	children := make([]any, len(s.sdf))
+	for i, x := range s.sdf {
+		children[i] = x
+	}
+	return &Node{Kind: "Union3D", Children: children, Opaque: minOpaque(s.min)}
+}
+
+// Inspect returns the description of the difference of two SDF3s.
+func (s *DifferenceSDF3) Inspect() *Node {
+	return &Node{Kind: "Difference3D", Children: []any{s.s0, s.s1}, Opaque: maxOpaque(s.max)}
+}
+
+// Inspect returns the description of an elongated SDF3.
+func (s *ElongateSDF3) Inspect() *Node {
+	return &Node{Kind: "Elongate3D", Parms: map[string]any{"h": v3Parm(s.hp.MulScalar(2))}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of an SDF3 intersection.
+func (s *IntersectionSDF3) Inspect() *Node {
+	return &Node{Kind: "Intersect3D", Children: []any{s.s0, s.s1}, Opaque: maxOpaque(s.max)}
+}
+
+// Inspect returns the description of a cut SDF3.
+func (s *CutSDF3) Inspect() *Node {
+	return &Node{Kind: "Cut3D", Parms: map[string]any{"a": v3Parm(s.a), "n": v3Parm(s.n.Neg())}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of an XYZ SDF3 array.
+func (s *ArraySDF3) Inspect() *Node {
+	return &Node{
+		Kind:     "Array3D",
+		Parms:    map[string]any{"num": []int{s.num.X, s.num.Y, s.num.Z}, "step": v3Parm(s.step)},
+		Children: []any{s.sdf},
+		Opaque:   minOpaque(s.min),
+	}
+}
+
+// Inspect returns the description of a rotate/union SDF3.
+func (s *RotateUnionSDF3) Inspect() *Node {
+	m := s.step.Inverse()
+	return &Node{
+		Kind:     "RotateUnion3D",
+		Parms:    map[string]any{"num": s.num, "step": m[:]},
+		Children: []any{s.sdf},
+		Opaque:   minOpaque(s.min),
+	}
+}
+
+// Inspect returns the description of a rotate/copy SDF3.
+func (s *RotateCopySDF3) Inspect() *Node {
+	n := int(math.Round(Tau / s.theta))
+	return &Node{Kind: "RotateCopy3D", Parms: map[string]any{"num": n}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of an offset SDF3.
+func (s *OffsetSDF3) Inspect() *Node {
+	return &Node{Kind: "Offset3D", Parms: map[string]any{"offset": s.offset}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a shelled SDF3.
+func (s *ShellSDF3) Inspect() *Node {
+	return &Node{Kind: "Shell3D", Parms: map[string]any{"thickness": 2 * s.delta}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a drafted SDF3.
+func (s *DraftSDF3) Inspect() *Node {
+	return &Node{
+		Kind:     "Draft3D",
+		Parms:    map[string]any{"pull": v3Parm(s.pull), "parting": v3Parm(s.parting), "angle": s.angle},
+		Children: []any{s.sdf},
+	}
+}
+
+// Inspect returns the description of a connected SDF3.
+func (s *ConnectedSDF3) Inspect() *Node {
+	return &Node{Kind: "Connected3D", Parms: map[string]any{"connectors": connectorsParm(s.connectors)}, Children: []any{s.sdf}}
+}
+
+// Inspect returns the description of a 3d screw form.
+func (s *ScrewSDF3) Inspect() *Node {
+	return &Node{Kind: "Screw3D", Parms: map[string]any{
+		"length": 2 * s.length,
+		"taper":  s.taper,
+		"pitch":  s.pitch,
+		"starts": int(math.Round(-s.lead / s.pitch)),
+	}, Children: []any{s.thread}}
+}
+
+// Inspect returns the description of a cached SDF3.
+func (s *CacheSDF3) Inspect() *Node {
+	k := s.cache.parms()
+	return &Node{Kind: "Cache3D", Parms: map[string]any{"quantum": k.Quantum, "capacity": k.Capacity}, Children: []any{s.sdf}}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,258 @@
+//-----------------------------------------------------------------------------
+/*
+
+Interference and Clearance Checking
+
+Check the fit of two parts (E.g. a nut and bolt, a lid and box) before
+printing them.
+
+The parts interfere where they are both within half the required clearance
+of each other. For parts with exact distance functions the clearance at a
+point is twice the larger of the two distances. Its minimum is the gap
+between the parts, or negative if the parts intersect.
+
+The search is on an adaptive grid: cells that can't contain a violation or
+a smaller clearance are skipped.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// Interference3D returns the region where two SDF3s are closer than the clearance.
+// With zero clearance it's the intersection of the SDF3s.
+func Interference3D(s0, s1 SDF3, clearance float64) SDF3 {
+	if clearance != 0 {
+		s0 = Offset3D(s0, 0.5*clearance)
+		s1 = Offset3D(s1, 0.5*clearance)
+	}
+	return Intersect3D(s0, s1)
+}
+
+//-----------------------------------------------------------------------------
+
+// InterferenceParms defines the parameters for an interference check.
+type InterferenceParms struct {
+	Clearance float64 // required clearance between the parts (0 to check for intersection)
+	Cells     int     // grid cells along the longest axis of the overlap region
+}
+
+// InterferenceRegion is a connected region with less than the required clearance.
+type InterferenceRegion struct {
+	Box       Box3    // bounding box of the region
+	Center    v3.Vec  // mean position of the samples
+	Volume    float64 // approximate intersection volume
+	Samples   int     // number of samples
+	Clearance float64 // minimum clearance in the region (negative for intersections)
+}
+
+// Interference is the result of an interference check.
+type Interference struct {
+	Volume    float64              // approximate intersection volume
+	Clearance float64              // minimum clearance between the parts (negative for intersections)
+	Point     v3.Vec               // location of the minimum clearance
+	Regions   []InterferenceRegion // regions with less than the required clearance
+}
+
+// OK returns true if the parts have the required clearance.
+func (x *Interference) OK() bool {
+	return len(x.Regions) == 0
+}
+
+func (x *Interference) String() string {
+	return fmt.Sprintf("clearance %g at %v, intersection volume %g, %d regions", x.Clearance, x.Point, x.Volume, len(x.Regions))
+}
+
+// clearanceOverlap returns the overlap of two bounding boxes enlarged by half the clearance.
+func clearanceOverlap(bb0, bb1 Box3, clearance float64) (Box3, bool) {
+	e := v3.Vec{X: clearance, Y: clearance, Z: clearance}
+	bb0 = bb0.Enlarge(e)
+	bb1 = bb1.Enlarge(e)
+	overlap := Box3{bb0.Min.Max(bb1.Min), bb0.Max.Min(bb1.Max)}
+	return overlap, overlap.Size().MinComponent() > 0
+}
+
+// interferenceSearch is the adaptive grid search state.
+type interferenceSearch struct {
+	s0, s1 SDF3
+	limit  float64 // violation limit for the max distance (half the clearance)
+	origin v3.Vec  // grid origin
+	step   float64 // leaf cell size
+	best   float64 // minimum max distance
+	point  v3.Vec  // location of the minimum
+	bad    map[[3]int]float64
+}
+
+// eval returns the larger of the two distances at a point.
+func (s *interferenceSearch) eval(p v3.Vec) float64 {
+	return math.Max(s.s0.Evaluate(p), s.s1.Evaluate(p))
+}
+
+// search checks a cell of n x n x n leaf cells at grid index i.
+func (s *interferenceSearch) search(i [3]int, n int) {
+	size := float64(n) * s.step
+	c := s.origin.Add(v3.Vec{X: float64(i[0]), Y: float64(i[1]), Z: float64(i[2])}.MulScalar(s.step)).AddScalar(0.5 * size)
+	d := s.eval(c)
+	if d < s.best {
+		s.best = d
+		s.point = c
+	}
+	if n == 1 {
+		if d < s.limit {
+			s.bad[i] = d
+		}
+		return
+	}
+	// the max distance changes by no more than the distance moved
+	r := 0.5 * math.Sqrt(3) * size
+	if d-r >= s.limit && d-r >= s.best {
+		return
+	}
+	h := n / 2
+	for dx := 0; dx < 2; dx++ {
+		for dy := 0; dy < 2; dy++ {
+			for dz := 0; dz < 2; dz++ {
+				s.search([3]int{i[0] + dx*h, i[1] + dy*h, i[2] + dz*h}, h)
+			}
+		}
+	}
+}
+
+// CheckInterference checks the clearance between two SDF3s.
+// Use Transform3D to position the parts. The clearance values are exact for
+// exact distance functions and accurate to about the grid cell size.
+// The regions are sorted by decreasing volume.
+func CheckInterference(s0, s1 SDF3, k *InterferenceParms) (*Interference, error) {
+	if k.Cells <= 0 {
+		return nil, ErrMsg("cells <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, ErrMsg("clearance < 0")
+	}
+	bb0 := s0.BoundingBox()
+	bb1 := s1.BoundingBox()
+	// the grid resolution is set by the overlap region (or both parts if they don't overlap)
+	all := bb0.Extend(bb1)
+	size := all.Size()
+	if overlap, ok := clearanceOverlap(bb0, bb1, k.Clearance); ok {
+		size = overlap.Size()
+	}
+	step := size.MaxComponent() / float64(k.Cells)
+	// the grid covers both parts with a power of 2 number of leaf cells
+	n := 1
+	for float64(n)*step < all.Size().MaxComponent() {
+		n *= 2
+	}
+
+	s := &interferenceSearch{
+		s0:     s0,
+		s1:     s1,
+		limit:  0.5 * k.Clearance,
+		origin: all.Min,
+		step:   step,
+		best:   math.Inf(1),
+		bad:    map[[3]int]float64{},
+	}
+	s.search([3]int{}, n)
+
+	x := &Interference{
+		Clearance: 2 * s.best,
+		Point:     s.point,
+	}
+
+	// group the samples into connected regions
+	cell := step * step * step
+	seen := map[[3]int]bool{}
+	for idx := range s.bad {
+		if seen[idx] {
+			continue
+		}
+		seen[idx] = true
+		stack := [][3]int{idx}
+		var r InterferenceRegion
+		r.Clearance = math.Inf(1)
+		var sum v3.Vec
+		for len(stack) != 0 {
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			d := s.bad[c]
+			p := s.origin.Add(v3.Vec{X: float64(c[0]), Y: float64(c[1]), Z: float64(c[2])}.AddScalar(0.5).MulScalar(step))
+			if r.Samples == 0 {
+				r.Box = Box3{p, p}
+			}
+			r.Samples++
+			sum = sum.Add(p)
+			r.Box = r.Box.Include(p)
+			r.Clearance = math.Min(r.Clearance, 2*d)
+			if d < 0 {
+				r.Volume += cell
+			}
+			// 26-connected neighbours
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					for dz := -1; dz <= 1; dz++ {
+						nb := [3]int{c[0] + dx, c[1] + dy, c[2] + dz}
+						if _, ok := s.bad[nb]; ok && !seen[nb] {
+							seen[nb] = true
+							stack = append(stack, nb)
+						}
+					}
+				}
+			}
+		}
+		r.Center = sum.DivScalar(float64(r.Samples))
+		x.Volume += r.Volume
+		x.Regions = append(x.Regions, r)
+	}
+	sort.Slice(x.Regions, func(i, j int) bool {
+		if x.Regions[i].Volume != x.Regions[j].Volume {
+			return x.Regions[i].Volume > x.Regions[j].Volume
+		}
+		return x.Regions[i].Clearance < x.Regions[j].Clearance
+	})
+	return x, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// PartInterference is the interference between two parts of an assembly.
+type PartInterference struct {
+	Parts [2]string
+	*Interference
+}
+
+// Interference checks the clearance between all pairs of parts in an assembly.
+// It returns the pairs without the required clearance.
+func (a *Assembly) Interference(k *InterferenceParms) ([]PartInterference, error) {
+	var list []PartInterference
+	for i, p0 := range a.parts {
+		s0 := p0.Placed()
+		for _, p1 := range a.parts[i+1:] {
+			s1 := p1.Placed()
+			if _, ok := clearanceOverlap(s0.BoundingBox(), s1.BoundingBox(), k.Clearance); !ok {
+				// the bounding boxes have the required clearance
+				continue
+			}
+			x, err := CheckInterference(s0, s1, k)
+			if err != nil {
+				return nil, err
+			}
+			if !x.OK() {
+				list = append(list, PartInterference{[2]string{p0.Name, p1.Name}, x})
+			}
+		}
+	}
+	return list, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -122,6 +122,26 @@ func (a *Line2) IntersectLine(b *Line2) []v2.Vec {
 	return nil
 }
 
+//-----------------------------------------------------------------------------
+
+// WriteLines writes a stream of lines to a slice.
+func WriteLines(wg *sync.WaitGroup, lines *[]*Line2) chan<- []*Line2 {
+	// External code writes lines to this channel.
+	// This goroutine reads the channel and appends the lines to a slice.
+	c := make(chan []*Line2)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// read lines from the channel and append them to the slice
+		for ls := range c {
+			*lines = append(*lines, ls...)
+		}
+	}()
+
+	return c
+}
+
 //-----------------------------------------------------------------------------
 // Line2 Buffering
 
@@ -264,8 +264,9 @@ func (node *qtNode) winding(p v2.Vec, wn int) int {
 
 // MeshSDF2 is SDF2 made from a set of line segments.
 type MeshSDF2 struct {
-	qt *qtNode // quadtree root
-	bb Box2    // bounding box
+	qt   *qtNode  // quadtree root
+	mesh []*Line2 // line segments
+	bb   Box2     // bounding box
 }
 
 // Mesh2D returns an SDF2 made from a set of line segments.
@@ -290,8 +291,9 @@ func Mesh2D(mesh []*Line2) (SDF2, error) {
 	qt := qtBuild(0, qtBox, mesh)
 
 	return &MeshSDF2{
-		qt: qt,
-		bb: bb,
+		qt:   qt,
+		mesh: mesh,
+		bb:   bb,
 	}, nil
 }
 
@@ -348,6 +350,59 @@ func Polygon2D(vertex []v2.Vec) (SDF2, error) {
 	return Mesh2D(VertexToLine(vertex, true))
 }
 
+//-----------------------------------------------------------------------------
+// Stroke2D. Line segments drawn with a round pen.
+
+// StrokeSDF2 is SDF2 made from a set of line segments drawn with a round pen.
+type StrokeSDF2 struct {
+	qt     *qtNode  // quadtree root
+	mesh   []*Line2 // line segments
+	radius float64  // pen radius
+	bb     Box2     // bounding box
+}
+
+// Stroke2D returns an SDF2 made from a set of line segments drawn with a round pen.
+// The pen width is the width of the stroke.
+func Stroke2D(lines []*Line2, width float64) (SDF2, error) {
+	if width <= 0 {
+		return nil, ErrMsg("width <= 0")
+	}
+	// remove any zero length segments
+	var mesh []*Line2
+	for _, l := range lines {
+		if !l.Degenerate(tolerance) {
+			mesh = append(mesh, l)
+		}
+	}
+	if len(mesh) == 0 {
+		return nil, ErrMsg("no 2d line segments")
+	}
+
+	// work out the bounding box
+	bb := mesh[0].BoundingBox()
+	for _, edge := range mesh {
+		bb = bb.Include(edge[0]).Include(edge[1])
+	}
+	qtBox := bb.Square().ScaleAboutCenter(1.01)
+
+	return &StrokeSDF2{
+		qt:     qtBuild(0, qtBox, mesh),
+		mesh:   mesh,
+		radius: 0.5 * width,
+		bb:     bb.Enlarge(v2.Vec{width, width}),
+	}, nil
+}
+
+// Evaluate returns the minimum distance for a set of strokes.
+func (s *StrokeSDF2) Evaluate(p v2.Vec) float64 {
+	return math.Sqrt(s.qt.minDist2(p, math.MaxFloat64)) - s.radius
+}
+
+// BoundingBox returns the bounding box of a set of strokes.
+func (s *StrokeSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
 //-----------------------------------------------------------------------------
 // Mesh2D Slow. Provided for testing and benchmarking purposes.
 
@@ -0,0 +1,421 @@
+//-----------------------------------------------------------------------------
+/*
+
+SDF Tree Profiling
+
+Wrap every node of an SDF2/SDF3 tree with a profiling node that counts the
+evaluations and accumulates the evaluation time for the node.
+
+The results are available as a text tree, or as a pprof profile. In the pprof
+profile each SDF node is a function and the SDF tree is the call stack, so the
+usual pprof views (top, tree, flame graph) show where the evaluation time goes.
+
+	s, p := sdf.ProfileSDF3(s)
+	render.ToSTL(s, "model.stl", r)
+	p.WriteText(os.Stdout)
+	p.WritePprof(f) // go tool pprof -http=: f
+
+Notes:
+
+The profiled tree is a copy of the original tree, the original is not modified.
+Each node is rebuilt from its description (see Inspect) with its children
+wrapped. Blending functions aren't part of the description, they are carried across to
+the rebuilt node. Nodes that can't be rebuilt (no introspection, other opaque
+nodes) are profiled as a single node that includes their subtree.
+The timing overhead is included in the cumulative (and self) time of each node.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// profileNode accumulates the profile for an SDF node.
+type profileNode struct {
+	id       int
+	name     string
+	evals    atomic.Uint64
+	nsecs    atomic.Int64
+	children []*profileNode
+}
+
+func (n *profileNode) add(start time.Time) {
+	n.evals.Add(1)
+	n.nsecs.Add(int64(time.Since(start)))
+}
+
+// profileSDF2 is a profiling wrapper for an SDF2.
+type profileSDF2 struct {
+	sdf  SDF2
+	node *profileNode
+}
+
+// Evaluate returns the minimum distance to a profiled SDF2.
+func (s *profileSDF2) Evaluate(p v2.Vec) float64 {
+	start := time.Now()
+	d := s.sdf.Evaluate(p)
+	s.node.add(start)
+	return d
+}
+
+// BoundingBox returns the bounding box of a profiled SDF2.
+func (s *profileSDF2) BoundingBox() Box2 {
+	return s.sdf.BoundingBox()
+}
+
+// profileSDF3 is a profiling wrapper for an SDF3.
+type profileSDF3 struct {
+	sdf  SDF3
+	node *profileNode
+}
+
+// Evaluate returns the minimum distance to a profiled SDF3.
+func (s *profileSDF3) Evaluate(p v3.Vec) float64 {
+	start := time.Now()
+	d := s.sdf.Evaluate(p)
+	s.node.add(start)
+	return d
+}
+
+// BoundingBox returns the bounding box of a profiled SDF3.
+func (s *profileSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+//-----------------------------------------------------------------------------
+// Tree Wrapping
+
+// Profile is the evaluation profile for an SDF tree.
+type Profile struct {
+	root  *profileNode
+	nodes int
+	start time.Time
+}
+
+// wrapChildren returns a copy of an SDF node rebuilt with its child SDFs wrapped.
+// Nodes that can't be rebuilt from their description are profiled as leaves.
+func (p *Profile) wrapChildren(s any, node *profileNode) any {
+	switch x := s.(type) {
+	case *GeneratedSDF2:
+		return &GeneratedSDF2{p.wrap(x.sdf, node).(SDF2), x.name, x.parms}
+	case *GeneratedSDF3:
+		return &GeneratedSDF3{p.wrap(x.sdf, node).(SDF3), x.name, x.parms, x.part}
+	}
+	x, ok := s.(Inspector)
+	if !ok {
+		return s
+	}
+	n := x.Inspect()
+	min, max, blend := blending(s)
+	if blend {
+		// the blending functions are set on the rebuilt node
+		n.Opaque = ""
+	}
+	if len(n.Children) == 0 || n.Opaque != "" || lookupKind(n.Kind) == nil {
+		return s
+	}
+	children := make([]any, len(n.Children))
+	for i, c := range n.Children {
+		children[i] = p.wrap(c, node)
+	}
+	y, err := rebuildNode(n, children)
+	if err != nil {
+		node.children = nil
+		return s
+	}
+	if blend {
+		setBlending(y, min, max)
+	}
+	return y
+}
+
+// blending returns the blending functions of an SDF node.
+func blending(s any) (MinFunc, MaxFunc, bool) {
+	switch x := s.(type) {
+	case *UnionSDF2:
+		return x.min, nil, true
+	case *ArraySDF2:
+		return x.min, nil, true
+	case *RotateUnionSDF2:
+		return x.min, nil, true
+	case *UnionSDF3:
+		return x.min, nil, true
+	case *ArraySDF3:
+		return x.min, nil, true
+	case *RotateUnionSDF3:
+		return x.min, nil, true
+	case *IntersectionSDF2:
+		return nil, x.max, true
+	case *DifferenceSDF2:
+		return nil, x.max, true
+	case *IntersectionSDF3:
+		return nil, x.max, true
+	case *DifferenceSDF3:
+		return nil, x.max, true
+	}
+	return nil, nil, false
+}
+
+// setBlending sets the blending functions of a rebuilt SDF node.
+func setBlending(s any, min MinFunc, max MaxFunc) {
+	if x, ok := s.(interface{ SetMin(MinFunc) }); ok && min != nil {
+		x.SetMin(min)
+	}
+	if x, ok := s.(interface{ SetMax(MaxFunc) }); ok && max != nil {
+		x.SetMax(max)
+	}
+}
+
+// nodeName returns the type name for an SDF node.
+func nodeName(s any) string {
+	name := reflect.TypeOf(s).String()
+	name = strings.TrimPrefix(name, "*")
+	return strings.TrimPrefix(name, "sdf.")
+}
+
+// wrap returns a profiled copy of an SDF tree.
+func (p *Profile) wrap(s any, parent *profileNode) any {
+	node := &profileNode{
+		id:   p.nodes,
+		name: nodeName(s),
+	}
+	p.nodes++
+	if parent != nil {
+		parent.children = append(parent.children, node)
+	} else {
+		p.root = node
+	}
+	x := p.wrapChildren(s, node)
+	switch y := x.(type) {
+	case SDF3:
+		return &profileSDF3{y, node}
+	case SDF2:
+		return &profileSDF2{y, node}
+	}
+	return x
+}
+
+// ProfileSDF2 returns a profiled copy of an SDF2 tree and its profile.
+func ProfileSDF2(s SDF2) (SDF2, *Profile) {
+	p := &Profile{start: time.Now()}
+	return p.wrap(s, nil).(SDF2), p
+}
+
+// ProfileSDF3 returns a profiled copy of an SDF3 tree and its profile.
+func ProfileSDF3(s SDF3) (SDF3, *Profile) {
+	p := &Profile{start: time.Now()}
+	return p.wrap(s, nil).(SDF3), p
+}
+
+//-----------------------------------------------------------------------------
+// Results
+
+// ProfileNode is the profile for a node of an SDF tree.
+type ProfileNode struct {
+	ID       int            // node identifier (depth first order)
+	Name     string         // node type
+	Evals    uint64         // number of evaluations
+	Time     time.Duration  // cumulative evaluation time (including children)
+	Children []*ProfileNode // child nodes
+}
+
+// Self returns the evaluation time excluding the child nodes.
+func (n *ProfileNode) Self() time.Duration {
+	t := n.Time
+	for _, c := range n.Children {
+		t -= c.Time
+	}
+	if t < 0 {
+		return 0
+	}
+	return t
+}
+
+func (n *profileNode) snapshot() *ProfileNode {
+	x := &ProfileNode{
+		ID:    n.id,
+		Name:  n.name,
+		Evals: n.evals.Load(),
+		Time:  time.Duration(n.nsecs.Load()),
+	}
+	for _, c := range n.children {
+		x.Children = append(x.Children, c.snapshot())
+	}
+	return x
+}
+
+// Tree returns the current profile results as a tree.
+func (p *Profile) Tree() *ProfileNode {
+	return p.root.snapshot()
+}
+
+// WriteText writes the profile results as an indented text tree.
+func (p *Profile) WriteText(w io.Writer) error {
+	root := p.Tree()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "node\tevals\ttime\tself\t%%time\n")
+	var walk func(n *ProfileNode, depth int)
+	walk = func(n *ProfileNode, depth int) {
+		pct := 0.0
+		if root.Time > 0 {
+			pct = 100 * float64(n.Time) / float64(root.Time)
+		}
+		name := fmt.Sprintf("%s%s #%d", strings.Repeat("  ", depth), n.Name, n.ID)
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.1f\n", name, n.Evals, n.Time.Round(time.Microsecond), n.Self().Round(time.Microsecond), pct)
+		for _, c := range n.Children {
+			walk(c, depth+1)
+		}
+	}
+	walk(root, 0)
+	return tw.Flush()
+}
+
+func (p *Profile) String() string {
+	var b strings.Builder
+	p.WriteText(&b)
+	return b.String()
+}
+
+//-----------------------------------------------------------------------------
+// pprof Output
+
+// pbuf is a minimal protocol buffer encoder.
+type pbuf struct {
+	bytes.Buffer
+}
+
+func (b *pbuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.WriteByte(byte(x) | 0x80)
+		x >>= 7
+	}
+	b.WriteByte(byte(x))
+}
+
+// uint64Field writes a varint field.
+func (b *pbuf) uint64Field(tag int, x uint64) {
+	b.varint(uint64(tag) << 3)
+	b.varint(x)
+}
+
+// bytesField writes a length delimited field.
+func (b *pbuf) bytesField(tag int, x []byte) {
+	b.varint(uint64(tag)<<3 | 2)
+	b.varint(uint64(len(x)))
+	b.Write(x)
+}
+
+// packedField writes a packed repeated varint field.
+func (b *pbuf) packedField(tag int, x []uint64) {
+	var p pbuf
+	for _, v := range x {
+		p.varint(v)
+	}
+	b.bytesField(tag, p.Bytes())
+}
+
+// WritePprof writes the profile results as a (gzipped) pprof profile.
+// The sample values are the evaluation count and the self time of each node,
+// the call stack is the path from the node to the root of the SDF tree.
+func (p *Profile) WritePprof(w io.Writer) error {
+	// pprof profile.proto field numbers
+	const (
+		profileSampleType    = 1
+		profileSample        = 2
+		profileLocation      = 4
+		profileFunction      = 5
+		profileStringTable   = 6
+		profileTimeNanos     = 9
+		profileDurationNanos = 10
+		valueTypeType        = 1
+		valueTypeUnit        = 2
+		sampleLocationID     = 1
+		sampleValue          = 2
+		locationID           = 1
+		locationLine         = 4
+		lineFunctionID       = 1
+		functionID           = 1
+		functionName         = 2
+		functionSystemName   = 3
+	)
+
+	strs := []string{""}
+	strIndex := map[string]uint64{"": 0}
+	str := func(s string) uint64 {
+		if i, ok := strIndex[s]; ok {
+			return i
+		}
+		strIndex[s] = uint64(len(strs))
+		strs = append(strs, s)
+		return strIndex[s]
+	}
+
+	var out pbuf
+	valueType := func(typ, unit string) []byte {
+		var b pbuf
+		b.uint64Field(valueTypeType, str(typ))
+		b.uint64Field(valueTypeUnit, str(unit))
+		return b.Bytes()
+	}
+	out.bytesField(profileSampleType, valueType("evaluations", "count"))
+	out.bytesField(profileSampleType, valueType("time", "nanoseconds"))
+
+	var walk func(n *ProfileNode, stack []uint64)
+	walk = func(n *ProfileNode, stack []uint64) {
+		id := uint64(n.ID + 1)
+		name := fmt.Sprintf("%s #%d", n.Name, n.ID)
+		// function
+		var f pbuf
+		f.uint64Field(functionID, id)
+		f.uint64Field(functionName, str(name))
+		f.uint64Field(functionSystemName, str(n.Name))
+		out.bytesField(profileFunction, f.Bytes())
+		// location
+		var line pbuf
+		line.uint64Field(lineFunctionID, id)
+		var l pbuf
+		l.uint64Field(locationID, id)
+		l.bytesField(locationLine, line.Bytes())
+		out.bytesField(profileLocation, l.Bytes())
+		// sample, the stack is leaf first
+		stack = append([]uint64{id}, stack...)
+		var s pbuf
+		s.packedField(sampleLocationID, stack)
+		s.packedField(sampleValue, []uint64{n.Evals, uint64(n.Self())})
+		out.bytesField(profileSample, s.Bytes())
+		for _, c := range n.Children {
+			walk(c, stack)
+		}
+	}
+	walk(p.Tree(), nil)
+
+	out.uint64Field(profileTimeNanos, uint64(p.start.UnixNano()))
+	out.uint64Field(profileDurationNanos, uint64(time.Since(p.start)))
+	for _, s := range strs {
+		out.bytesField(profileStringTable, []byte(s))
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(out.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+//-----------------------------------------------------------------------------
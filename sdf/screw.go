@@ -11,9 +11,10 @@ the x-axis as the screw axis. Most thread profiles are symmetric about the y-axi
 but a few aren't (E.g. buttress threads) so in general we build the profile of
 an entire pitch period.
 
-This code doesn't deal with thread tolerancing. If you want threads to fit properly
-the radius of the thread will need to be tweaked (+/-) to give internal/external thread
-clearance.
+Internal thread profiles are the shape of the tapped hole. They have the basic crest
+and root clearances of their thread standard, but this code doesn't deal with thread
+tolerancing. If you want threads to fit properly the radius of the thread will need
+to be tweaked (+/-) to give internal/external thread clearance.
 
 */
 //-----------------------------------------------------------------------------
@@ -24,6 +25,8 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
+	"strings"
 
 	v2 "github.com/deadsy/sdfx/vec/v2"
 	v3 "github.com/deadsy/sdfx/vec/v3"
@@ -35,6 +38,8 @@ import (
 // ThreadParameters stores the values that define a thread.
 type ThreadParameters struct {
 	Name         string  // name of screw thread
+	Family       string  // thread family, e.g. "iso", "unc", "bspp", "tr"
+	Form         string  // thread form, e.g. "iso", "whitworth", "acme"
 	Radius       float64 // nominal major radius of screw
 	Pitch        float64 // thread to thread distance of screw
 	Taper        float64 // thread taper (radians)
@@ -49,6 +54,8 @@ func (t *ThreadParameters) ToMillimetre() *ThreadParameters {
 	}
 	return &ThreadParameters{
 		Name:         t.Name,
+		Family:       t.Family,
+		Form:         t.Form,
 		Radius:       t.Radius * MillimetresPerInch,
 		Pitch:        t.Pitch * MillimetresPerInch,
 		Taper:        t.Taper,
@@ -61,23 +68,34 @@ type threadDatabase map[string]*ThreadParameters
 
 var threadDB = initThreadLookup()
 
+// add adds a thread to the thread database.
+func (m threadDatabase) add(t *ThreadParameters) {
+	if t.HexFlat2Flat <= 0 {
+		log.Panicf("bad flat to flat distance for thread \"%s\"", t.Name)
+	}
+	if _, ok := m[t.Name]; ok {
+		log.Panicf("duplicate thread \"%s\"", t.Name)
+	}
+	m[t.Name] = t
+}
+
 // UTSAdd adds a Unified Thread Standard to the thread database.
+// The family ("unc", "unf") is the prefix of the thread name.
 func (m threadDatabase) UTSAdd(
 	name string, // thread name
 	diameter float64, // screw major diameter
 	tpi float64, // threads per inch
 	ftof float64, // hex head flat to flat distance
 ) {
-	if ftof <= 0 {
-		log.Panicf("bad flat to flat distance for thread \"%s\"", name)
-	}
-	t := ThreadParameters{}
-	t.Name = name
-	t.Radius = 0.5 * diameter
-	t.Pitch = 1.0 / tpi
-	t.HexFlat2Flat = ftof
-	t.Units = "inch"
-	m[name] = &t
+	m.add(&ThreadParameters{
+		Name:         name,
+		Family:       strings.SplitN(name, "_", 2)[0],
+		Form:         "iso",
+		Radius:       0.5 * diameter,
+		Pitch:        1.0 / tpi,
+		HexFlat2Flat: ftof,
+		Units:        "inch",
+	})
 }
 
 // ISOAdd adds an ISO Thread Standard to the thread database.
@@ -87,16 +105,15 @@ func (m threadDatabase) ISOAdd(
 	pitch float64, // thread pitch
 	ftof float64, // hex head flat to flat distance
 ) {
-	if ftof <= 0 {
-		log.Panicf("bad flat to flat distance for thread \"%s\"", name)
-	}
-	t := ThreadParameters{}
-	t.Name = name
-	t.Radius = 0.5 * diameter
-	t.Pitch = pitch
-	t.HexFlat2Flat = ftof
-	t.Units = "mm"
-	m[name] = &t
+	m.add(&ThreadParameters{
+		Name:         name,
+		Family:       "iso",
+		Form:         "iso",
+		Radius:       0.5 * diameter,
+		Pitch:        pitch,
+		HexFlat2Flat: ftof,
+		Units:        "mm",
+	})
 }
 
 // NPTAdd adds an National Pipe Thread to the thread database.
@@ -106,27 +123,192 @@ func (m threadDatabase) NPTAdd(
 	tpi float64, // threads per inch
 	ftof float64, // hex head flat to flat distance
 ) {
-	if ftof <= 0 {
-		log.Panicf("bad flat to flat distance for thread \"%s\"", name)
-	}
-	t := ThreadParameters{}
-	t.Name = name
-	t.Radius = 0.5 * diameter
-	t.Pitch = 1.0 / tpi
-	t.Taper = math.Atan(1.0 / 32.0)
-	t.HexFlat2Flat = ftof
-	t.Units = "inch"
-	m[name] = &t
+	m.add(&ThreadParameters{
+		Name:         name,
+		Family:       "npt",
+		Form:         "iso",
+		Radius:       0.5 * diameter,
+		Pitch:        1.0 / tpi,
+		Taper:        math.Atan(1.0 / 32.0),
+		HexFlat2Flat: ftof,
+		Units:        "inch",
+	})
+}
+
+// BSWAdd adds a British Standard Whitworth/Fine thread to the thread database.
+// The family ("bsw", "bsf") is the prefix of the thread name.
+func (m threadDatabase) BSWAdd(
+	name string, // thread name
+	diameter float64, // screw major diameter
+	tpi float64, // threads per inch
+	ftof float64, // hex head flat to flat distance
+) {
+	m.add(&ThreadParameters{
+		Name:         name,
+		Family:       strings.SplitN(name, "_", 2)[0],
+		Form:         "whitworth",
+		Radius:       0.5 * diameter,
+		Pitch:        1.0 / tpi,
+		HexFlat2Flat: ftof,
+		Units:        "inch",
+	})
+}
+
+// BSPAdd adds a British Standard Pipe thread to the thread database.
+// Parallel threads (ISO 228) are named "G<size>", tapered threads (ISO 7) are named "R<size>".
+func (m threadDatabase) BSPAdd(
+	size string, // nominal pipe size
+	diameter float64, // screw major diameter (mm)
+	tpi float64, // threads per inch
+	ftof float64, // hex head flat to flat distance (mm)
+) {
+	m.add(&ThreadParameters{
+		Name:         "G" + size,
+		Family:       "bspp",
+		Form:         "whitworth",
+		Radius:       0.5 * diameter,
+		Pitch:        MillimetresPerInch / tpi,
+		HexFlat2Flat: ftof,
+		Units:        "mm",
+	})
+	m.add(&ThreadParameters{
+		Name:         "R" + size,
+		Family:       "bspt",
+		Form:         "whitworth",
+		Radius:       0.5 * diameter,
+		Pitch:        MillimetresPerInch / tpi,
+		Taper:        math.Atan(1.0 / 32.0),
+		HexFlat2Flat: ftof,
+		Units:        "mm",
+	})
+}
+
+// TrAdd adds an ISO 2904 metric trapezoidal thread to the thread database.
+func (m threadDatabase) TrAdd(
+	diameter float64, // screw major diameter
+	pitch float64, // thread pitch
+) {
+	m.add(&ThreadParameters{
+		Name:         fmt.Sprintf("Tr%gx%g", diameter, pitch),
+		Family:       "tr",
+		Form:         "trapezoidal",
+		Radius:       0.5 * diameter,
+		Pitch:        pitch,
+		HexFlat2Flat: 1.5 * diameter, // no standard, for nuts only
+		Units:        "mm",
+	})
+}
+
+// AcmeAdd adds an ASME B1.5 general purpose acme thread to the thread database.
+func (m threadDatabase) AcmeAdd(
+	name string, // thread name
+	diameter float64, // screw major diameter
+	tpi float64, // threads per inch
+) {
+	m.add(&ThreadParameters{
+		Name:         name,
+		Family:       "acme",
+		Form:         "acme",
+		Radius:       0.5 * diameter,
+		Pitch:        1.0 / tpi,
+		HexFlat2Flat: 1.5 * diameter, // no standard, for nuts only
+		Units:        "inch",
+	})
+}
+
+// ButtressAdd adds a buttress thread to the thread database.
+// The thread form ("ansi_buttress", "plastic_buttress") is also the family.
+func (m threadDatabase) ButtressAdd(
+	name string, // thread name
+	form string, // thread form
+	diameter float64, // screw major diameter
+	pitch float64, // thread pitch
+	units string, // "inch" or "mm"
+) {
+	m.add(&ThreadParameters{
+		Name:         name,
+		Family:       form,
+		Form:         form,
+		Radius:       0.5 * diameter,
+		Pitch:        pitch,
+		HexFlat2Flat: 1.5 * diameter, // no standard, for nuts only
+		Units:        units,
+	})
+}
+
+// isoThreads is the ISO 261 table of metric screw threads.
+// The hex flat to flat sizes are from ISO 4032 (DIN 934 below M1.6 and for M3, M10 and M12).
+// ISO 4032 has no nuts for the "next size" diameters, they use the hex of the
+// next larger ISO 4032 size.
+var isoThreads = []struct {
+	diameter float64   // major diameter
+	ftof     float64   // hex flat to flat
+	coarse   float64   // coarse pitch (0 = none)
+	fine     []float64 // fine pitches
+}{
+	{1, 2.5, 0.25, []float64{0.2}},
+	{1.1, 3, 0.25, []float64{0.2}}, // next size
+	{1.2, 3, 0.25, []float64{0.2}},
+	{1.4, 3, 0.3, []float64{0.2}},
+	{1.6, 3.2, 0.35, []float64{0.2}},
+	{1.8, 4, 0.35, []float64{0.2}}, // next size
+	{2, 4, 0.4, []float64{0.25}},
+	{2.2, 5, 0.45, []float64{0.25}}, // next size
+	{2.5, 5, 0.45, []float64{0.35}},
+	{3, 6, 0.5, []float64{0.35}},
+	{3.5, 6, 0.6, []float64{0.35}},
+	{4, 7, 0.7, []float64{0.5}},
+	{4.5, 8, 0.75, []float64{0.5}}, // next size
+	{5, 8, 0.8, []float64{0.5}},
+	{5.5, 10, 0, []float64{0.5}}, // next size
+	{6, 10, 1, []float64{0.75}},
+	{7, 11, 1, []float64{0.75}},
+	{8, 13, 1.25, []float64{1, 0.75}},
+	{9, 17, 1.25, []float64{1, 0.75}}, // next size
+	{10, 17, 1.5, []float64{1.25, 1, 0.75}},
+	{11, 19, 1.5, []float64{1, 0.75}}, // next size
+	{12, 19, 1.75, []float64{1.5, 1.25, 1}},
+	{14, 21, 2, []float64{1.5, 1.25, 1}},
+	{15, 24, 0, []float64{1.5, 1}}, // next size
+	{16, 24, 2, []float64{1.5, 1}},
+	{17, 27, 0, []float64{1.5, 1}}, // next size
+	{18, 27, 2.5, []float64{2, 1.5, 1}},
+	{20, 30, 2.5, []float64{2, 1.5, 1}},
+	{22, 34, 2.5, []float64{2, 1.5, 1}},
+	{24, 36, 3, []float64{2, 1.5, 1}},
+	{25, 41, 0, []float64{2, 1.5, 1}}, // next size
+	{26, 41, 0, []float64{1.5}},       // next size
+	{27, 41, 3, []float64{2, 1.5, 1}},
+	{28, 46, 0, []float64{2, 1.5, 1}}, // next size
+	{30, 46, 3.5, []float64{3, 2, 1.5, 1}},
+	{32, 50, 0, []float64{2, 1.5}}, // next size
+	{33, 50, 3.5, []float64{3, 2, 1.5}},
+	{35, 55, 0, []float64{1.5}}, // next size
+	{36, 55, 4, []float64{3, 2, 1.5}},
+	{38, 60, 0, []float64{1.5}}, // next size
+	{39, 60, 4, []float64{3, 2, 1.5}},
+	{40, 65, 0, []float64{3, 2, 1.5}}, // next size
+	{42, 65, 4.5, []float64{4, 3, 2, 1.5}},
+	{45, 70, 4.5, []float64{4, 3, 2, 1.5}},
+	{48, 75, 5, []float64{4, 3, 2, 1.5}},
+	{50, 80, 0, []float64{3, 2, 1.5}}, // next size
+	{52, 80, 5, []float64{4, 3, 2, 1.5}},
+	{55, 85, 0, []float64{4, 3, 2, 1.5}}, // next size
+	{56, 85, 5.5, []float64{4, 3, 2, 1.5}},
+	{58, 90, 0, []float64{4, 3, 2, 1.5}}, // next size
+	{60, 90, 5.5, []float64{4, 3, 2, 1.5}},
+	{62, 95, 0, []float64{4, 3, 2, 1.5}}, // next size
+	{64, 95, 6, []float64{4, 3, 2, 1.5}},
 }
 
 // initThreadLookup adds a collection of standard threads to the thread database.
 func initThreadLookup() threadDatabase {
 	m := make(threadDatabase)
 	// UTS Coarse
-	m.UTSAdd("unc_4_40", 0.112, 40, 0.183) // ftof?
-	m.UTSAdd("unc_6_32", 0.138, 32, 0.226) // ftof?
-	m.UTSAdd("unc_8_32", 0.164, 32, 0.27)  // ftof?
-	m.UTSAdd("unc_10_24", 0.19, 24, 0.312) // ftof?
+	m.UTSAdd("unc_4_40", 0.112, 40, 1.0/4.0)
+	m.UTSAdd("unc_6_32", 0.138, 32, 5.0/16.0)
+	m.UTSAdd("unc_8_32", 0.164, 32, 11.0/32.0)
+	m.UTSAdd("unc_10_24", 0.19, 24, 3.0/8.0)
 	m.UTSAdd("unc_1/4", 1.0/4.0, 20, 7.0/16.0)
 	m.UTSAdd("unc_5/16", 5.0/16.0, 18, 1.0/2.0)
 	m.UTSAdd("unc_3/8", 3.0/8.0, 16, 9.0/16.0)
@@ -139,10 +321,10 @@ func initThreadLookup() threadDatabase {
 	m.UTSAdd("unc_1", 1.0, 8, 3.0/2.0)
 
 	// UTS Fine
-	m.UTSAdd("unf_4_48", 0.112, 48, 0.183) // ftof?
-	m.UTSAdd("unf_6_40", 0.138, 40, 0.226) // ftof?
-	m.UTSAdd("unf_8_36", 0.164, 36, 0.27)  // ftof?
-	m.UTSAdd("unf_10_32", 0.19, 32, 0.312) // ftof?
+	m.UTSAdd("unf_4_48", 0.112, 48, 1.0/4.0)
+	m.UTSAdd("unf_6_40", 0.138, 40, 5.0/16.0)
+	m.UTSAdd("unf_8_36", 0.164, 36, 11.0/32.0)
+	m.UTSAdd("unf_10_32", 0.19, 32, 3.0/8.0)
 	m.UTSAdd("unf_1/4", 1.0/4.0, 28, 7.0/16.0)
 	m.UTSAdd("unf_5/16", 5.0/16.0, 24, 1.0/2.0)
 	m.UTSAdd("unf_3/8", 3.0/8.0, 24, 9.0/16.0)
@@ -168,51 +350,112 @@ func initThreadLookup() threadDatabase {
 	m.NPTAdd("npt_3", 3.500, 8, 88.9*InchesPerMillimetre)
 	m.NPTAdd("npt_4", 4.500, 8, 117.3*InchesPerMillimetre)
 
-	// ISO Coarse
-	m.ISOAdd("M1x0.25", 1, 0.25, 1.75)    // ftof?
-	m.ISOAdd("M1.2x0.25", 1.2, 0.25, 2.0) // ftof?
-	m.ISOAdd("M1.6x0.35", 1.6, 0.35, 3.2)
-	m.ISOAdd("M2x0.4", 2, 0.4, 4)
-	m.ISOAdd("M2.5x0.45", 2.5, 0.45, 5)
-	m.ISOAdd("M3x0.5", 3, 0.5, 6)
-	m.ISOAdd("M4x0.7", 4, 0.7, 7)
-	m.ISOAdd("M5x0.8", 5, 0.8, 8)
-	m.ISOAdd("M6x1", 6, 1, 10)
-	m.ISOAdd("M8x1.25", 8, 1.25, 13)
-	m.ISOAdd("M10x1.5", 10, 1.5, 17)
-	m.ISOAdd("M12x1.75", 12, 1.75, 19)
-	m.ISOAdd("M16x2", 16, 2, 24)
-	m.ISOAdd("M20x2.5", 20, 2.5, 30)
-	m.ISOAdd("M24x3", 24, 3, 36)
-	m.ISOAdd("M30x3.5", 30, 3.5, 46)
-	m.ISOAdd("M36x4", 36, 4, 55)
-	m.ISOAdd("M42x4.5", 42, 4.5, 65)
-	m.ISOAdd("M48x5", 48, 5, 75)
-	m.ISOAdd("M56x5.5", 56, 5.5, 85)
-	m.ISOAdd("M64x6", 64, 6, 95)
-
-	// ISO Fine
-	m.ISOAdd("M1x0.2", 1, 0.2, 1.75)    // ftof?
-	m.ISOAdd("M1.2x0.2", 1.2, 0.2, 2.0) // ftof?
-	m.ISOAdd("M1.6x0.2", 1.6, 0.2, 3.2)
-	m.ISOAdd("M2x0.25", 2, 0.25, 4)
-	m.ISOAdd("M2.5x0.35", 2.5, 0.35, 5)
-	m.ISOAdd("M3x0.35", 3, 0.35, 6)
-	m.ISOAdd("M4x0.5", 4, 0.5, 7)
-	m.ISOAdd("M5x0.5", 5, 0.5, 8)
-	m.ISOAdd("M6x0.75", 6, 0.75, 10)
-	m.ISOAdd("M8x1", 8, 1, 13)
-	m.ISOAdd("M10x1.25", 10, 1.25, 17)
-	m.ISOAdd("M12x1.5", 12, 1.5, 19)
-	m.ISOAdd("M16x1.5", 16, 1.5, 24)
-	m.ISOAdd("M20x2", 20, 2, 30)
-	m.ISOAdd("M24x2", 24, 2, 36)
-	m.ISOAdd("M30x2", 30, 2, 46)
-	m.ISOAdd("M36x3", 36, 3, 55)
-	m.ISOAdd("M42x3", 42, 3, 65)
-	m.ISOAdd("M48x3", 48, 3, 75)
-	m.ISOAdd("M56x4", 56, 4, 85)
-	m.ISOAdd("M64x4", 64, 4, 95)
+	// ISO 261 Coarse and Fine, named "M<diameter>x<pitch>"
+	for _, k := range isoThreads {
+		if k.coarse != 0 {
+			m.ISOAdd(fmt.Sprintf("M%gx%g", k.diameter, k.coarse), k.diameter, k.coarse, k.ftof)
+		}
+		for _, pitch := range k.fine {
+			m.ISOAdd(fmt.Sprintf("M%gx%g", k.diameter, pitch), k.diameter, pitch, k.ftof)
+		}
+	}
+
+	// British Standard Whitworth. Flat to flat from BS 1083.
+	m.BSWAdd("bsw_1/8", 1.0/8.0, 40, 0.338) // not in BS 1083
+	m.BSWAdd("bsw_3/16", 3.0/16.0, 24, 0.445)
+	m.BSWAdd("bsw_1/4", 1.0/4.0, 20, 0.525)
+	m.BSWAdd("bsw_5/16", 5.0/16.0, 18, 0.600)
+	m.BSWAdd("bsw_3/8", 3.0/8.0, 16, 0.710)
+	m.BSWAdd("bsw_7/16", 7.0/16.0, 14, 0.820)
+	m.BSWAdd("bsw_1/2", 1.0/2.0, 12, 0.920)
+	m.BSWAdd("bsw_9/16", 9.0/16.0, 12, 1.010)
+	m.BSWAdd("bsw_5/8", 5.0/8.0, 11, 1.100)
+	m.BSWAdd("bsw_3/4", 3.0/4.0, 10, 1.300)
+	m.BSWAdd("bsw_7/8", 7.0/8.0, 9, 1.480)
+	m.BSWAdd("bsw_1", 1.0, 8, 1.670)
+	m.BSWAdd("bsw_1_1/4", 5.0/4.0, 7, 2.050)
+	m.BSWAdd("bsw_1_1/2", 3.0/2.0, 6, 2.410)
+	m.BSWAdd("bsw_2", 2.0, 4.5, 3.150)
+
+	// British Standard Fine. Same heads as BSW.
+	m.BSWAdd("bsf_3/16", 3.0/16.0, 32, 0.445)
+	m.BSWAdd("bsf_1/4", 1.0/4.0, 26, 0.525)
+	m.BSWAdd("bsf_5/16", 5.0/16.0, 22, 0.600)
+	m.BSWAdd("bsf_3/8", 3.0/8.0, 20, 0.710)
+	m.BSWAdd("bsf_7/16", 7.0/16.0, 18, 0.820)
+	m.BSWAdd("bsf_1/2", 1.0/2.0, 16, 0.920)
+	m.BSWAdd("bsf_9/16", 9.0/16.0, 16, 1.010)
+	m.BSWAdd("bsf_5/8", 5.0/8.0, 14, 1.100)
+	m.BSWAdd("bsf_3/4", 3.0/4.0, 12, 1.300)
+	m.BSWAdd("bsf_7/8", 7.0/8.0, 11, 1.480)
+	m.BSWAdd("bsf_1", 1.0, 10, 1.670)
+
+	// British Standard Pipe. Parallel (G) and Taper (R). Flat to flat from fitting plugs (mm).
+	m.BSPAdd("1/16", 7.723, 28, 12) // no standard plug, approximate
+	m.BSPAdd("1/8", 9.728, 28, 14)
+	m.BSPAdd("1/4", 13.157, 19, 19)
+	m.BSPAdd("3/8", 16.662, 19, 22)
+	m.BSPAdd("1/2", 20.955, 14, 27)
+	m.BSPAdd("5/8", 22.911, 14, 30) // no standard plug, approximate
+	m.BSPAdd("3/4", 26.441, 14, 32)
+	m.BSPAdd("1", 33.249, 11, 41)
+	m.BSPAdd("1_1/4", 41.910, 11, 50)
+	m.BSPAdd("1_1/2", 47.803, 11, 55)
+	m.BSPAdd("2", 59.614, 11, 70)
+	m.BSPAdd("2_1/2", 75.184, 11, 85)
+	m.BSPAdd("3", 87.884, 11, 100)
+	m.BSPAdd("4", 113.030, 11, 130)
+
+	// ISO 2904 Metric Trapezoidal, plus the common 3d printer lead screws.
+	m.TrAdd(8, 1.5)
+	m.TrAdd(8, 2)
+	m.TrAdd(10, 2)
+	m.TrAdd(10, 3)
+	m.TrAdd(12, 2)
+	m.TrAdd(12, 3)
+	m.TrAdd(14, 3)
+	m.TrAdd(16, 4)
+	m.TrAdd(18, 4)
+	m.TrAdd(20, 4)
+	m.TrAdd(22, 5)
+	m.TrAdd(24, 5)
+	m.TrAdd(26, 5)
+	m.TrAdd(28, 5)
+	m.TrAdd(30, 6)
+	m.TrAdd(32, 6)
+	m.TrAdd(36, 6)
+	m.TrAdd(40, 7)
+	m.TrAdd(44, 7)
+	m.TrAdd(48, 8)
+	m.TrAdd(50, 8)
+	m.TrAdd(52, 8)
+	m.TrAdd(60, 9)
+
+	// ASME B1.5 General Purpose Acme
+	m.AcmeAdd("acme_1/4", 1.0/4.0, 16)
+	m.AcmeAdd("acme_5/16", 5.0/16.0, 14)
+	m.AcmeAdd("acme_3/8", 3.0/8.0, 12)
+	m.AcmeAdd("acme_7/16", 7.0/16.0, 12)
+	m.AcmeAdd("acme_1/2", 1.0/2.0, 10)
+	m.AcmeAdd("acme_5/8", 5.0/8.0, 8)
+	m.AcmeAdd("acme_3/4", 3.0/4.0, 6)
+	m.AcmeAdd("acme_7/8", 7.0/8.0, 6)
+	m.AcmeAdd("acme_1", 1.0, 5)
+	m.AcmeAdd("acme_1_1/4", 5.0/4.0, 5)
+	m.AcmeAdd("acme_1_1/2", 3.0/2.0, 4)
+	m.AcmeAdd("acme_1_3/4", 7.0/4.0, 4)
+	m.AcmeAdd("acme_2", 2.0, 4)
+
+	// ANSI 45/7 Buttress (ASME B1.9), one of the recommended pitches per diameter.
+	m.ButtressAdd("ansi_buttress_1/2", "ansi_buttress", 1.0/2.0, 1.0/16.0, "inch")
+	m.ButtressAdd("ansi_buttress_3/4", "ansi_buttress", 3.0/4.0, 1.0/16.0, "inch")
+	m.ButtressAdd("ansi_buttress_1", "ansi_buttress", 1.0, 1.0/12.0, "inch")
+	m.ButtressAdd("ansi_buttress_1_1/4", "ansi_buttress", 5.0/4.0, 1.0/10.0, "inch")
+	m.ButtressAdd("ansi_buttress_1_1/2", "ansi_buttress", 3.0/2.0, 1.0/8.0, "inch")
+	m.ButtressAdd("ansi_buttress_2", "ansi_buttress", 2.0, 1.0/8.0, "inch")
+
+	// Plastic Buttress (mm). E.g. gas can caps.
+	m.ButtressAdd("plastic_buttress_48.5x6", "plastic_buttress", 48.5, 6, "mm")
 	return m
 }
 
@@ -224,6 +467,49 @@ func ThreadLookup(name string) (*ThreadParameters, error) {
 	return nil, fmt.Errorf("thread \"%s\" not found", name)
 }
 
+// ThreadFamilies returns the sorted names of the thread families in the database.
+func ThreadFamilies() []string {
+	set := make(map[string]bool)
+	for _, t := range threadDB {
+		set[t.Family] = true
+	}
+	families := make([]string, 0, len(set))
+	for k := range set {
+		families = append(families, k)
+	}
+	sort.Strings(families)
+	return families
+}
+
+// ThreadQuery returns the threads of a family ("" for all families) with a
+// major diameter (in mm) within [dMin, dMax]. The result is sorted by diameter,
+// then by decreasing pitch (coarse first).
+func ThreadQuery(family string, dMin, dMax float64) []*ThreadParameters {
+	threads := []*ThreadParameters{}
+	for _, t := range threadDB {
+		if family != "" && t.Family != family {
+			continue
+		}
+		d := 2.0 * t.ToMillimetre().Radius
+		if d < dMin || d > dMax {
+			continue
+		}
+		threads = append(threads, t)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		ti := threads[i].ToMillimetre()
+		tj := threads[j].ToMillimetre()
+		if ti.Radius != tj.Radius {
+			return ti.Radius < tj.Radius
+		}
+		if ti.Pitch != tj.Pitch {
+			return ti.Pitch > tj.Pitch
+		}
+		return ti.Name < tj.Name
+	})
+	return threads
+}
+
 // HexRadius returns the hex head radius.
 func (t *ThreadParameters) HexRadius() float64 {
 	return t.HexFlat2Flat / (2.0 * math.Cos(DtoR(30)))
@@ -234,32 +520,154 @@ func (t *ThreadParameters) HexHeight() float64 {
 	return 2.0 * t.HexRadius() * (5.0 / 12.0)
 }
 
+// Profile returns the 2d thread profile for the thread form of this thread.
+// The radius is normally the thread radius adjusted for any tolerance.
+// Internal profiles are the shape of the tapped hole, they are truncated at
+// the minor diameter and have the standard crest clearance (where there is one)
+// at the major diameter.
+func (t *ThreadParameters) Profile(
+	radius float64, // radius of thread
+	external bool, // external (or internal) thread
+) (SDF2, error) {
+	switch t.Form {
+	case "iso":
+		return ISOThread(radius, t.Pitch, external)
+	case "whitworth":
+		return WhitworthThread(radius, t.Pitch, external)
+	case "acme":
+		return trapezoidThread(radius, t.Pitch, 29.0, t.acmeClearance(), external)
+	case "trapezoidal":
+		return TrapezoidalThread(radius, t.Pitch, external)
+	case "ansi_buttress":
+		return buttressThread(radius, t.Pitch, 0, 0.0714*t.Pitch, 0, external)
+	case "plastic_buttress":
+		return buttressThread(radius, t.Pitch, 0.05*t.Pitch, 0.15*t.Pitch, 0.15*t.Pitch, external)
+	}
+	return nil, fmt.Errorf("unknown thread form \"%s\"", t.Form)
+}
+
+// acmeClearance returns the ASME B1.5 general purpose acme crest clearance.
+// The minimum major diameter clearance is 0.020" for 10 tpi and coarser, else 0.010".
+func (t *ThreadParameters) acmeClearance() float64 {
+	pitch := t.Pitch
+	c := 0.5 * 0.010
+	if t.Units == "mm" {
+		pitch *= InchesPerMillimetre
+		c *= MillimetresPerInch
+	}
+	if pitch >= 0.1 {
+		c *= 2.0
+	}
+	return c
+}
+
 //-----------------------------------------------------------------------------
 // Thread Profiles
 
+// trapezoidThread returns the 2d profile for a trapezoidal thread with
+// the given included thread angle. The clearance is the radial crest
+// clearance, it deepens the root of an external thread and enlarges the major
+// diameter of an internal thread.
+func trapezoidThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+	angle float64, // included thread angle (degrees)
+	clearance float64, // radial crest clearance
+	external bool, // external (or internal) thread
+) (SDF2, error) {
+
+	theta := DtoR(angle / 2.0)
+	delta := 0.25 * pitch * math.Tan(theta)
+	// the flanks cross the pitch line at +/- pitch/4
+	var rMinor, rMajor, xMinor, xMajor float64
+	if external {
+		rMinor = radius - 0.5*pitch - clearance
+		rMajor = radius
+		xMinor = 0.25*pitch + delta + clearance*math.Tan(theta)
+		xMajor = 0.25*pitch - delta
+	} else {
+		rMinor = radius - 0.5*pitch
+		rMajor = radius + clearance
+		xMinor = 0.25*pitch + delta
+		xMajor = 0.25*pitch - delta - clearance*math.Tan(theta)
+	}
+
+	p := NewPolygon()
+	p.Add(radius, 0)
+	p.Add(radius, rMinor)
+	p.Add(xMinor, rMinor)
+	p.Add(xMajor, rMajor)
+	p.Add(-xMajor, rMajor)
+	p.Add(-xMinor, rMinor)
+	p.Add(-radius, rMinor)
+	p.Add(-radius, 0)
+
+	return Polygon2D(p.Vertices())
+}
+
 // AcmeThread returns the 2d profile for an acme thread.
 func AcmeThread(
 	radius float64, // radius of thread
 	pitch float64, // thread to thread distance
 ) (SDF2, error) {
+	return trapezoidThread(radius, pitch, 29.0, 0, true)
+}
 
-	h := radius - 0.5*pitch
-	theta := DtoR(29.0 / 2.0)
-	delta := 0.25 * pitch * math.Tan(theta)
-	xOfs0 := 0.25*pitch - delta
-	xOfs1 := 0.25*pitch + delta
+// TrapezoidalThread returns the 2d profile for an ISO 2904 metric trapezoidal thread.
+// This is the metric version of the acme thread with a 30 degree thread angle.
+func TrapezoidalThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+	external bool, // external (or internal) thread
+) (SDF2, error) {
+	// ISO 2904 crest clearance
+	var ac float64
+	switch {
+	case pitch <= 1.5:
+		ac = 0.15
+	case pitch <= 5:
+		ac = 0.25
+	case pitch <= 12:
+		ac = 0.5
+	default:
+		ac = 1
+	}
+	return trapezoidThread(radius, pitch, 30.0, ac, external)
+}
 
-	acme := NewPolygon()
-	acme.Add(radius, 0)
-	acme.Add(radius, h)
-	acme.Add(xOfs1, h)
-	acme.Add(xOfs0, radius)
-	acme.Add(-xOfs0, radius)
-	acme.Add(-xOfs1, h)
-	acme.Add(-radius, h)
-	acme.Add(-radius, 0)
+// WhitworthThread returns the 2d profile for a Whitworth (BSW/BSF/BSP) thread.
+// The 55 degree thread has rounded crests and roots, 1/6 of the fundamental
+// triangle height is removed from each. The internal thread has a tighter root
+// radius to clear the external crest.
+// https://en.wikipedia.org/wiki/British_Standard_Whitworth
+func WhitworthThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+	external bool, // external (or internal) thread
+) (SDF2, error) {
+
+	theta := DtoR(55.0 / 2.0)
+	h := pitch / (2.0 * math.Tan(theta))
+	r := 0.137329 * pitch // crest and root radius
+	rCrest := radius + h/6.0
+	rRoot := rCrest - h
+
+	// the internal thread has a tighter root to clear the external crest
+	rc := r
+	if !external {
+		rc = 0.5 * r
+	}
 
-	return Polygon2D(acme.Vertices())
+	bsw := NewPolygon()
+	bsw.Add(pitch, 0)
+	bsw.Add(pitch, rCrest)
+	bsw.Add(pitch/2.0, rRoot).Smooth(r, 5)
+	bsw.Add(0, rCrest).Smooth(rc, 5)
+	bsw.Add(-pitch/2.0, rRoot).Smooth(r, 5)
+	bsw.Add(-pitch, rCrest)
+	bsw.Add(-pitch, 0)
+
+	return Polygon2D(bsw.Vertices())
 }
 
 // ISOThread returns the 2d profile for an ISO/UTS thread.
@@ -303,12 +711,16 @@ func ISOThread(
 	return Polygon2D(iso.Vertices())
 }
 
-// ANSIButtressThread returns the 2d profile for an ANSI 45/7 buttress thread.
-// https://en.wikipedia.org/wiki/Buttress_thread
-// AMSE B1.9-1973
-func ANSIButtressThread(
+// buttressThread returns the 2d profile for a 45/7 buttress thread.
+// The crest corners and the root are rounded with the given radii. The internal
+// thread is truncated above the root rounding to give root clearance.
+func buttressThread(
 	radius float64, // radius of thread
 	pitch float64, // thread to thread distance
+	r7 float64, // 7 degree flank crest corner radius
+	rRoot float64, // root radius
+	r45 float64, // 45 degree flank crest corner radius
+	external bool, // external (or internal) thread
 ) (SDF2, error) {
 	t0 := math.Tan(DtoR(45.0))
 	t1 := math.Tan(DtoR(7.0))
@@ -321,39 +733,39 @@ func ANSIButtressThread(
 	tp := NewPolygon()
 	tp.Add(pitch, 0)
 	tp.Add(pitch, radius)
-	tp.Add(hp-((h0-h1)*t1), radius)
-	tp.Add(t0*h0-hp, radius-h1).Smooth(0.0714*pitch, 5)
-	tp.Add((h0-h1)*t0-hp, radius)
+	tp.Add(hp-((h0-h1)*t1), radius).Smooth(r7, 5)
+	if external {
+		tp.Add(t0*h0-hp, radius-h1).Smooth(rRoot, 5)
+	} else {
+		// truncate above the external root rounding (~0.6 pitch for ANSI 45/7)
+		d := rRoot * math.Cos(DtoR(7.0)) / math.Tan(DtoR(26.0))
+		tp.Add(t0*h0-hp+d*t1, radius-h1+d)
+		tp.Add(t0*h0-hp-d*t0, radius-h1+d)
+	}
+	tp.Add((h0-h1)*t0-hp, radius).Smooth(r45, 5)
 	tp.Add(-pitch, radius)
 	tp.Add(-pitch, 0)
 
 	return Polygon2D(tp.Vertices())
 }
 
+// ANSIButtressThread returns the 2d profile for an ANSI 45/7 buttress thread.
+// https://en.wikipedia.org/wiki/Buttress_thread
+// AMSE B1.9-1973
+func ANSIButtressThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+) (SDF2, error) {
+	return buttressThread(radius, pitch, 0, 0.0714*pitch, 0, true)
+}
+
 // PlasticButtressThread returns the 2d profile for a screw top style plastic buttress thread.
 // Similar to ANSI 45/7 - but with more corner rounding
 func PlasticButtressThread(
 	radius float64, // radius of thread
 	pitch float64, // thread to thread distance
 ) (SDF2, error) {
-	t0 := math.Tan(DtoR(45.0))
-	t1 := math.Tan(DtoR(7.0))
-	b := 0.6 // thread engagement
-
-	h0 := pitch / (t0 + t1)
-	h1 := ((b / 2.0) * pitch) + (0.5 * h0)
-	hp := pitch / 2.0
-
-	tp := NewPolygon()
-	tp.Add(pitch, 0)
-	tp.Add(pitch, radius)
-	tp.Add(hp-((h0-h1)*t1), radius).Smooth(0.05*pitch, 5)
-	tp.Add(t0*h0-hp, radius-h1).Smooth(0.15*pitch, 5)
-	tp.Add((h0-h1)*t0-hp, radius).Smooth(0.15*pitch, 5)
-	tp.Add(-pitch, radius)
-	tp.Add(-pitch, 0)
-
-	return Polygon2D(tp.Vertices())
+	return buttressThread(radius, pitch, 0.05*pitch, 0.15*pitch, 0.15*pitch, true)
 }
 
 //-----------------------------------------------------------------------------
@@ -148,6 +148,9 @@ type ExtrudeSDF3 struct {
 	sdf     SDF2
 	height  float64
 	extrude ExtrudeFunc
+	twist   float64 // twist over the height (introspection)
+	scale   v2.Vec  // scaling at the top (introspection)
+	custom  bool    // extrusion function set with SetExtrude
 	bb      Box3
 }
 
@@ -157,6 +160,7 @@ func Extrude3D(sdf SDF2, height float64) SDF3 {
 	s.sdf = sdf
 	s.height = height / 2
 	s.extrude = NormalExtrude
+	s.scale = v2.Vec{1, 1}
 	// work out the bounding box
 	bb := sdf.BoundingBox()
 	s.bb = Box3{v3.Vec{bb.Min.X, bb.Min.Y, -s.height}, v3.Vec{bb.Max.X, bb.Max.Y, s.height}}
@@ -169,6 +173,8 @@ func TwistExtrude3D(sdf SDF2, height, twist float64) SDF3 {
 	s.sdf = sdf
 	s.height = height / 2
 	s.extrude = TwistExtrude(height, twist)
+	s.twist = twist
+	s.scale = v2.Vec{1, 1}
 	// work out the bounding box
 	bb := sdf.BoundingBox()
 	l := bb.Max.Length()
@@ -182,6 +188,7 @@ func ScaleExtrude3D(sdf SDF2, height float64, scale v2.Vec) SDF3 {
 	s.sdf = sdf
 	s.height = height / 2
 	s.extrude = ScaleExtrude(height, scale)
+	s.scale = scale
 	// work out the bounding box
 	bb := sdf.BoundingBox()
 	bb = bb.Extend(Box2{bb.Min.Mul(scale), bb.Max.Mul(scale)})
@@ -195,6 +202,8 @@ func ScaleTwistExtrude3D(sdf SDF2, height, twist float64, scale v2.Vec) SDF3 {
 	s.sdf = sdf
 	s.height = height / 2
 	s.extrude = ScaleTwistExtrude(height, twist, scale)
+	s.twist = twist
+	s.scale = scale
 	// work out the bounding box
 	bb := sdf.BoundingBox()
 	bb = bb.Extend(Box2{bb.Min.Mul(scale), bb.Max.Mul(scale)})
@@ -216,6 +225,7 @@ func (s *ExtrudeSDF3) Evaluate(p v3.Vec) float64 {
 // SetExtrude sets the extrusion control function.
 func (s *ExtrudeSDF3) SetExtrude(extrude ExtrudeFunc) {
 	s.extrude = extrude
+	s.custom = true
 }
 
 // BoundingBox returns the bounding box for an extrusion.
@@ -1015,51 +1025,6 @@ func (s *RotateCopySDF3) BoundingBox() Box3 {
 
 //-----------------------------------------------------------------------------
 
-/* WIP
-
-// Connector3 defines a 3d connection point.
-type Connector3 struct {
-	Name     string
-	Position v3.Vec
-	Vector   v3.Vec
-	Angle    float64
-}
-
-// ConnectedSDF3 is an SDF3 with connection points defined.
-type ConnectedSDF3 struct {
-	sdf        SDF3
-	connectors []Connector3
-}
-
-// AddConnector adds connection points to an SDF3.
-func AddConnector(sdf SDF3, connectors ...Connector3) SDF3 {
-	// is the sdf already connected?
-	if s, ok := sdf.(*ConnectedSDF3); ok {
-		// append connection points
-		s.connectors = append(s.connectors, connectors...)
-		return s
-	}
-	// return a new connected sdf
-	return &ConnectedSDF3{
-		sdf:        sdf,
-		connectors: connectors,
-	}
-}
-
-// Evaluate returns the minimum distance to a connected SDF3.
-func (s *ConnectedSDF3) Evaluate(p v3.Vec) float64 {
-	return s.sdf.Evaluate(p)
-}
-
-// BoundingBox returns the bounding box of a connected SDF3.
-func (s *ConnectedSDF3) BoundingBox() Box3 {
-	return s.sdf.BoundingBox()
-}
-
-*/
-
-//-----------------------------------------------------------------------------
-
 // OffsetSDF3 offsets the distance function of an existing SDF3.
 type OffsetSDF3 struct {
 	sdf    SDF3    // the underlying SDF
@@ -5,14 +5,21 @@
 package sdf
 
 import (
+	"bytes"
+	"encoding/binary"
 	"math"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	v2 "github.com/deadsy/sdfx/vec/v2"
 	"github.com/deadsy/sdfx/vec/v2i"
 	v3 "github.com/deadsy/sdfx/vec/v3"
+	"github.com/deadsy/sdfx/vec/v3i"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
 )
 
 //-----------------------------------------------------------------------------
@@ -816,3 +823,981 @@ func Test_Normal(t *testing.T) {
 }
 
 //-----------------------------------------------------------------------------
+
+func Test_ThreadLookup(t *testing.T) {
+	for _, name := range []string{"M8x1.25", "M64x1.5", "Tr8x2", "G1/4", "R1/2", "bsw_1/4", "acme_1/2", "unc_4_40"} {
+		k, err := ThreadLookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := k.Profile(k.Radius, true); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+	threads := ThreadQuery("iso", 8, 10)
+	if len(threads) != 10 {
+		t.Fatal("expected 10 iso threads from 8 to 10 mm, got", len(threads))
+	}
+	if threads[0].Name != "M8x1.25" || threads[9].Name != "M10x0.75" {
+		t.Fatal("bad thread query order", threads[0].Name, threads[9].Name)
+	}
+	if len(ThreadQuery("tr", 0, 100)) == 0 {
+		t.Fatal("expected trapezoidal threads")
+	}
+}
+
+func Test_ThreadClearance(t *testing.T) {
+	// the internal profile (the tapped hole) must contain the external profile
+	for _, name := range []string{"M8x1.25", "bsw_1/4", "acme_1/2", "Tr8x2", "ansi_buttress_1/2", "plastic_buttress_48.5x6"} {
+		k, err := ThreadLookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k = k.ToMillimetre()
+		external, err := k.Profile(k.Radius, true)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		internal, err := k.Profile(k.Radius, false)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		// allow for the faceting of the rounded crests and roots
+		eps := 0.01 * k.Pitch
+		const n = 50
+		clearance := false
+		for i := 0; i <= n; i++ {
+			for j := 0; j <= n; j++ {
+				p := v2.Vec{
+					(float64(i)/n - 0.5) * k.Pitch,
+					k.Radius + (float64(j)/n-0.8)*k.Pitch,
+				}
+				dExt := external.Evaluate(p)
+				dInt := internal.Evaluate(p)
+				if dExt < 0 && dInt > eps {
+					t.Fatalf("%s: external thread at %v is outside the internal thread", name, p)
+				}
+				if dExt > 0 && dInt < 0 {
+					clearance = true
+				}
+			}
+		}
+		if !clearance {
+			t.Errorf("%s: no clearance between the internal and external threads", name)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_CamSynthesis(t *testing.T) {
+	program := CamProgram{
+		{CamDwell, DtoR(90), 0},
+		{CamCycloidal, DtoR(120), 5},
+		{CamDwell, DtoR(30), 0},
+		{CamPolynomial, DtoR(120), -5},
+	}
+	for _, follower := range []CamFollower{KnifeEdgeFollower, RollerFollower, FlatFaceFollower} {
+		k := &CamParms{Program: program, Follower: follower, BaseRadius: 20, RollerRadius: 4, Steps: 720}
+		cam, err := CamProfile2D(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples, err := CamFollowerSamples(cam, follower, k.RollerRadius, 360)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range samples {
+			s, _, _ := program.Displacement(x.Angle)
+			if math.Abs(s-x.Displacement) > 1e-3 {
+				t.Fatalf("follower %d: displacement error at %f degrees", follower, RtoD(x.Angle))
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_TextLayout(t *testing.T) {
+	sf, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := OpenTypeFont(sf)
+	h := 10.0
+
+	// left/baseline: the text starts at x = 0 and sits on y = 0
+	s, err := TextLayout2D(f, NewText("HH").SetAlign(AlignLeft, AlignBaseline), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	if bb.Min.X < 0 || bb.Min.X > 0.2*h || math.Abs(bb.Min.Y) > 1e-6 {
+		t.Fatalf("bad left/baseline bounding box %v", bb)
+	}
+
+	// right/top: the text ends at x = 0 and is below y = 0
+	s, err = TextLayout2D(f, NewText("HH").SetAlign(AlignRight, AlignTop), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb = s.BoundingBox()
+	if bb.Max.X > 0 || bb.Max.X < -0.2*h || bb.Max.Y > 0 {
+		t.Fatalf("bad right/top bounding box %v", bb)
+	}
+
+	// word wrap
+	txt := NewText("the quick brown fox jumps over the lazy dog").SetWidth(8 * h)
+	tl, err := txt.layout(f, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tl.lines) < 2 {
+		t.Fatalf("expected wrapped lines, got %d", len(tl.lines))
+	}
+	k := h / tl.height
+	for _, l := range tl.lines {
+		if l.width*k > 8*h {
+			t.Fatalf("line \"%s\" is wider than the wrap width", string(l.runes))
+		}
+	}
+
+	// text on an arc stays within the annulus
+	s, err = TextArc2D(f, NewText("KNOB"), h, 30, DtoR(90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb = s.BoundingBox()
+	for _, p := range []v2.Vec{bb.Min, bb.Max, {bb.Min.X, bb.Max.Y}, {bb.Max.X, bb.Min.Y}} {
+		if p.Length() > 30+1.5*h {
+			t.Fatalf("arc text outside the expected radius %v", bb)
+		}
+	}
+	if bb.Min.Y < 0 {
+		t.Fatalf("arc text not at the top of the circle %v", bb)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Hershey(t *testing.T) {
+	h := 5.0
+	lines, err := HersheyStrokes(NewText("H").SetAlign(AlignLeft, AlignBaseline), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 'H' is 3 strokes with a cap height of 21/32 of the line height
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 strokes, got %d", len(lines))
+	}
+	capHeight := h * 21 / 32
+	if !lines[0][0].Equals(v2.Vec{4 * h / 32, capHeight}, tolerance) {
+		t.Fatalf("bad stroke start %v", lines[0][0])
+	}
+
+	s, err := HersheyText2D(NewText("H").SetAlign(AlignLeft, AlignBaseline), h, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// on the crossbar, the stroke radius inside
+	p := v2.Vec{11 * h / 32, 11 * h / 32}
+	if math.Abs(s.Evaluate(p)+0.1) > tolerance {
+		t.Fatalf("bad stroke distance %f", s.Evaluate(p))
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Cache3D(t *testing.T) {
+	s0, err := Sphere3D(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1 := Cache3D(s0).(*CacheSDF3)
+	bb := s0.BoundingBox()
+	pSet := bb.RandomSet(512)
+
+	// concurrent evaluation
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, p := range pSet {
+				if math.Abs(s1.Evaluate(p)-s0.Evaluate(p)) > tolerance {
+					t.Errorf("%v cache error", p)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	st := s1.Stats()
+	if st.Reads != 8*512 || st.Entries != 512 {
+		t.Fatalf("unexpected cache stats %s", st)
+	}
+	for _, p := range pSet {
+		s1.Evaluate(p)
+	}
+	if s1.Stats().Hits-st.Hits != 512 {
+		t.Fatalf("expected all cache hits %s", s1.Stats())
+	}
+
+	// the cache is bounded
+	s2 := BoundedCache3D(s0, &CacheParms{Capacity: 1024}).(*CacheSDF3)
+	for _, p := range bb.RandomSet(10000) {
+		s2.Evaluate(p)
+	}
+	if s2.Stats().Entries > 1024 {
+		t.Fatalf("cache has %d entries (capacity 1024)", s2.Stats().Entries)
+	}
+
+	// quantized keys share evaluations
+	s3 := BoundedCache3D(s0, &CacheParms{Quantum: 0.1}).(*CacheSDF3)
+	s3.Evaluate(v3.Vec{1, 1, 1})
+	s3.Evaluate(v3.Vec{1.01, 1, 1})
+	if s3.Stats().Hits != 1 {
+		t.Fatalf("expected a quantized cache hit")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_VoxelSDF3(t *testing.T) {
+	s0, err := Sphere3D(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s0.BoundingBox()
+	for _, tricubic := range []bool{false, true} {
+		m, err := NewSparseVoxelSDF3(s0, &VoxelParms{Cells: 64, Band: 2, Tricubic: tricubic})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, total := m.Blocks()
+		if stored >= total {
+			t.Fatalf("voxel grid is not sparse (%d of %d blocks)", stored, total)
+		}
+		for _, p := range bb.RandomSet(1000) {
+			d0 := s0.Evaluate(p)
+			d1 := m.Evaluate(p)
+			if math.Abs(d0) < 0.5 {
+				if math.Abs(d0-d1) > 0.05 {
+					t.Fatalf("%v near surface error %f", p, d0-d1)
+				}
+				continue
+			}
+			// away from the surface the sign is correct and the distance is conservative
+			if d0*d1 <= 0 || math.Abs(d1) > math.Abs(d0)+0.05 {
+				t.Fatalf("%v far error %f %f", p, d0, d1)
+			}
+		}
+		// out of the box extrapolation
+		p := v3.Vec{30, 0, 0}
+		if math.Abs(m.Evaluate(p)-s0.Evaluate(p)) > 0.05 {
+			t.Fatalf("bad extrapolation %f", m.Evaluate(p))
+		}
+		// serialization
+		var buf bytes.Buffer
+		if err := m.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		m1, err := ReadVoxelSDF3(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range bb.RandomSet(100) {
+			if m.Evaluate(p) != m1.Evaluate(p) {
+				t.Fatalf("%v serialization mismatch", p)
+			}
+		}
+		// the output is repeatable
+		var buf0, buf1 bytes.Buffer
+		if err := m.Write(&buf0); err != nil {
+			t.Fatal(err)
+		}
+		if err := m1.Write(&buf1); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf0.Bytes(), buf1.Bytes()) {
+			t.Fatal("voxel file output is not repeatable")
+		}
+	}
+	// bad cell counts are clamped
+	m := NewVoxelSDF3(s0, 0, nil)
+	if _, ok := m.(*VoxelSDF3); !ok {
+		t.Fatal("expected a VoxelSDF3")
+	}
+}
+
+func Test_VoxelFile(t *testing.T) {
+	// header returns a voxel file header
+	header := func(cellSize float64, blocks [5]int32) []byte {
+		var buf bytes.Buffer
+		buf.WriteString(voxelMagic)
+		binary.Write(&buf, binary.LittleEndian, []float64{-1, -1, -1, 1, 1, 1, -1, -1, -1, cellSize})
+		binary.Write(&buf, binary.LittleEndian, blocks)
+		return buf.Bytes()
+	}
+	// block returns the coarse grid and blocks for a 1x1x1 block grid
+	block := func(nBlocks int32, idx ...int32) []byte {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, make([]float64, 8))
+		binary.Write(&buf, binary.LittleEndian, nBlocks)
+		for _, i := range idx {
+			binary.Write(&buf, binary.LittleEndian, []int32{i, i, i})
+			binary.Write(&buf, binary.LittleEndian, make([]float64, (voxelBlock+1)*(voxelBlock+1)*(voxelBlock+1)))
+		}
+		return buf.Bytes()
+	}
+	good := header(0.25, [5]int32{1, 1, 1, 0, 0})
+	if _, err := ReadVoxelSDF3(bytes.NewReader(append(good, block(1, 0)...))); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"magic", []byte("SDFXVOX0")},
+		{"short", good[:20]},
+		{"nan cell size", header(math.NaN(), [5]int32{1, 1, 1, 0, 0})},
+		{"zero cell size", header(0, [5]int32{1, 1, 1, 0, 0})},
+		{"zero blocks", header(0.25, [5]int32{1, 0, 1, 0, 0})},
+		{"negative blocks", header(0.25, [5]int32{1, -1, 1, 0, 0})},
+		{"huge blocks", header(0.25, [5]int32{math.MaxInt32, math.MaxInt32, math.MaxInt32, 0, 0})},
+		{"too many blocks", header(0.25, [5]int32{1 << 10, 1 << 10, 1 << 10, 0, 0})},
+		{"bad apron", header(0.25, [5]int32{1, 1, 1, 2, 0})},
+		{"negative block count", append(good, block(-1)...)},
+		{"large block count", append(good, block(2)...)},
+		{"bad block index", append(good, block(1, 1)...)},
+		{"missing block", append(good, block(1)...)},
+	}
+	for _, test := range tests {
+		if _, err := ReadVoxelSDF3(bytes.NewReader(test.data)); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+	// duplicate blocks
+	dup := header(0.25, [5]int32{2, 1, 1, 0, 0})
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, make([]float64, 12))
+	binary.Write(&buf, binary.LittleEndian, int32(2))
+	for i := 0; i < 2; i++ {
+		binary.Write(&buf, binary.LittleEndian, []int32{0, 0, 0})
+		binary.Write(&buf, binary.LittleEndian, make([]float64, (voxelBlock+1)*(voxelBlock+1)*(voxelBlock+1)))
+	}
+	if _, err := ReadVoxelSDF3(bytes.NewReader(append(dup, buf.Bytes()...))); err == nil {
+		t.Error("duplicate block: expected an error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// testSDF3 is an SDF3 without introspection.
+type testSDF3 struct {
+	sdf SDF3
+}
+
+func (s *testSDF3) Evaluate(p v3.Vec) float64 {
+	return s.sdf.Evaluate(p)
+}
+
+func (s *testSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+func Test_Profile(t *testing.T) {
+	s0, err := Sphere3D(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := Box3D(v3.Vec{10, 10, 30}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Circle2D(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Difference3D(Union3D(s0, Cache3D(s1)), Extrude3D(c, 40))
+
+	ps, p := ProfileSDF3(s)
+	bb := s.BoundingBox()
+	for _, x := range bb.RandomSet(1000) {
+		if ps.Evaluate(x) != s.Evaluate(x) {
+			t.Fatalf("%v profiled evaluation mismatch", x)
+		}
+	}
+
+	root := p.Tree()
+	if root.Name != "DifferenceSDF3" || root.Evals != 1000 || len(root.Children) != 2 {
+		t.Fatalf("bad profile root %+v", root)
+	}
+	circle := root.Children[1].Children[0]
+	if circle.Name != "CircleSDF2" || circle.Evals != 1000 {
+		t.Fatalf("bad profile leaf %+v", circle)
+	}
+	// cache nodes are rebuilt with a profiled child
+	cache := root.Children[0].Children[1]
+	if cache.Name != "CacheSDF3" || len(cache.Children) != 1 || cache.Children[0].Name != "BoxSDF3" {
+		t.Fatalf("bad profile cache node %+v", cache)
+	}
+
+	// smooth unions and bounded caches keep their blending and parameters
+	u := Union3D(s0, BoundedCache3D(s1, &CacheParms{Quantum: 0.5, Capacity: 1000}))
+	u.(*UnionSDF3).SetMin(PolyMin(2))
+	d := Difference3D(u, Extrude3D(c, 40))
+	d.(*DifferenceSDF3).SetMax(PolyMax(1))
+	ps, p = ProfileSDF3(d)
+	for _, x := range bb.RandomSet(1000) {
+		if ps.Evaluate(x) != d.Evaluate(x) {
+			t.Fatalf("%v profiled blended evaluation mismatch", x)
+		}
+	}
+	root = p.Tree()
+	if len(root.Children) != 2 || len(root.Children[0].Children) != 2 {
+		t.Fatalf("blended nodes are not wrapped %+v", root)
+	}
+	pc := ps.(*profileSDF3).sdf.(*DifferenceSDF3).s0.(*profileSDF3).sdf.(*UnionSDF3).sdf[1].(*profileSDF3).sdf.(*CacheSDF3)
+	if *pc.cache.parms() != (CacheParms{Quantum: 0.5, Capacity: 1000}) {
+		t.Fatalf("bad profiled cache parameters %+v", pc.cache.parms())
+	}
+
+	// nodes without introspection are leaves
+	_, p = ProfileSDF3(&testSDF3{s})
+	if root := p.Tree(); len(root.Children) != 0 {
+		t.Fatalf("bad opaque profile %+v", root)
+	}
+
+	// the original tree is not modified
+	if _, ok := s.(*DifferenceSDF3).s0.(*UnionSDF3); !ok {
+		t.Fatal("original tree was modified")
+	}
+
+	var buf bytes.Buffer
+	if err := p.WritePprof(&buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// treeKinds returns the node kinds of an SDF tree.
+func treeKinds(s any) []string {
+	var kinds []string
+	Walk(s, func(s any, n *Node, depth int) bool {
+		kinds = append(kinds, strings.Repeat(" ", depth)+n.Kind)
+		return true
+	})
+	return kinds
+}
+
+func Test_Serialize(t *testing.T) {
+	thread, err := ISOThread(5, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	screw, err := Screw3D(thread, 20, 0, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cone, err := Cone3D(10, 6, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cyl, err := Cylinder3D(12, 4, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box, err := Box3D(v3.Vec{20, 10, 6}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Circle2D(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tri, err := Polygon2D([]v2.Vec{{0, 0}, {4, 0}, {0, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rounded, err := ExtrudeRounded3D(Box2D(v2.Vec{6, 4}, 0.5), 5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loft, err := Loft3D(c, Box2D(v2.Vec{3, 3}, 0), 8, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev, err := RevolveTheta3D(Transform2D(c, Translate2d(v2.Vec{8, 0})), DtoR(270))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shell, err := Shell3D(Transform3D(cyl, Translate3d(v3.Vec{0, 15, 0})), 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft, err := Draft3D(Transform3D(box, Translate3d(v3.Vec{0, -15, 0})), v3.Vec{0, 0, 1}, v3.Vec{0, 0, -1}, DtoR(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	connected, err := AddConnector(draft, Connector3{"base", v3.Vec{0, -15, -10}, v3.Vec{0, 0, -1}, DtoR(45)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Union3D(
+		Difference3D(box, Transform3D(cone, RotateX(DtoR(30)))),
+		Transform3D(screw, Translate3d(v3.Vec{20, 0, 0})),
+		Intersect3D(rounded, Cut3D(cyl, v3.Vec{0, 0, 1}, v3.Vec{1, 1, 0})),
+		ScaleTwistExtrude3D(Union2D(tri, Offset2D(Line2D(5, 0.5), 0.2)), 4, 1, v2.Vec{0.5, 0.8}),
+		Array3D(ScaleUniform3D(loft, 0.5), v3i.Vec{2, 1, 2}, v3.Vec{5, 0, 5}),
+		RotateCopy3D(Elongate3D(rev, v3.Vec{1, 2, 0}), 3),
+		Offset3D(shell, 0.1),
+		draft,
+		connected,
+		Extrude3D(Cut2D(Array2D(c, v2i.Vec{3, 2}, v2.Vec{5, 5}), v2.Vec{1, 1}, v2.Vec{0, 1}), 2),
+	)
+
+	js, err := EncodeJSON(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ys, err := EncodeYAML(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decode := range []func() (any, error){
+		func() (any, error) { return DecodeJSON(js) },
+		func() (any, error) { return DecodeYAML(ys) },
+	} {
+		x, err := decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s1, ok := x.(SDF3)
+		if !ok {
+			t.Fatalf("decoded %T, not an SDF3", x)
+		}
+		if !reflect.DeepEqual(treeKinds(s1), treeKinds(s)) {
+			t.Fatalf("tree mismatch\n%s\n%s", TreeString(s1), TreeString(s))
+		}
+		bb := s.BoundingBox()
+		for _, p := range bb.RandomSet(2000) {
+			if math.Abs(s.Evaluate(p)-s1.Evaluate(p)) > 1e-9 {
+				t.Fatalf("%v evaluation mismatch %f != %f", p, s.Evaluate(p), s1.Evaluate(p))
+			}
+		}
+	}
+
+	// constructor parameters are reported, not the derived values
+	n := Inspect(cone)
+	for k, v := range map[string]float64{"height": 10, "r0": 6, "r1": 3, "round": 1} {
+		if math.Abs(n.Parms[k].(float64)-v) > 1e-9 {
+			t.Fatalf("bad cone parameters %v", n.Parms)
+		}
+	}
+
+	// custom blending can't be serialized
+	u := Union3D(box, cyl)
+	u.(*UnionSDF3).SetMin(PolyMin(1))
+	if _, err := EncodeJSON(u); err == nil {
+		t.Fatal("expected an error for a custom blend")
+	}
+	if Inspect(u).Opaque == "" {
+		t.Fatal("expected an opaque node")
+	}
+
+	// cache parameters round trip, older models use the default parameters
+	c2 := Extrude3D(BoundedCache2D(c, &CacheParms{Quantum: 0.01, Capacity: 500}), 4)
+	for _, x := range []any{BoundedCache3D(box, &CacheParms{Quantum: 0.25, Capacity: 2000}), c2} {
+		js, err := EncodeJSON(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x1, err := DecodeJSON(js)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(Inspect(x1), Inspect(x)) {
+			t.Fatalf("cache mismatch %v != %v", Inspect(x1), Inspect(x))
+		}
+	}
+	x, err := DecodeJSON([]byte(`{"format": "sdfx", "version": 1, "model": {"kind": "Cache3D", "children": [{"kind": "Sphere3D", "parms": {"radius": 1}}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *x.(*CacheSDF3).cache.parms() != (CacheParms{Capacity: defaultCacheCapacity}) {
+		t.Fatalf("bad default cache parameters %+v", x.(*CacheSDF3).cache.parms())
+	}
+
+	// nodes without introspection are opaque leaves
+	n = Inspect(&testSDF3{box})
+	if n.Opaque == "" || len(n.Children) != 0 || len(treeKinds(&testSDF3{box})) != 1 {
+		t.Fatalf("bad opaque node %v", n)
+	}
+
+	// generator parameters are a snapshot
+	type boxParms struct{ Size float64 }
+	k := &boxParms{Size: 10}
+	g := Generated3D(box, "testBox", k)
+	k.Size = 20
+	if x := Inspect(g).Parms["parms"].(map[string]any); x["Size"] != 10.0 {
+		t.Fatalf("generator parameters changed %v", x)
+	}
+
+	// bad documents
+	for _, doc := range []string{
+		`{"format": "sdfx", "version": 1, "model": {"kind": "Sphere3D", "parms": {}}}`,
+		`{"format": "sdfx", "version": 1, "model": {"kind": "Teapot3D"}}`,
+		`{"format": "sdfx", "version": 1, "model": {"kind": "Extrude3D", "parms": {"height": 1}, "children": [{"kind": "Sphere3D", "parms": {"radius": 1}}]}}`,
+		`{"format": "sdfx", "version": 2, "model": {"kind": "Sphere3D", "parms": {"radius": 1}}}`,
+	} {
+		if _, err := DecodeJSON([]byte(doc)); err == nil {
+			t.Fatalf("expected an error for %s", doc)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Draft(t *testing.T) {
+	// a box sitting on the parting plane
+	box, err := Box3D(v3.Vec{20, 20, 20}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box = Transform3D(box, Translate3d(v3.Vec{0, 0, 10}))
+	pull := v3.Vec{0, 0, 1}
+	angle := DtoR(2)
+
+	// the vertical walls have no draft, they form a single region
+	regions, err := DraftCheck(box, pull, v3.Vec{}, DtoR(1), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 1 || math.Abs(regions[0].MinAngle) > 1e-3 {
+		t.Fatalf("expected 1 region with no draft, got %v", regions)
+	}
+	if a := regions[0].Area; a < 0.8*1600 || a > 1.2*1600 {
+		t.Errorf("expected wall area of 1600, got %g", a)
+	}
+
+	s, err := Draft3D(box, pull, v3.Vec{}, angle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// walls are inset by h * tan(angle), the top is unchanged
+	tests := []struct {
+		p v3.Vec
+		d float64
+	}{
+		{v3.Vec{10, 0, 0.001}, 0},
+		{v3.Vec{10 - 15*math.Tan(angle), 0, 15}, 0},
+		{v3.Vec{0, -10 + 19*math.Tan(angle), 19}, 0},
+		{v3.Vec{0, 0, 20}, 0},
+		{v3.Vec{2, 3, 22}, 2 / (1 + math.Tan(angle))},
+	}
+	for _, test := range tests {
+		if d := s.Evaluate(test.p); math.Abs(d-test.d) > 1e-4 {
+			t.Errorf("%v: expected %g, got %g", test.p, test.d, d)
+		}
+	}
+
+	// the drafted box passes, an undercut doesn't
+	regions, err = DraftCheck(s, pull, v3.Vec{}, DtoR(1), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 0 {
+		t.Errorf("expected no regions, got %v", regions)
+	}
+	ledge, _ := Box3D(v3.Vec{10, 30, 4}, 0)
+	ledge = Transform3D(ledge, Translate3d(v3.Vec{0, 0, 12}))
+	regions, err = DraftCheck(Union3D(s, ledge), pull, v3.Vec{}, DtoR(1), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	undercut := false
+	for _, r := range regions {
+		if r.MinAngle < DtoR(-80) {
+			undercut = true
+		}
+	}
+	if !undercut {
+		t.Errorf("expected an undercut, got %v", regions)
+	}
+
+	// two sided draft about a central parting plane
+	s, err = Draft3D(box, pull, v3.Vec{0, 0, 10}, angle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, z := range []float64{2, 18} {
+		p := v3.Vec{10 - 8*math.Tan(angle), 0, z}
+		if d := s.Evaluate(p); math.Abs(d) > 1e-4 {
+			t.Errorf("%v: expected 0, got %g", p, d)
+		}
+	}
+	regions, err = DraftCheck(s, pull, v3.Vec{0, 0, 10}, DtoR(1), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 0 {
+		t.Errorf("expected no regions, got %v", regions)
+	}
+
+	// a tall part, the offset at the top is larger than the distance from
+	// the walls to the top face
+	tall, err := Box3D(v3.Vec{20, 20, 100}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tall = Transform3D(tall, Translate3d(v3.Vec{0, 0, 50}))
+	angle = DtoR(5)
+	s, err = Draft3D(tall, pull, v3.Vec{}, angle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		p      v3.Vec
+		inside bool
+	}{
+		{v3.Vec{-8.5, 0, 99}, false},
+		{v3.Vec{0, 8.5, 99}, false},
+		{v3.Vec{-1.2, 0, 99}, true},
+		{v3.Vec{0, 0, 99.9}, true},
+	} {
+		if d := s.Evaluate(test.p); (d < 0) != test.inside {
+			t.Errorf("%v: bad distance %g", test.p, d)
+		}
+	}
+	for _, z := range []float64{10, 50, 99} {
+		p := v3.Vec{10 - z*math.Tan(angle), 0, z}
+		if d := s.Evaluate(p); math.Abs(d) > 1e-4 {
+			t.Errorf("%v: expected 0, got %g", p, d)
+		}
+	}
+	regions, err = DraftCheck(s, pull, v3.Vec{}, DtoR(4), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 0 {
+		t.Errorf("expected no regions, got %v", regions)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Connector(t *testing.T) {
+	// connectors transform with their frames
+	c := Connector3{"a", v3.Vec{1, 2, 3}, v3.Vec{0, 1, 1}.Normalize(), DtoR(30)}
+	m := Translate3d(v3.Vec{5, -2, 7}).Mul(Rotate3d(v3.Vec{1, 2, 3}, DtoR(70)))
+	c1 := c.Transform(m)
+	if !c1.Frame().Equals(m.Mul(c.Frame()), tolerance) {
+		t.Errorf("bad transformed connector %v", c1)
+	}
+	if c2 := c.Transform(Identity3d()); !c2.Position.Equals(c.Position, tolerance) ||
+		!c2.Vector.Equals(c.Vector, tolerance) || math.Abs(c2.Angle-c.Angle) > tolerance {
+		t.Errorf("expected %v, got %v", c, c2)
+	}
+
+	// mated connectors coincide with opposed axes and aligned angles
+	tests := []struct {
+		a, b  Connector3
+		angle float64
+	}{
+		{Connector3{"a", v3.Vec{0, 0, 10}, v3.Vec{0, 0, 1}, 0}, Connector3{"b", v3.Vec{0, 0, -5}, v3.Vec{0, 0, -1}, 0}, 0},
+		{Connector3{"a", v3.Vec{1, 2, 3}, v3.Vec{1, 0, 0}, DtoR(20)}, Connector3{"b", v3.Vec{-4, 0, 1}, v3.Vec{0, 1, 0}, DtoR(-60)}, DtoR(90)},
+		{Connector3{"a", v3.Vec{0, 0, 0}, v3.Vec{1, 1, 1}.Normalize(), 1}, Connector3{"b", v3.Vec{3, 3, 3}, v3.Vec{1, 1, 1}.Normalize(), 2}, 3},
+	}
+	for _, test := range tests {
+		m := Mate(&test.a, &test.b, test.angle)
+		b := test.b.Transform(m)
+		if !b.Position.Equals(test.a.Position, tolerance) {
+			t.Errorf("expected position %v, got %v", test.a.Position, b.Position)
+		}
+		if !b.Vector.Equals(test.a.Vector.Neg(), tolerance) {
+			t.Errorf("expected vector %v, got %v", test.a.Vector.Neg(), b.Vector)
+		}
+		// the joint angle rotates b about the axis of a
+		xa := test.a.Frame().Mul(RotateZ(test.angle)).MulPosition(v3.Vec{1, 0, 0})
+		xb := b.Frame().MulPosition(v3.Vec{1, 0, 0})
+		if !xa.Equals(xb, tolerance) {
+			t.Errorf("expected x-axis %v, got %v", xa, xb)
+		}
+	}
+
+	box, _ := Box3D(v3.Vec{1, 1, 1}, 0)
+	s, err := AddConnector(box, Connector3{Name: "top", Position: v3.Vec{0, 0, 0.5}, Vector: v3.Vec{0, 0, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := AddConnector(s, Connector3{Name: "bottom", Position: v3.Vec{0, 0, -0.5}, Vector: v3.Vec{0, 0, -1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.(*ConnectedSDF3).Connectors()); n != 1 {
+		t.Errorf("expected 1 connector, got %d", n)
+	}
+	if n := len(s1.(*ConnectedSDF3).Connectors()); n != 2 {
+		t.Errorf("expected 2 connectors, got %d", n)
+	}
+	if x, err := Connector(s1, "top"); err != nil || !x.Vector.Equals(v3.Vec{0, 0, 1}, tolerance) {
+		t.Errorf("bad connector %v %v", x, err)
+	}
+	for _, c := range []Connector3{{Name: "top", Vector: v3.Vec{0, 0, 1}}, {Name: "x"}, {Vector: v3.Vec{0, 0, 1}}} {
+		if _, err := AddConnector(s1, c); err == nil {
+			t.Errorf("expected an error for %v", c)
+		}
+	}
+	if _, err := Connector(box, "top"); err == nil {
+		t.Error("expected an error for an sdf without connectors")
+	}
+}
+
+func Test_Assembly(t *testing.T) {
+	// a two link arm on a base
+	link := func(length float64) SDF3 {
+		box, _ := Box3D(v3.Vec{2, 2, length}, 0)
+		s, err := AddConnector(Transform3D(box, Translate3d(v3.Vec{0, 0, length / 2})),
+			Connector3{Name: "base", Vector: v3.Vec{0, 0, -1}},
+			Connector3{Name: "tip", Position: v3.Vec{0, 0, length}, Vector: v3.Vec{0, 0, 1}},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	// the elbow joint axis is along y
+	elbow, err := AddConnector(link(10), Connector3{Name: "elbow", Position: v3.Vec{0, 0, 10}, Vector: v3.Vec{0, 1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forearm, err := AddConnector(link(6), Connector3{Name: "elbow", Vector: v3.Vec{0, -1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAssembly()
+	if err := a.Add("base", link(1), Translate3d(v3.Vec{5, 0, 0})); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Mate("upper", elbow, "base", "base", "tip", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Mate("fore", forearm, "elbow", "upper", "elbow", 0); err != nil {
+		t.Fatal(err)
+	}
+	tip := func() v3.Vec {
+		p, _ := a.Part("fore")
+		c, err := p.Connector("tip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Position
+	}
+	if p := tip(); !p.Equals(v3.Vec{5, 0, 17}, tolerance) {
+		t.Errorf("expected tip at (5, 0, 17), got %v", p)
+	}
+	// bend the elbow 90 degrees about y
+	if err := a.SetAngle("fore", DtoR(90)); err != nil {
+		t.Fatal(err)
+	}
+	if p := tip(); !p.Equals(v3.Vec{11, 0, 11}, tolerance) {
+		t.Errorf("expected tip at (11, 0, 11), got %v", p)
+	}
+	// turn the upper arm 90 degrees about z, the forearm follows
+	if err := a.SetAngle("upper", DtoR(90)); err != nil {
+		t.Fatal(err)
+	}
+	if p := tip(); !p.Equals(v3.Vec{5, 6, 11}, tolerance) {
+		t.Errorf("expected tip at (5, 6, 11), got %v", p)
+	}
+	if x, _ := a.Angle("upper"); math.Abs(x-DtoR(90)) > tolerance {
+		t.Errorf("expected angle 90, got %g", RtoD(x))
+	}
+
+	u := a.Union()
+	if d := u.Evaluate(v3.Vec{5, 3, 11}); d > 0 {
+		t.Errorf("expected the forearm at (5, 3, 11), distance %g", d)
+	}
+	if n := len(a.Parts()); n != 3 {
+		t.Errorf("expected 3 parts, got %d", n)
+	}
+
+	// errors
+	if err := a.Add("base", elbow, Identity3d()); err == nil {
+		t.Error("expected an error for a duplicate part")
+	}
+	if err := a.Mate("x", forearm, "elbow", "nope", "tip", 0); err == nil {
+		t.Error("expected an error for a missing part")
+	}
+	if err := a.Mate("x", forearm, "nope", "upper", "tip", 0); err == nil {
+		t.Error("expected an error for a missing connector")
+	}
+	if err := a.SetAngle("base", 1); err == nil {
+		t.Error("expected an error for a placed part")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Interference(t *testing.T) {
+	s0, _ := Sphere3D(5)
+	s1, _ := Sphere3D(5)
+	tests := []struct {
+		x         float64 // sphere separation
+		clearance float64 // required clearance
+		regions   int
+		volume    float64
+	}{
+		{12, 1, 0, 0},
+		{12, 3, 1, 0},
+		{8, 0, 1, math.Pi * 28 * 4 / 12}, // lens volume
+		{30, 0, 0, 0},
+	}
+	for _, test := range tests {
+		s := Transform3D(s1, Translate3d(v3.Vec{test.x, 0, 0}))
+		x, err := CheckInterference(s0, s, &InterferenceParms{Clearance: test.clearance, Cells: 40})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(x.Regions) != test.regions {
+			t.Errorf("%v: expected %d regions, got %v", test, test.regions, x.Regions)
+		}
+		if math.Abs(x.Volume-test.volume) > 0.1*test.volume {
+			t.Errorf("%v: expected volume %g, got %g", test, test.volume, x.Volume)
+		}
+		// the grid step is set by the overlap region (or the size of both parts)
+		step := (10 + test.clearance) / 40
+		if test.x-10 >= test.clearance {
+			step = (test.x + 10) / 40
+		}
+		if math.Abs(x.Clearance-(test.x-10)) > 2*step {
+			t.Errorf("%v: expected clearance %g, got %g", test, test.x-10, x.Clearance)
+		}
+		if math.Abs(x.Point.X-test.x/2) > 2*step {
+			t.Errorf("%v: expected clearance point near x = %g, got %v", test, test.x/2, x.Point)
+		}
+	}
+
+	// the interference region
+	s := Interference3D(s0, Transform3D(s1, Translate3d(v3.Vec{12, 0, 0})), 3)
+	if d := s.Evaluate(v3.Vec{6, 0, 0}); math.Abs(d-(-0.5)) > tolerance {
+		t.Errorf("expected -0.5, got %g", d)
+	}
+
+	// parts in an assembly
+	a := NewAssembly()
+	a.Add("s0", s0, Identity3d())
+	a.Add("s1", s1, Translate3d(v3.Vec{9, 0, 0}))
+	a.Add("s2", s1, Translate3d(v3.Vec{0, 20, 0}))
+	list, err := a.Interference(&InterferenceParms{Cells: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Parts != [2]string{"s0", "s1"} {
+		t.Errorf("expected s0/s1 interference, got %v", list)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,977 @@
+//-----------------------------------------------------------------------------
+/*
+
+SDF Tree Serialization
+
+Save and load SDF trees as JSON or YAML documents. The document is the
+introspected tree (see inspect.go), each node is:
+
+	{"kind": "Cylinder3D", "parms": {"height": 10, "radius": 3, "round": 0}, "children": [...]}
+
+and the tree is rebuilt by calling the constructor for each kind with the
+parameters and the decoded children.
+
+	b, err := sdf.EncodeJSON(s)
+	...
+	x, err := sdf.DecodeJSON(b)
+	s := x.(sdf.SDF3)
+
+Objects built by a registered generator (see obj.Register) are stored as the
+generator name and its parameter set, rather than as the expanded tree.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	v2 "github.com/deadsy/sdfx/vec/v2"
+	"github.com/deadsy/sdfx/vec/v2i"
+	v3 "github.com/deadsy/sdfx/vec/v3"
+	"github.com/deadsy/sdfx/vec/v3i"
+	"gopkg.in/yaml.v3"
+)
+
+//-----------------------------------------------------------------------------
+
+const modelFormat = "sdfx"
+const modelVersion = 1
+
+// DecodeFunc builds an SDF node from its parameters and decoded children.
+type DecodeFunc func(k *NodeParms, children []any) (any, error)
+
+// GeneratorFunc builds an SDF2 or SDF3 from JSON encoded parameters.
+type GeneratorFunc func(parms []byte) (any, error)
+
+var registry = struct {
+	sync.RWMutex
+	kinds      map[string]DecodeFunc
+	generators map[string]GeneratorFunc
+}{
+	kinds:      map[string]DecodeFunc{},
+	generators: map[string]GeneratorFunc{},
+}
+
+// RegisterKind registers the decoder for a node kind.
+func RegisterKind(kind string, fn DecodeFunc) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.kinds[kind] = fn
+}
+
+// RegisterGenerator registers a named object generator.
+func RegisterGenerator(name string, fn GeneratorFunc) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.generators[name] = fn
+}
+
+func lookupKind(kind string) DecodeFunc {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.kinds[kind]
+}
+
+func lookupGenerator(name string) GeneratorFunc {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.generators[name]
+}
+
+// Kinds returns the sorted list of registered node kinds.
+func Kinds() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	kinds := make([]string, 0, len(registry.kinds))
+	for k := range registry.kinds {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+//-----------------------------------------------------------------------------
+// Encoding
+
+// encodeNode returns the document tree for an SDF node.
+func encodeNode(s any) (map[string]any, error) {
+	n := Inspect(s)
+	if n.Opaque != "" {
+		return nil, fmt.Errorf("%s: can't serialize (%s)", n.Kind, n.Opaque)
+	}
+	if lookupKind(n.Kind) == nil {
+		return nil, fmt.Errorf("%s: unknown kind", n.Kind)
+	}
+	x := map[string]any{"kind": n.Kind}
+	if len(n.Parms) != 0 {
+		x["parms"] = n.Parms
+	}
+	if len(n.Children) != 0 {
+		children := make([]any, len(n.Children))
+		for i, c := range n.Children {
+			var err error
+			children[i], err = encodeNode(c)
+			if err != nil {
+				return nil, err
+			}
+		}
+		x["children"] = children
+	}
+	return x, nil
+}
+
+// encodeDocument returns the document tree for an SDF2/SDF3.
+func encodeDocument(s any) (map[string]any, error) {
+	if s == nil {
+		return nil, errors.New("sdf == nil")
+	}
+	model, err := encodeNode(s)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"format":  modelFormat,
+		"version": modelVersion,
+		"model":   model,
+	}, nil
+}
+
+// EncodeJSON returns the JSON document for an SDF2/SDF3.
+func EncodeJSON(s any) ([]byte, error) {
+	doc, err := encodeDocument(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// EncodeYAML returns the YAML document for an SDF2/SDF3.
+func EncodeYAML(s any) ([]byte, error) {
+	doc, err := encodeDocument(s)
+	if err != nil {
+		return nil, err
+	}
+	// Go through JSON so that generator parameter structs
+	// have the same field names in both formats.
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var x any
+	if err := json.Unmarshal(b, &x); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(x)
+}
+
+//-----------------------------------------------------------------------------
+// Decoding
+
+// decodeNode builds an SDF node from its document tree.
+func decodeNode(x any) (any, error) {
+	m, ok := x.(map[string]any)
+	if !ok {
+		return nil, errors.New("node is not a map")
+	}
+	kind, ok := m["kind"].(string)
+	if !ok {
+		return nil, errors.New("node has no kind")
+	}
+	fn := lookupKind(kind)
+	if fn == nil {
+		return nil, fmt.Errorf("%s: unknown kind", kind)
+	}
+	k := &NodeParms{}
+	if p, ok := m["parms"]; ok {
+		if k.parms, ok = p.(map[string]any); !ok {
+			return nil, fmt.Errorf("%s: parms is not a map", kind)
+		}
+	}
+	var children []any
+	if c, ok := m["children"]; ok {
+		list, ok := c.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: children is not a list", kind)
+		}
+		for _, x := range list {
+			child, err := decodeNode(x)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+	}
+	s, err := fn(k, children)
+	if err == nil {
+		err = k.err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", kind, err)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("%s: nil sdf", kind)
+	}
+	return s, nil
+}
+
+// rebuildNode builds a new SDF node from the description of a node and a new
+// set of child nodes.
+func rebuildNode(n *Node, children []any) (any, error) {
+	if n.Opaque != "" {
+		return nil, fmt.Errorf("%s: can't rebuild (%s)", n.Kind, n.Opaque)
+	}
+	fn := lookupKind(n.Kind)
+	if fn == nil {
+		return nil, fmt.Errorf("%s: unknown kind", n.Kind)
+	}
+	// the decoders read the parameters in their document form
+	b, err := json.Marshal(n.Parms)
+	if err != nil {
+		return nil, err
+	}
+	k := &NodeParms{}
+	if err := json.Unmarshal(b, &k.parms); err != nil {
+		return nil, err
+	}
+	s, err := fn(k, children)
+	if err == nil {
+		err = k.err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.Kind, err)
+	}
+	return s, nil
+}
+
+// decodeDocument builds an SDF2/SDF3 from a document tree.
+func decodeDocument(x any) (any, error) {
+	doc, ok := x.(map[string]any)
+	if !ok {
+		return nil, errors.New("document is not a map")
+	}
+	if doc["format"] != modelFormat {
+		return nil, errors.New("not an sdfx model")
+	}
+	version, err := toInt(doc["version"])
+	if err != nil || version < 1 || version > modelVersion {
+		return nil, fmt.Errorf("unsupported model version %v", doc["version"])
+	}
+	return decodeNode(doc["model"])
+}
+
+// DecodeJSON builds an SDF2/SDF3 from a JSON document.
+func DecodeJSON(b []byte) (any, error) {
+	var x any
+	if err := json.Unmarshal(b, &x); err != nil {
+		return nil, err
+	}
+	return decodeDocument(x)
+}
+
+// DecodeYAML builds an SDF2/SDF3 from a YAML document.
+func DecodeYAML(b []byte) (any, error) {
+	var x any
+	if err := yaml.Unmarshal(b, &x); err != nil {
+		return nil, err
+	}
+	return decodeDocument(x)
+}
+
+//-----------------------------------------------------------------------------
+// Files
+
+// isYAML returns true if the filename has a YAML extension.
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// SaveModel saves an SDF2/SDF3 to a file (YAML for *.yaml, *.yml, else JSON).
+func SaveModel(path string, s any) error {
+	var b []byte
+	var err error
+	if isYAML(path) {
+		b, err = EncodeYAML(s)
+	} else {
+		b, err = EncodeJSON(s)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// LoadModel loads an SDF2/SDF3 from a file (YAML for *.yaml, *.yml, else JSON).
+func LoadModel(path string) (any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isYAML(path) {
+		return DecodeYAML(b)
+	}
+	return DecodeJSON(b)
+}
+
+//-----------------------------------------------------------------------------
+// Node Parameters
+
+// NodeParms reads the parameters of a node being decoded.
+// The first error is recorded and returned by Err.
+type NodeParms struct {
+	parms map[string]any
+	err   error
+}
+
+func (k *NodeParms) fail(err error) {
+	if k.err == nil {
+		k.err = err
+	}
+}
+
+// Err returns the first error reading the parameters.
+func (k *NodeParms) Err() error {
+	return k.err
+}
+
+// Value returns a parameter value (nil if it doesn't exist).
+func (k *NodeParms) Value(name string) any {
+	return k.parms[name]
+}
+
+func (k *NodeParms) get(name string) (any, bool) {
+	x, ok := k.parms[name]
+	if !ok {
+		k.fail(fmt.Errorf("missing parameter \"%s\"", name))
+	}
+	return x, ok
+}
+
+func toFloat(x any) (float64, error) {
+	switch v := x.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("%v is not a number", x)
+}
+
+func toInt(x any) (int, error) {
+	f, err := toFloat(x)
+	if err != nil {
+		return 0, err
+	}
+	if f != float64(int(f)) {
+		return 0, fmt.Errorf("%v is not an integer", x)
+	}
+	return int(f), nil
+}
+
+// Float returns a float parameter.
+func (k *NodeParms) Float(name string) float64 {
+	x, ok := k.get(name)
+	if !ok {
+		return 0
+	}
+	f, err := toFloat(x)
+	if err != nil {
+		k.fail(fmt.Errorf("parameter \"%s\": %w", name, err))
+	}
+	return f
+}
+
+// Int returns an integer parameter.
+func (k *NodeParms) Int(name string) int {
+	x, ok := k.get(name)
+	if !ok {
+		return 0
+	}
+	i, err := toInt(x)
+	if err != nil {
+		k.fail(fmt.Errorf("parameter \"%s\": %w", name, err))
+	}
+	return i
+}
+
+// String returns a string parameter.
+func (k *NodeParms) String(name string) string {
+	x, ok := k.get(name)
+	if !ok {
+		return ""
+	}
+	s, ok := x.(string)
+	if !ok {
+		k.fail(fmt.Errorf("parameter \"%s\": %v is not a string", name, x))
+	}
+	return s
+}
+
+// Floats returns a list of n floats (n < 0 for any length).
+func (k *NodeParms) Floats(name string, n int) []float64 {
+	x, ok := k.get(name)
+	if !ok {
+		return make([]float64, max(n, 0))
+	}
+	list, err := toFloats(x, n)
+	if err != nil {
+		k.fail(fmt.Errorf("parameter \"%s\": %w", name, err))
+		return make([]float64, max(n, 0))
+	}
+	return list
+}
+
+func toFloats(x any, n int) ([]float64, error) {
+	list, ok := x.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a list", x)
+	}
+	if n >= 0 && len(list) != n {
+		return nil, fmt.Errorf("list length is %d, not %d", len(list), n)
+	}
+	f := make([]float64, len(list))
+	for i, v := range list {
+		var err error
+		f[i], err = toFloat(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Ints returns a list of n integers.
+func (k *NodeParms) Ints(name string, n int) []int {
+	f := k.Floats(name, n)
+	x := make([]int, len(f))
+	for i := range f {
+		x[i] = int(f[i])
+		if float64(x[i]) != f[i] {
+			k.fail(fmt.Errorf("parameter \"%s\": %v is not an integer", name, f[i]))
+		}
+	}
+	return x
+}
+
+// V2 returns a 2d vector parameter.
+func (k *NodeParms) V2(name string) v2.Vec {
+	x := k.Floats(name, 2)
+	return v2.Vec{x[0], x[1]}
+}
+
+// V3 returns a 3d vector parameter.
+func (k *NodeParms) V3(name string) v3.Vec {
+	x := k.Floats(name, 3)
+	return v3.Vec{x[0], x[1], x[2]}
+}
+
+// V2i returns a 2d integer vector parameter.
+func (k *NodeParms) V2i(name string) v2i.Vec {
+	x := k.Ints(name, 2)
+	return v2i.Vec{x[0], x[1]}
+}
+
+// V3i returns a 3d integer vector parameter.
+func (k *NodeParms) V3i(name string) v3i.Vec {
+	x := k.Ints(name, 3)
+	return v3i.Vec{x[0], x[1], x[2]}
+}
+
+// M33 returns a 3x3 matrix parameter.
+func (k *NodeParms) M33(name string) M33 {
+	var m M33
+	copy(m[:], k.Floats(name, 9))
+	return m
+}
+
+// M44 returns a 4x4 matrix parameter.
+func (k *NodeParms) M44(name string) M44 {
+	var m M44
+	copy(m[:], k.Floats(name, 16))
+	return m
+}
+
+// Connectors returns a list of 3d connectors.
+func (k *NodeParms) Connectors(name string) []Connector3 {
+	x, ok := k.get(name)
+	if !ok {
+		return nil
+	}
+	list, ok := x.([]any)
+	if !ok {
+		k.fail(fmt.Errorf("parameter \"%s\": not a list", name))
+		return nil
+	}
+	connectors := make([]Connector3, len(list))
+	for i, v := range list {
+		m, ok := v.(map[string]any)
+		if !ok {
+			k.fail(fmt.Errorf("parameter \"%s\": %v is not a connector", name, v))
+			return nil
+		}
+		c := &NodeParms{parms: m}
+		connectors[i] = Connector3{
+			Name:     c.String("name"),
+			Position: c.V3("position"),
+			Vector:   c.V3("vector"),
+			Angle:    c.Float("angle"),
+		}
+		if err := c.Err(); err != nil {
+			k.fail(fmt.Errorf("parameter \"%s\": %w", name, err))
+			return nil
+		}
+	}
+	return connectors
+}
+
+// Lines returns a list of 2d line segments.
+func (k *NodeParms) Lines(name string) []*Line2 {
+	x, ok := k.get(name)
+	if !ok {
+		return nil
+	}
+	list, ok := x.([]any)
+	if !ok {
+		k.fail(fmt.Errorf("parameter \"%s\": not a list", name))
+		return nil
+	}
+	lines := make([]*Line2, len(list))
+	for i, v := range list {
+		f, err := toFloats(v, 4)
+		if err != nil {
+			k.fail(fmt.Errorf("parameter \"%s\": %w", name, err))
+			return nil
+		}
+		lines[i] = &Line2{{f[0], f[1]}, {f[2], f[3]}}
+	}
+	return lines
+}
+
+//-----------------------------------------------------------------------------
+// Child Nodes
+
+// childSDF2 returns the i-th child as an SDF2.
+func childSDF2(children []any, i int) (SDF2, error) {
+	if i >= len(children) {
+		return nil, fmt.Errorf("missing child %d", i)
+	}
+	s, ok := children[i].(SDF2)
+	if !ok {
+		return nil, fmt.Errorf("child %d is not an SDF2", i)
+	}
+	return s, nil
+}
+
+// childSDF3 returns the i-th child as an SDF3.
+func childSDF3(children []any, i int) (SDF3, error) {
+	if i >= len(children) {
+		return nil, fmt.Errorf("missing child %d", i)
+	}
+	s, ok := children[i].(SDF3)
+	if !ok {
+		return nil, fmt.Errorf("child %d is not an SDF3", i)
+	}
+	return s, nil
+}
+
+// checkChildren checks the number of children.
+func checkChildren(children []any, n int) error {
+	if len(children) != n {
+		return fmt.Errorf("%d children, expected %d", len(children), n)
+	}
+	return nil
+}
+
+// unary2 registers a kind with a single SDF2 child.
+func unary2(kind string, fn func(k *NodeParms, s SDF2) (any, error)) {
+	RegisterKind(kind, func(k *NodeParms, children []any) (any, error) {
+		if err := checkChildren(children, 1); err != nil {
+			return nil, err
+		}
+		s, err := childSDF2(children, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(k, s)
+	})
+}
+
+// unary3 registers a kind with a single SDF3 child.
+func unary3(kind string, fn func(k *NodeParms, s SDF3) (any, error)) {
+	RegisterKind(kind, func(k *NodeParms, children []any) (any, error) {
+		if err := checkChildren(children, 1); err != nil {
+			return nil, err
+		}
+		s, err := childSDF3(children, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(k, s)
+	})
+}
+
+// binary2 registers a kind with two SDF2 children.
+func binary2(kind string, fn func(s0, s1 SDF2) SDF2) {
+	RegisterKind(kind, func(k *NodeParms, children []any) (any, error) {
+		if err := checkChildren(children, 2); err != nil {
+			return nil, err
+		}
+		s0, err := childSDF2(children, 0)
+		if err != nil {
+			return nil, err
+		}
+		s1, err := childSDF2(children, 1)
+		if err != nil {
+			return nil, err
+		}
+		return fn(s0, s1), nil
+	})
+}
+
+// binary3 registers a kind with two SDF3 children.
+func binary3(kind string, fn func(s0, s1 SDF3) SDF3) {
+	RegisterKind(kind, func(k *NodeParms, children []any) (any, error) {
+		if err := checkChildren(children, 2); err != nil {
+			return nil, err
+		}
+		s0, err := childSDF3(children, 0)
+		if err != nil {
+			return nil, err
+		}
+		s1, err := childSDF3(children, 1)
+		if err != nil {
+			return nil, err
+		}
+		return fn(s0, s1), nil
+	})
+}
+
+// leaf registers a kind with no children.
+func leaf(kind string, fn func(k *NodeParms) (any, error)) {
+	RegisterKind(kind, func(k *NodeParms, children []any) (any, error) {
+		if err := checkChildren(children, 0); err != nil {
+			return nil, err
+		}
+		return fn(k)
+	})
+}
+
+//-----------------------------------------------------------------------------
+// Node Kinds
+
+func init() {
+
+	// 2D primitives
+	leaf("Circle2D", func(k *NodeParms) (any, error) {
+		return Circle2D(k.Float("radius"))
+	})
+	leaf("Box2D", func(k *NodeParms) (any, error) {
+		return Box2D(k.V2("size"), k.Float("round")), nil
+	})
+	leaf("Line2D", func(k *NodeParms) (any, error) {
+		return Line2D(k.Float("length"), k.Float("round")), nil
+	})
+	leaf("Mesh2D", func(k *NodeParms) (any, error) {
+		return Mesh2D(k.Lines("lines"))
+	})
+	leaf("Stroke2D", func(k *NodeParms) (any, error) {
+		return Stroke2D(k.Lines("lines"), k.Float("width"))
+	})
+
+	// 2D operations
+	unary2("Offset2D", func(k *NodeParms, s SDF2) (any, error) {
+		return Offset2D(s, k.Float("offset")), nil
+	})
+	unary2("Cut2D", func(k *NodeParms, s SDF2) (any, error) {
+		return Cut2D(s, k.V2("a"), k.V2("v")), nil
+	})
+	unary2("Transform2D", func(k *NodeParms, s SDF2) (any, error) {
+		return Transform2D(s, k.M33("matrix")), nil
+	})
+	unary2("ScaleUniform2D", func(k *NodeParms, s SDF2) (any, error) {
+		return ScaleUniform2D(s, k.Float("k")), nil
+	})
+	unary2("Array2D", func(k *NodeParms, s SDF2) (any, error) {
+		return Array2D(s, k.V2i("num"), k.V2("step")), nil
+	})
+	unary2("RotateUnion2D", func(k *NodeParms, s SDF2) (any, error) {
+		return RotateUnion2D(s, k.Int("num"), k.M33("step")), nil
+	})
+	unary2("RotateCopy2D", func(k *NodeParms, s SDF2) (any, error) {
+		return RotateCopy2D(s, k.Int("num")), nil
+	})
+	unary2("Elongate2D", func(k *NodeParms, s SDF2) (any, error) {
+		return Elongate2D(s, k.V2("h")), nil
+	})
+	unary2("Cache2D", func(k *NodeParms, s SDF2) (any, error) {
+		return BoundedCache2D(s, decodeCacheParms(k)), nil
+	})
+	unary3("Slice2D", func(k *NodeParms, s SDF3) (any, error) {
+		return Slice2D(s, k.V3("a"), k.V3("n")), nil
+	})
+	binary2("Intersect2D", Intersect2D)
+	binary2("Difference2D", Difference2D)
+	RegisterKind("Union2D", func(k *NodeParms, children []any) (any, error) {
+		s := make([]SDF2, len(children))
+		for i := range children {
+			var err error
+			if s[i], err = childSDF2(children, i); err != nil {
+				return nil, err
+			}
+		}
+		return Union2D(s...), nil
+	})
+
+	// 3D primitives
+	leaf("Box3D", func(k *NodeParms) (any, error) {
+		return Box3D(k.V3("size"), k.Float("round"))
+	})
+	leaf("Sphere3D", func(k *NodeParms) (any, error) {
+		return Sphere3D(k.Float("radius"))
+	})
+	leaf("Cylinder3D", func(k *NodeParms) (any, error) {
+		return Cylinder3D(k.Float("height"), k.Float("radius"), k.Float("round"))
+	})
+	leaf("Cone3D", func(k *NodeParms) (any, error) {
+		return Cone3D(k.Float("height"), k.Float("r0"), k.Float("r1"), k.Float("round"))
+	})
+
+	// 2D to 3D
+	unary2("RevolveTheta3D", func(k *NodeParms, s SDF2) (any, error) {
+		return RevolveTheta3D(s, k.Float("theta"))
+	})
+	unary2("Extrude3D", func(k *NodeParms, s SDF2) (any, error) {
+		return Extrude3D(s, k.Float("height")), nil
+	})
+	unary2("TwistExtrude3D", func(k *NodeParms, s SDF2) (any, error) {
+		return TwistExtrude3D(s, k.Float("height"), k.Float("twist")), nil
+	})
+	unary2("ScaleExtrude3D", func(k *NodeParms, s SDF2) (any, error) {
+		return ScaleExtrude3D(s, k.Float("height"), k.V2("scale")), nil
+	})
+	unary2("ScaleTwistExtrude3D", func(k *NodeParms, s SDF2) (any, error) {
+		return ScaleTwistExtrude3D(s, k.Float("height"), k.Float("twist"), k.V2("scale")), nil
+	})
+	unary2("ExtrudeRounded3D", func(k *NodeParms, s SDF2) (any, error) {
+		return ExtrudeRounded3D(s, k.Float("height"), k.Float("round"))
+	})
+	unary2("Screw3D", func(k *NodeParms, s SDF2) (any, error) {
+		return Screw3D(s, k.Float("length"), k.Float("taper"), k.Float("pitch"), k.Int("starts"))
+	})
+	RegisterKind("Loft3D", func(k *NodeParms, children []any) (any, error) {
+		if err := checkChildren(children, 2); err != nil {
+			return nil, err
+		}
+		s0, err := childSDF2(children, 0)
+		if err != nil {
+			return nil, err
+		}
+		s1, err := childSDF2(children, 1)
+		if err != nil {
+			return nil, err
+		}
+		return Loft3D(s0, s1, k.Float("height"), k.Float("round"))
+	})
+
+	// 3D operations
+	unary3("Transform3D", func(k *NodeParms, s SDF3) (any, error) {
+		return Transform3D(s, k.M44("matrix")), nil
+	})
+	unary3("ScaleUniform3D", func(k *NodeParms, s SDF3) (any, error) {
+		return ScaleUniform3D(s, k.Float("k")), nil
+	})
+	unary3("Elongate3D", func(k *NodeParms, s SDF3) (any, error) {
+		return Elongate3D(s, k.V3("h")), nil
+	})
+	unary3("Cut3D", func(k *NodeParms, s SDF3) (any, error) {
+		return Cut3D(s, k.V3("a"), k.V3("n")), nil
+	})
+	unary3("Array3D", func(k *NodeParms, s SDF3) (any, error) {
+		return Array3D(s, k.V3i("num"), k.V3("step")), nil
+	})
+	unary3("RotateUnion3D", func(k *NodeParms, s SDF3) (any, error) {
+		return RotateUnion3D(s, k.Int("num"), k.M44("step")), nil
+	})
+	unary3("RotateCopy3D", func(k *NodeParms, s SDF3) (any, error) {
+		return RotateCopy3D(s, k.Int("num")), nil
+	})
+	unary3("Offset3D", func(k *NodeParms, s SDF3) (any, error) {
+		return Offset3D(s, k.Float("offset")), nil
+	})
+	unary3("Shell3D", func(k *NodeParms, s SDF3) (any, error) {
+		return Shell3D(s, k.Float("thickness"))
+	})
+	unary3("Draft3D", func(k *NodeParms, s SDF3) (any, error) {
+		return Draft3D(s, k.V3("pull"), k.V3("parting"), k.Float("angle"))
+	})
+	unary3("Connected3D", func(k *NodeParms, s SDF3) (any, error) {
+		return AddConnector(s, k.Connectors("connectors")...)
+	})
+	unary3("Cache3D", func(k *NodeParms, s SDF3) (any, error) {
+		return BoundedCache3D(s, decodeCacheParms(k)), nil
+	})
+	binary3("Intersect3D", Intersect3D)
+	binary3("Difference3D", Difference3D)
+	RegisterKind("Union3D", func(k *NodeParms, children []any) (any, error) {
+		s := make([]SDF3, len(children))
+		for i := range children {
+			var err error
+			if s[i], err = childSDF3(children, i); err != nil {
+				return nil, err
+			}
+		}
+		return Union3D(s...), nil
+	})
+
+	// generated objects
+	leaf("Generated2D", decodeGenerated)
+	leaf("Generated3D", decodeGenerated)
+}
+
+//-----------------------------------------------------------------------------
+// Generated Objects
+
+// generatedParms is a snapshot of the parameters passed to a generator.
+// Later changes to the caller's parameters don't change the snapshot.
+type generatedParms struct {
+	value any   // parameters in their document form
+	err   error // the parameters can't be encoded
+}
+
+// snapshotParms returns a snapshot of generator parameters.
+func snapshotParms(parms any) generatedParms {
+	b, err := json.Marshal(parms)
+	if err != nil {
+		return generatedParms{err: err}
+	}
+	var x generatedParms
+	x.err = json.Unmarshal(b, &x.value)
+	return x
+}
+
+// GeneratedSDF2 is an SDF2 built by a named generator from a parameter set.
+type GeneratedSDF2 struct {
+	sdf   SDF2
+	name  string         // generator name
+	parms generatedParms // generator parameters
+}
+
+// Generated2D tags an SDF2 with the generator and parameters used to build it.
+func Generated2D(s SDF2, name string, parms any) SDF2 {
+	return &GeneratedSDF2{s, name, snapshotParms(parms)}
+}
+
+// Evaluate returns the minimum distance to a generated SDF2.
+func (s *GeneratedSDF2) Evaluate(p v2.Vec) float64 {
+	return s.sdf.Evaluate(p)
+}
+
+// BoundingBox returns the bounding box of a generated SDF2.
+func (s *GeneratedSDF2) BoundingBox() Box2 {
+	return s.sdf.BoundingBox()
+}
+
+// Inspect returns the description of a generated SDF2.
+func (s *GeneratedSDF2) Inspect() *Node {
+	return inspectGenerated("Generated2D", s.name, s.parms)
+}
+
+// GeneratedSDF3 is an SDF3 built by a named generator from a parameter set.
+type GeneratedSDF3 struct {
+	sdf   SDF3
+	name  string         // generator name
+	parms generatedParms // generator parameters
+	part  int            // part number for multi-part generators (< 0 for single part)
+}
+
+// Generated3D tags an SDF3 with the generator and parameters used to build it.
+func Generated3D(s SDF3, name string, parms any) SDF3 {
+	return &GeneratedSDF3{s, name, snapshotParms(parms), -1}
+}
+
+// GeneratedPart3D tags an SDF3 with the generator, parameters and part number used to build it.
+func GeneratedPart3D(s SDF3, name string, parms any, part int) SDF3 {
+	return &GeneratedSDF3{s, name, snapshotParms(parms), part}
+}
+
+// Evaluate returns the minimum distance to a generated SDF3.
+func (s *GeneratedSDF3) Evaluate(p v3.Vec) float64 {
+	return s.sdf.Evaluate(p)
+}
+
+// BoundingBox returns the bounding box of a generated SDF3.
+func (s *GeneratedSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+// Inspect returns the description of a generated SDF3.
+func (s *GeneratedSDF3) Inspect() *Node {
+	n := inspectGenerated("Generated3D", s.name, s.parms)
+	if s.part >= 0 {
+		n.Parms["part"] = s.part
+	}
+	return n
+}
+
+func inspectGenerated(kind, name string, parms generatedParms) *Node {
+	n := &Node{Kind: kind, Parms: map[string]any{"name": name, "parms": parms.value}}
+	if parms.err != nil {
+		n.Opaque = fmt.Sprintf("bad generator parameters (%s)", parms.err)
+	} else if lookupGenerator(name) == nil {
+		n.Opaque = "unregistered generator"
+	}
+	return n
+}
+
+// decodeCacheParms returns the cache parameters, models without them use the defaults.
+func decodeCacheParms(k *NodeParms) *CacheParms {
+	if k.Value("quantum") == nil && k.Value("capacity") == nil {
+		return nil
+	}
+	return &CacheParms{Quantum: k.Float("quantum"), Capacity: k.Int("capacity")}
+}
+
+// decodeGenerated builds a generated object.
+func decodeGenerated(k *NodeParms) (any, error) {
+	name := k.String("name")
+	if k.err != nil {
+		return nil, k.err
+	}
+	fn := lookupGenerator(name)
+	if fn == nil {
+		return nil, fmt.Errorf("unknown generator \"%s\"", name)
+	}
+	b, err := json.Marshal(k.Value("parms"))
+	if err != nil {
+		return nil, err
+	}
+	s, err := fn(b)
+	if err != nil {
+		return nil, err
+	}
+	if k.Value("part") == nil {
+		return s, nil
+	}
+	// multi-part generator
+	part := k.Int("part")
+	parts, ok := s.([]SDF3)
+	if !ok || part < 0 || part >= len(parts) {
+		return nil, fmt.Errorf("generator \"%s\" has no part %d", name, part)
+	}
+	return parts[part], nil
+}
+
+//-----------------------------------------------------------------------------
@@ -5,6 +5,16 @@ Text Operations
 
 Convert a string and font specification into an SDF2
 
+Fonts:
+
+TrueType fonts can be loaded with LoadFont (golang/freetype).
+TrueType and OpenType/CFF fonts can be loaded with LoadTextFont (x/image/font/sfnt).
+
+Layout:
+
+The text height h passed to the render functions is the line height of the font.
+Tracking, line spacing and wrap width are relative to, or in the units of, h.
+
 */
 //-----------------------------------------------------------------------------
 
@@ -12,28 +22,48 @@ package sdf
 
 import (
 	"io/ioutil"
+	"math"
+	"sort"
 	"strings"
 
 	v2 "github.com/deadsy/sdfx/vec/v2"
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
 	"golang.org/x/image/math/fixed"
 )
 
 //-----------------------------------------------------------------------------
 
-type align int
+// HAlign is the horizontal alignment of text.
+type HAlign int
+
+// Horizontal alignments.
+const (
+	AlignLeft   HAlign = iota // left hand side x = 0
+	AlignRight                // right hand side x = 0
+	AlignCenter               // center x = 0
+)
+
+// VAlign is the vertical alignment of text.
+type VAlign int
 
+// Vertical alignments.
 const (
-	lAlign align = iota // left hand side x = 0
-	rAlign              // right hand side x = 0
-	cAlign              // center x = 0
+	AlignBaseline VAlign = iota // baseline of the first line y = 0
+	AlignTop                    // top (ascent) of the first line y = 0
+	AlignMiddle                 // middle of the text block y = 0
+	AlignBottom                 // bottom (descent) of the last line y = 0
 )
 
 // Text stores a UTF8 string and it's rendering parameters.
 type Text struct {
-	s      string
-	halign align
+	s          string
+	halign     HAlign
+	valign     VAlign
+	tracking   float64 // additional character spacing (fraction of text height)
+	lineHeight float64 // line to line distance (multiple of text height)
+	width      float64 // maximum line width before word wrapping (0 = no wrapping)
 }
 
 //-----------------------------------------------------------------------------
@@ -110,46 +140,371 @@ func glyphConvert(g *truetype.GlyphBuf) (SDF2, error) {
 }
 
 //-----------------------------------------------------------------------------
+// Fonts
+
+// TextFont is a font that can be used to render text.
+// Distances are in font units.
+type TextFont interface {
+	metrics() (height, ascent, descent float64)
+	advance(r rune) (float64, error)
+	kern(r0, r1 rune) float64
+	glyph(r rune) (SDF2, error) // nil for an empty glyph
+}
 
-// lineSDF2 returns an SDF2 slice for a line of text
-func lineSDF2(f *truetype.Font, l string) ([]SDF2, float64, error) {
-	iPrev := truetype.Index(0)
-	scale := fixed.Int26_6(f.FUnitsPerEm())
-	xOfs := 0.0
+// ttFont is a golang/freetype TrueType font.
+type ttFont struct {
+	f     *truetype.Font
+	scale fixed.Int26_6
+}
 
-	var ss []SDF2
+// TrueTypeFont returns a text font for a golang/freetype TrueType font.
+func TrueTypeFont(f *truetype.Font) TextFont {
+	return &ttFont{
+		f:     f,
+		scale: fixed.Int26_6(f.FUnitsPerEm()),
+	}
+}
 
-	for _, r := range l {
-		i := f.Index(r)
+func (t *ttFont) metrics() (height, ascent, descent float64) {
+	vm := t.f.VMetric(t.scale, t.f.Index('\n'))
+	bb := t.f.Bounds(t.scale)
+	return float64(vm.AdvanceHeight), float64(bb.Max.Y), float64(-bb.Min.Y)
+}
 
-		// get the glyph metrics
-		hm := f.HMetric(scale, i)
+func (t *ttFont) advance(r rune) (float64, error) {
+	return float64(t.f.HMetric(t.scale, t.f.Index(r)).AdvanceWidth), nil
+}
 
-		// apply kerning
-		k := f.Kern(scale, iPrev, i)
-		xOfs += float64(k)
-		iPrev = i
+func (t *ttFont) kern(r0, r1 rune) float64 {
+	return float64(t.f.Kern(t.scale, t.f.Index(r0), t.f.Index(r1)))
+}
+
+func (t *ttFont) glyph(r rune) (SDF2, error) {
+	g := &truetype.GlyphBuf{}
+	err := g.Load(t.f, t.scale, t.f.Index(r), font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	return glyphConvert(g)
+}
+
+// otFont is an x/image/font/sfnt TrueType or OpenType/CFF font.
+type otFont struct {
+	f    *sfnt.Font
+	buf  sfnt.Buffer
+	ppem fixed.Int26_6
+}
+
+// OpenTypeFont returns a text font for an x/image/font/sfnt font.
+func OpenTypeFont(f *sfnt.Font) TextFont {
+	return &otFont{
+		f:    f,
+		ppem: fixed.Int26_6(f.UnitsPerEm()),
+	}
+}
+
+func (t *otFont) metrics() (height, ascent, descent float64) {
+	m, err := t.f.Metrics(&t.buf, t.ppem, font.HintingNone)
+	if err != nil {
+		return float64(t.ppem), float64(t.ppem), 0
+	}
+	return float64(m.Height), float64(m.Ascent), float64(m.Descent)
+}
+
+func (t *otFont) advance(r rune) (float64, error) {
+	i, err := t.f.GlyphIndex(&t.buf, r)
+	if err != nil {
+		return 0, err
+	}
+	a, err := t.f.GlyphAdvance(&t.buf, i, t.ppem, font.HintingNone)
+	return float64(a), err
+}
+
+func (t *otFont) kern(r0, r1 rune) float64 {
+	i0, err := t.f.GlyphIndex(&t.buf, r0)
+	if err != nil {
+		return 0
+	}
+	i1, err := t.f.GlyphIndex(&t.buf, r1)
+	if err != nil {
+		return 0
+	}
+	// fonts without a kern table return an error
+	k, err := t.f.Kern(&t.buf, i0, i1, t.ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return float64(k)
+}
+
+// curveSteps is the number of line segments for each glyph curve.
+const curveSteps = 8
+
+func fToV2(p fixed.Point26_6) v2.Vec {
+	// sfnt has the y-axis pointing down
+	return v2.Vec{float64(p.X), -float64(p.Y)}
+}
+
+func (t *otFont) glyph(r rune) (SDF2, error) {
+	i, err := t.f.GlyphIndex(&t.buf, r)
+	if err != nil {
+		return nil, err
+	}
+	segs, err := t.f.LoadGlyph(&t.buf, i, t.ppem, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The contours are combined into a single mesh.
+	// The mesh uses the non-zero winding rule, as do the fonts.
+	var lines []*Line2
+	var start, p v2.Vec
+	closeContour := func() {
+		if !p.Equals(start, tolerance) {
+			lines = append(lines, &Line2{p, start})
+		}
+	}
+	for _, s := range segs {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if len(lines) != 0 {
+				closeContour()
+			}
+			start = fToV2(s.Args[0])
+			p = start
+		case sfnt.SegmentOpLineTo:
+			q := fToV2(s.Args[0])
+			lines = append(lines, &Line2{p, q})
+			p = q
+		case sfnt.SegmentOpQuadTo:
+			c, q := fToV2(s.Args[0]), fToV2(s.Args[1])
+			x0 := p
+			for j := 1; j <= curveSteps; j++ {
+				u := float64(j) / curveSteps
+				w := 1 - u
+				x := p.MulScalar(w * w).Add(c.MulScalar(2 * w * u)).Add(q.MulScalar(u * u))
+				lines = append(lines, &Line2{x0, x})
+				x0 = x
+			}
+			p = q
+		case sfnt.SegmentOpCubeTo:
+			c0, c1, q := fToV2(s.Args[0]), fToV2(s.Args[1]), fToV2(s.Args[2])
+			x0 := p
+			for j := 1; j <= curveSteps; j++ {
+				u := float64(j) / curveSteps
+				w := 1 - u
+				x := p.MulScalar(w * w * w).Add(c0.MulScalar(3 * w * w * u)).Add(c1.MulScalar(3 * w * u * u)).Add(q.MulScalar(u * u * u))
+				lines = append(lines, &Line2{x0, x})
+				x0 = x
+			}
+			p = q
+		}
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	closeContour()
+	return Mesh2D(lines)
+}
+
+// LoadTextFont loads a TrueType (*.ttf) or OpenType (*.otf) font file.
+func LoadTextFont(fname string) (TextFont, error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	f, err := sfnt.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	return OpenTypeFont(f), nil
+}
+
+//-----------------------------------------------------------------------------
+// Layout
+
+// textLine is a line of laid out text.
+type textLine struct {
+	runes []rune
+	x     []float64 // x offset of each rune
+	width float64
+}
+
+// textLayout stores the layout of a text object in font units.
+type textLayout struct {
+	lines   []textLine
+	advance float64 // line to line distance
+	ascent  float64
+	descent float64
+	height  float64 // font line height
+}
 
-		// load the glyph
-		g := &truetype.GlyphBuf{}
-		err := g.Load(f, scale, i, font.HintingNone)
+// layoutLine returns the glyph positions for a single line of text.
+func layoutLine(f TextFont, s string, tracking float64) (textLine, error) {
+	l := textLine{runes: []rune(s)}
+	l.x = make([]float64, len(l.runes))
+	x := 0.0
+	for i, r := range l.runes {
+		if i != 0 {
+			x += f.kern(l.runes[i-1], r) + tracking
+		}
+		l.x[i] = x
+		a, err := f.advance(r)
 		if err != nil {
-			return nil, 0, err
+			return textLine{}, err
 		}
+		x += a
+	}
+	l.width = x
+	return l, nil
+}
 
-		s, err := glyphConvert(g)
+// layout lays out the lines of a text object, word wrapping to a maximum width.
+func (t *Text) layout(f TextFont, h float64) (*textLayout, error) {
+	height, ascent, descent := f.metrics()
+	k := height / h
+	lineHeight := t.lineHeight
+	if lineHeight == 0 {
+		lineHeight = 1
+	}
+	tracking := t.tracking * height
+	width := t.width * k
+
+	tl := &textLayout{
+		advance: lineHeight * height,
+		ascent:  ascent,
+		descent: descent,
+		height:  height,
+	}
+	for _, para := range strings.Split(t.s, "\n") {
+		if width <= 0 {
+			l, err := layoutLine(f, para, tracking)
+			if err != nil {
+				return nil, err
+			}
+			tl.lines = append(tl.lines, l)
+			continue
+		}
+		// greedy word wrap, a word wider than the line gets a line to itself
+		words := strings.Split(para, " ")
+		cur, err := layoutLine(f, words[0], tracking)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
-		if s != nil {
-			s = Transform2D(s, Translate2d(v2.Vec{xOfs, 0}))
-			ss = append(ss, s)
+		for _, w := range words[1:] {
+			l, err := layoutLine(f, string(cur.runes)+" "+w, tracking)
+			if err != nil {
+				return nil, err
+			}
+			if l.width > width && len(cur.runes) != 0 {
+				tl.lines = append(tl.lines, cur)
+				l, err = layoutLine(f, w, tracking)
+				if err != nil {
+					return nil, err
+				}
+			}
+			cur = l
+		}
+		tl.lines = append(tl.lines, cur)
+	}
+	return tl, nil
+}
+
+// lineOffset returns the x offset of a line for a horizontal alignment.
+func (l *textLine) lineOffset(halign HAlign) float64 {
+	switch halign {
+	case AlignRight:
+		return -l.width
+	case AlignCenter:
+		return -0.5 * l.width
+	}
+	return 0
+}
+
+// baseline returns the y offset of the first baseline for a vertical alignment.
+func (tl *textLayout) baseline(valign VAlign) float64 {
+	n := float64(len(tl.lines) - 1)
+	switch valign {
+	case AlignTop:
+		return -tl.ascent
+	case AlignMiddle:
+		return 0.5 * (n*tl.advance + tl.descent - tl.ascent)
+	case AlignBottom:
+		return n*tl.advance + tl.descent
+	}
+	return 0
+}
+
+// render returns the SDF2 (in font units) for the laid out text.
+// Each glyph is built in text order. Glyph curves are sampled with the package
+// random source, so reusing glyphs would change the rendering of existing text.
+func (tl *textLayout) render(f TextFont, halign HAlign) (SDF2, error) {
+	var ss []SDF2
+	for i := range tl.lines {
+		l := &tl.lines[i]
+		xOfs := l.lineOffset(halign)
+		yOfs := -float64(i) * tl.advance
+		for j, r := range l.runes {
+			g, err := f.glyph(r)
+			if err != nil {
+				return nil, err
+			}
+			if g != nil {
+				// place the glyph on the line, then the line in the text block
+				g = Transform2D(g, Translate2d(v2.Vec{l.x[j], 0}))
+				ss = append(ss, Transform2D(g, Translate2d(v2.Vec{xOfs, yOfs})))
+			}
 		}
+	}
+	s := Union2D(ss...)
+	if s == nil {
+		return nil, ErrMsg("no glyphs to render")
+	}
+	return s, nil
+}
 
-		xOfs += float64(hm.AdvanceWidth)
+// textCurve returns a single line of text placed along a curve.
+// place returns the position and direction of the curve at an arc length.
+func textCurve(f TextFont, t *Text, h, anchor float64, place func(s float64) (v2.Vec, float64)) (SDF2, error) {
+	if h <= 0 {
+		return nil, ErrMsg("h <= 0")
 	}
+	// the text is a single unwrapped line
+	t0 := *t
+	t0.s = strings.ReplaceAll(t.s, "\n", " ")
+	t0.width = 0
+	tl, err := t0.layout(f, h)
+	if err != nil {
+		return nil, err
+	}
+	k := h / tl.height
+	l := &tl.lines[0]
+	yOfs := tl.baseline(t.valign) * k
+	s0 := anchor + l.lineOffset(t.halign)*k
 
-	return ss, xOfs, nil
+	var ss []SDF2
+	for j, r := range l.runes {
+		g, err := f.glyph(r)
+		if err != nil {
+			return nil, err
+		}
+		if g == nil {
+			continue
+		}
+		a, err := f.advance(r)
+		if err != nil {
+			return nil, err
+		}
+		a *= k
+		// the center of the glyph advance is placed on the curve
+		p, theta := place(s0 + l.x[j]*k + 0.5*a)
+		m := Translate2d(p).Mul(Rotate2d(theta)).Mul(Translate2d(v2.Vec{-0.5 * a, yOfs}))
+		ss = append(ss, Transform2D(ScaleUniform2D(g, k), m))
+	}
+	s := Union2D(ss...)
+	if s == nil {
+		return nil, ErrMsg("no glyphs to render")
+	}
+	return s, nil
 }
 
 //-----------------------------------------------------------------------------
@@ -159,10 +514,36 @@ func lineSDF2(f *truetype.Font, l string) ([]SDF2, float64, error) {
 func NewText(s string) *Text {
 	return &Text{
 		s:      s,
-		halign: cAlign,
+		halign: AlignCenter,
 	}
 }
 
+// SetAlign sets the horizontal and vertical alignment of a text object.
+func (t *Text) SetAlign(h HAlign, v VAlign) *Text {
+	t.halign = h
+	t.valign = v
+	return t
+}
+
+// SetTracking sets the additional spacing between characters (as a fraction of the text height).
+func (t *Text) SetTracking(x float64) *Text {
+	t.tracking = x
+	return t
+}
+
+// SetLineHeight sets the line to line distance (as a multiple of the text height).
+func (t *Text) SetLineHeight(x float64) *Text {
+	t.lineHeight = x
+	return t
+}
+
+// SetWidth sets the maximum line width (in the units of the text height).
+// Lines are word wrapped to the width, 0 disables wrapping.
+func (t *Text) SetWidth(w float64) *Text {
+	t.width = w
+	return t
+}
+
 // LoadFont loads a truetype (*.ttf) font file.
 func LoadFont(fname string) (*truetype.Font, error) {
 	// read the font file
@@ -174,34 +555,109 @@ func LoadFont(fname string) (*truetype.Font, error) {
 }
 
 // Text2D returns a sized SDF2 for a text object.
+// The text block is centered on the origin, see TextLayout2D for aligned text.
 func Text2D(f *truetype.Font, t *Text, h float64) (SDF2, error) {
-	scale := fixed.Int26_6(f.FUnitsPerEm())
-	lines := strings.Split(t.s, "\n")
-	yOfs := 0.0
-	vm := f.VMetric(scale, f.Index('\n'))
-	ah := float64(vm.AdvanceHeight)
+	if h <= 0 {
+		return nil, ErrMsg("h <= 0")
+	}
+	tf := TrueTypeFont(f)
+	tl, err := t.layout(tf, h)
+	if err != nil {
+		return nil, err
+	}
+	s, err := tl.render(tf, t.halign)
+	if err != nil {
+		return nil, err
+	}
+	return CenterAndScale2D(s, h/tl.height), nil
+}
 
-	var ss []SDF2
+// TextLayout2D returns a sized SDF2 for a text object.
+// The text is positioned relative to the origin using the text alignment.
+func TextLayout2D(f TextFont, t *Text, h float64) (SDF2, error) {
+	if h <= 0 {
+		return nil, ErrMsg("h <= 0")
+	}
+	tl, err := t.layout(f, h)
+	if err != nil {
+		return nil, err
+	}
+	s, err := tl.render(f, t.halign)
+	if err != nil {
+		return nil, err
+	}
+	s = Transform2D(s, Translate2d(v2.Vec{0, tl.baseline(t.valign)}))
+	return ScaleUniform2D(s, h/tl.height), nil
+}
 
-	for i := range lines {
-		ssLine, hlen, err := lineSDF2(f, lines[i])
-		if err != nil {
-			return nil, err
-		}
-		xOfs := 0.0
-		if t.halign == rAlign {
-			xOfs = -hlen
-		} else if t.halign == cAlign {
-			xOfs = -hlen / 2.0
+// TextPath2D returns a sized SDF2 for a text object placed along a path.
+// The text is a single line with the baseline on the path and the glyphs to
+// the left of the path direction. The horizontal alignment positions the text at
+// the start, middle or end of the path, the vertical alignment offsets the
+// text from the path.
+func TextPath2D(f TextFont, t *Text, h float64, path *Polygon) (SDF2, error) {
+	vIn := path.Vertices()
+	if path.Closed() && len(vIn) != 0 {
+		vIn = append(vIn, vIn[0])
+	}
+	// remove zero length segments
+	var v []v2.Vec
+	for _, x := range vIn {
+		if len(v) == 0 || !x.Equals(v[len(v)-1], tolerance) {
+			v = append(v, x)
 		}
-		for i := range ssLine {
-			ssLine[i] = Transform2D(ssLine[i], Translate2d(v2.Vec{xOfs, yOfs}))
+	}
+	if len(v) < 2 {
+		return nil, ErrMsg("path has < 2 vertices")
+	}
+	// cumulative path length
+	d := make([]float64, len(v))
+	for i := 1; i < len(v); i++ {
+		d[i] = d[i-1] + v[i].Sub(v[i-1]).Length()
+	}
+	length := d[len(d)-1]
+
+	// positions beyond the ends of the path extend the first/last segment
+	place := func(s float64) (v2.Vec, float64) {
+		i := sort.SearchFloat64s(d, s) - 1
+		if i < 0 {
+			i = 0
+		} else if i > len(v)-2 {
+			i = len(v) - 2
 		}
-		ss = append(ss, ssLine...)
-		yOfs -= ah
+		u := v[i+1].Sub(v[i]).Normalize()
+		return v[i].Add(u.MulScalar(s - d[i])), math.Atan2(u.Y, u.X)
 	}
 
-	return CenterAndScale2D(Union2D(ss...), h/ah), nil
+	anchor := 0.0
+	switch t.halign {
+	case AlignCenter:
+		anchor = 0.5 * length
+	case AlignRight:
+		anchor = length
+	}
+	return textCurve(f, t, h, anchor, place)
+}
+
+// TextArc2D returns a sized SDF2 for a text object placed along a circular arc.
+// The arc is centered on the origin and the horizontal alignment positions the
+// text relative to the angle theta (radians). With radius > 0 the text reads
+// clockwise with the glyphs outside the arc (the top of a knob). With radius < 0
+// the text reads counter-clockwise with the glyphs inside the arc (the bottom of a knob).
+func TextArc2D(f TextFont, t *Text, h, radius, theta float64) (SDF2, error) {
+	if radius == 0 {
+		return nil, ErrMsg("radius == 0")
+	}
+	r := math.Abs(radius)
+	turn := -0.5 * Pi
+	if radius < 0 {
+		turn = 0.5 * Pi
+	}
+	place := func(s float64) (v2.Vec, float64) {
+		a := theta - s/radius
+		return v2.Vec{r * math.Cos(a), r * math.Sin(a)}, a + turn
+	}
+	return textCurve(f, t, h, 0, place)
 }
 
 //-----------------------------------------------------------------------------
@@ -3,12 +3,43 @@
 
 Voxel-based cache/smoothing to remove deep SDF2/SDF3 hierarchies and speed up evaluation
 
+The voxel grid is sparse. The grid is divided into blocks of voxelBlock^3 cells
+and only the blocks near the surface (the narrow band) store the distance at
+every cell corner. The remaining blocks are represented by a coarse grid of
+samples at the block corners.
+
+File Format (little endian):
+
+	magic       [8]byte "SDFXVOX1"
+	bb          6 x float64 (min x,y,z, max x,y,z)
+	origin      3 x float64
+	cellSize    float64
+	blocks      3 x int32
+	apron       int32
+	tricubic    int32
+	coarse      (blocks.x+1)*(blocks.y+1)*(blocks.z+1) x float64
+	nBlocks     int32
+	nBlocks x {
+		index   3 x int32
+		samples n^3 x float64 (n = voxelBlock + 1 + 2*apron)
+	}
+
 */
 //-----------------------------------------------------------------------------
 
 package sdf
 
 import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+
 	"github.com/deadsy/sdfx/vec/conv"
 	v3 "github.com/deadsy/sdfx/vec/v3"
 	"github.com/deadsy/sdfx/vec/v3i"
@@ -16,6 +47,23 @@ import (
 
 //-----------------------------------------------------------------------------
 
+// voxelBlock is the number of cells on each side of a voxel block.
+const voxelBlock = 8
+
+// voxelMagic identifies a voxel file.
+const voxelMagic = "SDFXVOX1"
+
+// voxelMaxBlocks is the maximum number of blocks in a voxel grid (1G cells).
+const voxelMaxBlocks = 1 << 21
+
+// VoxelParms defines the parameters for a voxel SDF3.
+type VoxelParms struct {
+	Cells    int          // number of cells on the longest axis of the bounding box
+	Band     float64      // half width of the narrow band in cells (<= 0 for a dense grid)
+	Tricubic bool         // use tricubic (Catmull-Rom) interpolation, else trilinear
+	Progress chan float64 // progress listener (may be nil)
+}
+
 // VoxelSDF3 is the SDF that represents a pre-computed voxel-based SDF3.
 // It can be used as a cache, or for smoothing.
 //
@@ -23,76 +71,315 @@ import (
 // It can be used to speed up all evaluations required by the surface mesher at the cost of scene setup time and accuracy.
 //
 // SMOOTHING (meshCells <<< renderer's meshCells):
-// It performs trilinear mapping for inner values and may be used as a cache for any other SDF, losing some accuracy.
+// It performs trilinear (or tricubic) mapping for inner values and may be used as a cache for any other SDF, losing some accuracy.
 //
 // WARNING: It may lose sharp features, even if meshCells is high.
+//
+// Away from the narrow band the distance is a conservative (under) estimate.
+// Outside of the bounding box the distance is extrapolated from the box surface.
 type VoxelSDF3 struct {
-	// voxelCorners are the values of this SDF in each voxel corner
-	voxelCorners map[v3i.Vec]float64 // TODO: Octree + k-d tree to simplify/reduce memory consumption + speed-up access?
-	// bb is the bounding box.
-	bb Box3
-	// Number of voxelCorners to consider
-	numVoxels v3i.Vec
+	bb       Box3                  // bounding box
+	origin   v3.Vec                // grid origin
+	cellSize float64               // cell size
+	blocks   v3i.Vec               // number of blocks on each axis
+	apron    int                   // extra samples around each block (tricubic interpolation)
+	tricubic bool                  // tricubic interpolation
+	coarse   []float64             // samples at the block corners
+	block    map[v3i.Vec][]float64 // samples for the narrow band blocks
 }
 
-// NewVoxelSDF3 returns a VoxelSDF3.
-// This populates the whole cache from the given SDF.
-// The progress listener may be nil.
-func NewVoxelSDF3(s SDF3, meshCells int, progress chan float64) SDF3 {
-	bb := s.BoundingBox() // TODO: Use default code to avoid duplication
+//-----------------------------------------------------------------------------
+
+// blockSide returns the number of samples on a side of a block.
+func (m *VoxelSDF3) blockSide() int {
+	return voxelBlock + 1 + 2*m.apron
+}
+
+// coarseIndex returns the index of a coarse sample.
+func (m *VoxelSDF3) coarseIndex(i v3i.Vec) int {
+	return (i.X*(m.blocks.Y+1)+i.Y)*(m.blocks.Z+1) + i.Z
+}
+
+// blockIndex returns the linear index of a block.
+func (m *VoxelSDF3) blockIndex(b v3i.Vec) int {
+	return (b.X*m.blocks.Y+b.Y)*m.blocks.Z + b.Z
+}
+
+// voxelCount returns the product of the grid dimensions.
+// It's an error if a dimension is <= 0 or the product is > limit.
+func voxelCount(limit int, dims ...int) (int, error) {
+	n := 1
+	for _, d := range dims {
+		if d <= 0 || n > limit/d {
+			return 0, errors.New("voxel grid is too large")
+		}
+		n *= d
+	}
+	return n, nil
+}
+
+// blockScale scales a block index to a cell index.
+func blockScale(b v3i.Vec) v3i.Vec {
+	return v3i.Vec{b.X * voxelBlock, b.Y * voxelBlock, b.Z * voxelBlock}
+}
+
+// corner returns the position of a cell corner.
+func (m *VoxelSDF3) corner(i v3i.Vec) v3.Vec {
+	return m.origin.Add(conv.V3iToV3(i).MulScalar(m.cellSize))
+}
+
+// sampleBlock returns the samples for a block.
+func (m *VoxelSDF3) sampleBlock(s SDF3, b v3i.Vec) []float64 {
+	n := m.blockSide()
+	samples := make([]float64, n*n*n)
+	base := blockScale(b).SubScalar(m.apron)
+	k := 0
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			for z := 0; z < n; z++ {
+				samples[k] = s.Evaluate(m.corner(base.Add(v3i.Vec{x, y, z})))
+				k++
+			}
+		}
+	}
+	return samples
+}
+
+// nearSurface returns true if the surface may pass through a block.
+func (m *VoxelSDF3) nearSurface(b v3i.Vec, band float64) bool {
+	if band <= 0 {
+		return true
+	}
+	limit := m.blockDiagonal() + band*m.cellSize
+	for i := 0; i < 8; i++ {
+		c := b.Add(v3i.Vec{i & 1, (i >> 1) & 1, (i >> 2) & 1})
+		if math.Abs(m.coarse[m.coarseIndex(c)]) <= limit {
+			return true
+		}
+	}
+	return false
+}
+
+// blockDiagonal returns the length of the block diagonal.
+func (m *VoxelSDF3) blockDiagonal() float64 {
+	return math.Sqrt(3) * voxelBlock * m.cellSize
+}
+
+// NewSparseVoxelSDF3 returns a sparse VoxelSDF3.
+// Only the blocks within the narrow band of the surface store the full set of samples.
+func NewSparseVoxelSDF3(s SDF3, k *VoxelParms) (*VoxelSDF3, error) {
+	if k.Cells <= 0 {
+		return nil, ErrMsg("Cells <= 0")
+	}
+	bb := s.BoundingBox()
 	bbSize := bb.Size()
-	resolution := bbSize.MaxComponent() / float64(meshCells)
-	cells := conv.V3ToV3i(bbSize.DivScalar(resolution))
-
-	voxelCorners := map[v3i.Vec]float64{}
-	voxelCornerIndex := v3i.Vec{}
-	for voxelCornerIndex.X = 0; voxelCornerIndex.X <= cells.X; voxelCornerIndex.X++ {
-		for voxelCornerIndex.Y = 0; voxelCornerIndex.Y <= cells.Y; voxelCornerIndex.Y++ {
-			for voxelCornerIndex.Z = 0; voxelCornerIndex.Z <= cells.Z; voxelCornerIndex.Z++ {
-				voxelCorner := bb.Min.Add(bbSize.Mul(conv.V3iToV3(voxelCornerIndex)).Div(conv.V3iToV3(cells)))
-				voxelCorners[voxelCornerIndex] = s.Evaluate(voxelCorner)
+	cellSize := bbSize.MaxComponent() / float64(k.Cells)
+	if cellSize <= 0 {
+		return nil, ErrMsg("empty bounding box")
+	}
+	cells := bbSize.DivScalar(cellSize).Ceil()
+	if cells.MaxComponent() > voxelBlock*voxelMaxBlocks {
+		return nil, ErrMsg("Cells is too large")
+	}
+	blocks := conv.V3ToV3i(cells.DivScalar(voxelBlock).Ceil())
+	blocks.X = max(blocks.X, 1)
+	blocks.Y = max(blocks.Y, 1)
+	blocks.Z = max(blocks.Z, 1)
+	if _, err := voxelCount(voxelMaxBlocks, blocks.X, blocks.Y, blocks.Z); err != nil {
+		return nil, err
+	}
+
+	m := &VoxelSDF3{
+		bb:       bb,
+		origin:   bb.Min,
+		cellSize: cellSize,
+		blocks:   blocks,
+		tricubic: k.Tricubic,
+		coarse:   make([]float64, (blocks.X+1)*(blocks.Y+1)*(blocks.Z+1)),
+		block:    make(map[v3i.Vec][]float64),
+	}
+	if k.Tricubic {
+		m.apron = 1
+	}
+
+	// coarse samples at the block corners
+	var i v3i.Vec
+	for i.X = 0; i.X <= blocks.X; i.X++ {
+		for i.Y = 0; i.Y <= blocks.Y; i.Y++ {
+			for i.Z = 0; i.Z <= blocks.Z; i.Z++ {
+				m.coarse[m.coarseIndex(i)] = s.Evaluate(m.corner(blockScale(i)))
 			}
 		}
-		if progress != nil {
-			progress <- float64(voxelCornerIndex.X) / float64(cells.X)
+	}
+
+	// work out the narrow band blocks
+	var near []v3i.Vec
+	var b v3i.Vec
+	for b.X = 0; b.X < blocks.X; b.X++ {
+		for b.Y = 0; b.Y < blocks.Y; b.Y++ {
+			for b.Z = 0; b.Z < blocks.Z; b.Z++ {
+				if m.nearSurface(b, k.Band) {
+					near = append(near, b)
+				}
+			}
 		}
 	}
 
-	return &VoxelSDF3{
-		voxelCorners: voxelCorners,
-		bb:           bb,
-		numVoxels:    cells,
+	// sample the narrow band blocks in parallel
+	type result struct {
+		b       v3i.Vec
+		samples []float64
 	}
+	jobs := make(chan v3i.Vec)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				results <- result{b, m.sampleBlock(s, b)}
+			}
+		}()
+	}
+	go func() {
+		for _, b := range near {
+			jobs <- b
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	n := 0
+	for r := range results {
+		m.block[r.b] = r.samples
+		n++
+		if k.Progress != nil {
+			k.Progress <- float64(n) / float64(len(near))
+		}
+	}
+
+	return m, nil
 }
 
-// Evaluate returns the minimum distance to a VoxelSDF3.
-func (m *VoxelSDF3) Evaluate(p v3.Vec) float64 {
-	// Find the voxel's {0,0,0} corner quickly and compute p's displacement
-	voxelSize := m.bb.Size().Div(conv.V3iToV3(m.numVoxels))
-	voxelStartIndex := conv.V3ToV3i(p.Sub(m.bb.Min).Div(voxelSize))
-	voxelStart := m.bb.Min.Add(voxelSize.Mul(conv.V3iToV3(voxelStartIndex)))
-	d := p.Sub(voxelStart).Div(voxelSize) // [0, 1) for each dimension
-	// Get the values at the voxel's corners
-	c000 := m.voxelCorners[voxelStartIndex]
-	c001 := m.voxelCorners[voxelStartIndex.Add(v3i.Vec{0, 0, 1})]
-	c010 := m.voxelCorners[voxelStartIndex.Add(v3i.Vec{0, 1, 0})]
-	c011 := m.voxelCorners[voxelStartIndex.Add(v3i.Vec{0, 1, 1})]
-	c100 := m.voxelCorners[voxelStartIndex.Add(v3i.Vec{1, 0, 0})]
-	c101 := m.voxelCorners[voxelStartIndex.Add(v3i.Vec{1, 0, 1})]
-	c110 := m.voxelCorners[voxelStartIndex.Add(v3i.Vec{1, 1, 0})]
-	c111 := m.voxelCorners[voxelStartIndex.Add(v3i.Vec{1, 1, 1})]
-	// Perform trilinear interpolation over the voxel's corners
+// NewVoxelSDF3 returns a VoxelSDF3.
+// This populates the whole cache from the given SDF.
+// The progress listener may be nil. meshCells is clamped to >= 1. An SDF
+// with an empty bounding box has nothing to cache and is returned as is.
+func NewVoxelSDF3(s SDF3, meshCells int, progress chan float64) SDF3 {
+	if s.BoundingBox().Size().MaxComponent() <= 0 {
+		return s
+	}
+	m, err := NewSparseVoxelSDF3(s, &VoxelParms{
+		Cells:    max(meshCells, 1),
+		Progress: progress,
+	})
+	if err != nil {
+		// the inputs have been checked
+		return s
+	}
+	return m
+}
+
+//-----------------------------------------------------------------------------
+
+// cubicWeights returns the Catmull-Rom weights for samples -1, 0, 1, 2.
+func cubicWeights(t float64) [4]float64 {
+	t2 := t * t
+	t3 := t2 * t
+	return [4]float64{
+		0.5 * (-t3 + 2*t2 - t),
+		0.5 * (3*t3 - 5*t2 + 2),
+		0.5 * (-3*t3 + 4*t2 + t),
+		0.5 * (t3 - t2),
+	}
+}
+
+// trilinear returns the trilinear interpolation of 8 corner values.
+func trilinear(c [8]float64, d v3.Vec) float64 {
 	// - 4 linear interpolations
-	c00 := c000*(1-d.X) + c100*d.X
-	c01 := c001*(1-d.X) + c101*d.X
-	c10 := c010*(1-d.X) + c110*d.X
-	c11 := c011*(1-d.X) + c111*d.X
+	c00 := c[0]*(1-d.X) + c[4]*d.X
+	c01 := c[1]*(1-d.X) + c[5]*d.X
+	c10 := c[2]*(1-d.X) + c[6]*d.X
+	c11 := c[3]*(1-d.X) + c[7]*d.X
 	// - 2 bilinear interpolations
 	c0 := c00*(1-d.Y) + c10*d.Y
 	c1 := c01*(1-d.Y) + c11*d.Y
 	// - 1 trilinear interpolation
-	c := c0*(1-d.Z) + c1*d.Z
-	return c
+	return c0*(1-d.Z) + c1*d.Z
+}
+
+// cellOf returns the cell index containing a point and the point's position within the cell.
+func cellOf(u v3.Vec, n v3i.Vec) (v3i.Vec, v3.Vec) {
+	i := v3i.Vec{
+		int(Clamp(math.Floor(u.X), 0, float64(n.X-1))),
+		int(Clamp(math.Floor(u.Y), 0, float64(n.Y-1))),
+		int(Clamp(math.Floor(u.Z), 0, float64(n.Z-1))),
+	}
+	return i, u.Sub(conv.V3iToV3(i))
+}
+
+// farDistance returns the distance for a point outside of the narrow band.
+func (m *VoxelSDF3) farDistance(p v3.Vec) float64 {
+	u := p.Sub(m.origin).DivScalar(voxelBlock * m.cellSize)
+	i, d := cellOf(u, m.blocks)
+	var c [8]float64
+	for j := range c {
+		c[j] = m.coarse[m.coarseIndex(i.Add(v3i.Vec{(j >> 2) & 1, (j >> 1) & 1, j & 1}))]
+	}
+	dist := trilinear(c, d)
+	// The interpolated distance may overestimate the real distance by up to
+	// the block diagonal. Return a conservative estimate.
+	if dist > 0 {
+		return math.Max(dist-m.blockDiagonal(), 0)
+	}
+	return math.Min(dist+m.blockDiagonal(), 0)
+}
+
+// Evaluate returns the minimum distance to a VoxelSDF3.
+func (m *VoxelSDF3) Evaluate(p v3.Vec) float64 {
+	// outside the bounding box: extrapolate from the box surface
+	q := p.Clamp(m.bb.Min, m.bb.Max)
+	if q != p {
+		return m.Evaluate(q) + p.Sub(q).Length()
+	}
+
+	u := p.Sub(m.origin).DivScalar(m.cellSize)
+	cell, d := cellOf(u, blockScale(m.blocks))
+	b := v3i.Vec{cell.X / voxelBlock, cell.Y / voxelBlock, cell.Z / voxelBlock}
+	samples, ok := m.block[b]
+	if !ok {
+		return m.farDistance(p)
+	}
+
+	// local sample index (with the apron offset)
+	n := m.blockSide()
+	base := blockScale(b)
+	l := v3i.Vec{cell.X - base.X, cell.Y - base.Y, cell.Z - base.Z}.AddScalar(m.apron)
+	sample := func(x, y, z int) float64 {
+		return samples[((l.X+x)*n+(l.Y+y))*n+l.Z+z]
+	}
+
+	if !m.tricubic {
+		var c [8]float64
+		for j := range c {
+			c[j] = sample((j>>2)&1, (j>>1)&1, j&1)
+		}
+		return trilinear(c, d)
+	}
+
+	wx := cubicWeights(d.X)
+	wy := cubicWeights(d.Y)
+	wz := cubicWeights(d.Z)
+	var dist float64
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			for z := 0; z < 4; z++ {
+				dist += wx[x] * wy[y] * wz[z] * sample(x-1, y-1, z-1)
+			}
+		}
+	}
+	return dist
 }
 
 // BoundingBox returns the bounding box for a VoxelSDF3.
@@ -100,4 +387,158 @@ func (m *VoxelSDF3) BoundingBox() Box3 {
 	return m.bb
 }
 
+// Blocks returns the number of stored (narrow band) blocks and the total number of blocks.
+func (m *VoxelSDF3) Blocks() (int, int) {
+	return len(m.block), m.blocks.X * m.blocks.Y * m.blocks.Z
+}
+
+//-----------------------------------------------------------------------------
+// Serialization
+
+// Write writes a VoxelSDF3 to a writer.
+func (m *VoxelSDF3) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	put := func(data any) error {
+		return binary.Write(bw, binary.LittleEndian, data)
+	}
+	hdr := []any{
+		[]byte(voxelMagic),
+		[]float64{m.bb.Min.X, m.bb.Min.Y, m.bb.Min.Z, m.bb.Max.X, m.bb.Max.Y, m.bb.Max.Z},
+		[]float64{m.origin.X, m.origin.Y, m.origin.Z, m.cellSize},
+		[]int32{int32(m.blocks.X), int32(m.blocks.Y), int32(m.blocks.Z), int32(m.apron), boolToInt32(m.tricubic)},
+		m.coarse,
+		int32(len(m.block)),
+	}
+	for _, x := range hdr {
+		if err := put(x); err != nil {
+			return err
+		}
+	}
+	// write the blocks in index order so the output is repeatable
+	keys := make([]v3i.Vec, 0, len(m.block))
+	for b := range m.block {
+		keys = append(keys, b)
+	}
+	sort.Slice(keys, func(i, j int) bool { return m.blockIndex(keys[i]) < m.blockIndex(keys[j]) })
+	for _, b := range keys {
+		if err := put([]int32{int32(b.X), int32(b.Y), int32(b.Z)}); err != nil {
+			return err
+		}
+		if err := put(m.block[b]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func boolToInt32(x bool) int32 {
+	if x {
+		return 1
+	}
+	return 0
+}
+
+// ReadVoxelSDF3 reads a VoxelSDF3 from a reader.
+func ReadVoxelSDF3(r io.Reader) (*VoxelSDF3, error) {
+	br := bufio.NewReader(r)
+	get := func(data any) error {
+		return binary.Read(br, binary.LittleEndian, data)
+	}
+	magic := make([]byte, len(voxelMagic))
+	if err := get(magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != voxelMagic {
+		return nil, errors.New("not a voxel file")
+	}
+	var f [10]float64
+	var n [5]int32
+	if err := get(&f); err != nil {
+		return nil, err
+	}
+	if err := get(&n); err != nil {
+		return nil, err
+	}
+	for _, x := range f {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, errors.New("bad voxel file header")
+		}
+	}
+	if n[3] < 0 || n[3] > 1 || f[9] <= 0 {
+		return nil, errors.New("bad voxel file header")
+	}
+	nBlocksMax, err := voxelCount(voxelMaxBlocks, int(n[0]), int(n[1]), int(n[2]))
+	if err != nil {
+		return nil, err
+	}
+	nCoarse, err := voxelCount(8*voxelMaxBlocks, int(n[0])+1, int(n[1])+1, int(n[2])+1)
+	if err != nil {
+		return nil, err
+	}
+	m := &VoxelSDF3{
+		bb:       Box3{v3.Vec{f[0], f[1], f[2]}, v3.Vec{f[3], f[4], f[5]}},
+		origin:   v3.Vec{f[6], f[7], f[8]},
+		cellSize: f[9],
+		blocks:   v3i.Vec{int(n[0]), int(n[1]), int(n[2])},
+		apron:    int(n[3]),
+		tricubic: n[4] != 0,
+		block:    make(map[v3i.Vec][]float64),
+	}
+	m.coarse = make([]float64, nCoarse)
+	if err := get(m.coarse); err != nil {
+		return nil, err
+	}
+	var nBlocks int32
+	if err := get(&nBlocks); err != nil {
+		return nil, err
+	}
+	if nBlocks < 0 || int(nBlocks) > nBlocksMax {
+		return nil, errors.New("bad voxel block count")
+	}
+	side := m.blockSide()
+	for i := 0; i < int(nBlocks); i++ {
+		var idx [3]int32
+		if err := get(&idx); err != nil {
+			return nil, err
+		}
+		b := v3i.Vec{int(idx[0]), int(idx[1]), int(idx[2])}
+		if b.X < 0 || b.Y < 0 || b.Z < 0 || b.X >= m.blocks.X || b.Y >= m.blocks.Y || b.Z >= m.blocks.Z {
+			return nil, errors.New("bad voxel block index")
+		}
+		if _, ok := m.block[b]; ok {
+			return nil, errors.New("duplicate voxel block")
+		}
+		samples := make([]float64, side*side*side)
+		if err := get(samples); err != nil {
+			return nil, err
+		}
+		m.block[b] = samples
+	}
+	return m, nil
+}
+
+// SaveVoxelSDF3 saves a VoxelSDF3 to a file.
+func SaveVoxelSDF3(path string, m *VoxelSDF3) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = m.Write(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadVoxelSDF3 loads a VoxelSDF3 from a file.
+func LoadVoxelSDF3(path string) (*VoxelSDF3, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadVoxelSDF3(f)
+}
+
 //-----------------------------------------------------------------------------